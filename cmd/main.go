@@ -1,12 +1,14 @@
 package main
 
 import (
+	"os"
+
 	"github.com/alexanderbkl/vidre-back/internal/commands"
 	"github.com/alexanderbkl/vidre-back/internal/event"
 )
 var log = event.Log
 
 func main() {
-	commands.Start()
+	commands.Run(os.Args[1:])
 	log.Println("Vidre started.")
 }
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/alexanderbkl/vidre-back/internal/constant"
+	"github.com/alexanderbkl/vidre-back/internal/db"
+	"github.com/alexanderbkl/vidre-back/internal/entity"
+	"github.com/alexanderbkl/vidre-back/internal/form"
+	"github.com/gin-gonic/gin"
+)
+
+// GetApiKeys returns all api keys, including expired and revoked ones.
+//
+// GET /api/api_keys
+func GetApiKeys(router *gin.RouterGroup) {
+	router.GET("/api_keys", func(ctx *gin.Context) {
+		var keys entity.ApiKeys
+		if err := db.Db().Order("created_at DESC").Find(&keys).Error; err != nil {
+			log.Errorf("cannot find api keys: %s", err)
+			ctx.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusOK, keys)
+	})
+}
+
+// CreateApiKey issues a new api key with the given scopes.
+//
+// The key is only returned once; it is sent as "Authorization: ApiKey <key>".
+//
+// POST /api/api_keys
+// - JSON body:
+//   - name: string
+//   - scopes: []string
+//   - expires_at: time (optional)
+func CreateApiKey(router *gin.RouterGroup) {
+	router.POST("/api_keys", func(ctx *gin.Context) {
+		var req form.CreateApiKeyRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		for _, scope := range req.Scopes {
+			if !constant.ValidScope(scope) {
+				ctx.JSON(http.StatusBadRequest, ErrorResponse(fmt.Errorf("invalid scope %s", scope)))
+				return
+			}
+		}
+
+		if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse(errors.New("expires_at must be in the future")))
+			return
+		}
+
+		key := entity.ApiKey{
+			Name:      req.Name,
+			ExpiresAt: req.ExpiresAt,
+		}
+
+		if payload := authPayload(ctx); payload != nil {
+			key.CreatedBy = payload.UserID
+		}
+
+		key.SetScopes(req.Scopes)
+		secret := key.NewSecret()
+
+		if err := key.Create(); err != nil {
+			log.Errorf("cannot create api key: %s", err)
+			ctx.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"api_key": key,
+			"key":     secret,
+		})
+	})
+}
+
+// RevokeApiKey revokes an api key. Revoked keys are kept for reference.
+//
+// DELETE /api/api_keys/:uid
+func RevokeApiKey(router *gin.RouterGroup) {
+	router.DELETE("/api_keys/:uid", func(ctx *gin.Context) {
+		key, err := entity.FindApiKeyByUID(ctx.Param("uid"))
+		if err != nil {
+			AbortEntityNotFound(ctx)
+			return
+		}
+
+		if err := key.Revoke(); err != nil {
+			log.Errorf("cannot revoke api key: %s", err)
+			AbortSaveFailed(ctx)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, key)
+	})
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/alexanderbkl/vidre-back/internal/audit"
+	"github.com/alexanderbkl/vidre-back/internal/clock"
+	"github.com/alexanderbkl/vidre-back/internal/constant"
+	"github.com/alexanderbkl/vidre-back/internal/db"
+	"github.com/alexanderbkl/vidre-back/internal/entity"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetAudit returns the audit log of an entity in chronological order: who
+// changed it, when and why, with the values of the changed fields before and
+// after. Entities are worker, work_schedule, extra_hour and festivo, which
+// is identified by its date. Without id the log of all entities of the kind
+// is returned.
+//
+// GET /api/audit?entity=&id=
+func GetAudit(router *gin.RouterGroup) {
+	router.GET("/audit", func(ctx *gin.Context) {
+		var payload struct {
+			Entity string `form:"entity" binding:"required"`
+			ID     string `form:"id"`
+		}
+
+		if err := ctx.ShouldBindQuery(&payload); err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		if !entity.ValidAuditEntity(payload.Entity) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid entity"})
+			return
+		}
+
+		logs, err := entity.FindAuditLogs(payload.Entity, payload.ID)
+		if err != nil {
+			log.Errorf("cannot find audit logs: %s", err)
+			ctx.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"audit": logs})
+	})
+}
+
+// auditActor returns the user, device or api key of the request.
+func auditActor(ctx *gin.Context) audit.Actor {
+	var actor audit.Actor
+
+	if p := authPayload(ctx); p != nil {
+		if p.UserID != 0 {
+			actor.UserID = &p.UserID
+			actor.UserName = p.UserName
+		}
+		if p.DeviceID != 0 {
+			actor.DeviceID = &p.DeviceID
+		}
+	}
+
+	if value, ok := ctx.Get(constant.AuthorizationApiKeyKey); ok {
+		if key, ok := value.(*entity.ApiKey); ok {
+			actor.ApiKeyID = &key.ID
+		}
+	}
+
+	return actor
+}
+
+// checkReason responds with an error and returns false if the reason of a
+// change is missing or too long.
+func checkReason(ctx *gin.Context, reason string) bool {
+	if err := audit.ValidateReason(reason); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse(err))
+		return false
+	}
+
+	return true
+}
+
+// auditWorkDays runs fn within a transaction and records the changes of the
+// work days of the worker on the dates in the audit log.
+func auditWorkDays(ctx *gin.Context, reason string, workerID uint, dates []time.Time, fn func(tx *gorm.DB) error) error {
+	actor := auditActor(ctx)
+
+	return db.Db().Transaction(func(tx *gorm.DB) error {
+		before := make([]*entity.WorkSchedule, len(dates))
+		for i, date := range dates {
+			day, err := clock.TxDay(tx, workerID, date)
+			if err != nil {
+				return err
+			}
+			before[i] = day
+		}
+
+		if err := fn(tx); err != nil {
+			return err
+		}
+
+		for i, date := range dates {
+			after, err := clock.TxDay(tx, workerID, date)
+			if err != nil {
+				return err
+			}
+
+			if err := audit.WorkDay(tx, actor, reason, before[i], after); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,158 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/alexanderbkl/vidre-back/internal/config"
+	"github.com/alexanderbkl/vidre-back/internal/constant"
+	"github.com/alexanderbkl/vidre-back/internal/entity"
+	"github.com/alexanderbkl/vidre-back/internal/form"
+	"github.com/alexanderbkl/vidre-back/pkg/token"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+var errInvalidCredentials = errors.New("invalid name or password")
+
+// Login authenticates a user by name and password and returns an access and refresh token.
+// Users with two-factor authentication must also send a TOTP or recovery code.
+//
+// POST /api/auth/login
+// - JSON body:
+//   - name: string
+//   - password: string
+//   - otp: string (optional)
+//   - recovery_code: string (optional)
+func Login(router *gin.RouterGroup, tokenMaker token.Maker) {
+	router.POST("/auth/login", func(ctx *gin.Context) {
+		var req form.LoginPasswordRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		user, err := checkCredentials(req)
+		if err != nil {
+			ctx.JSON(http.StatusUnauthorized, ErrorResponse(err))
+			return
+		}
+
+		if err := checkSecondFactor(user, req.Otp, req.RecoveryCode); err != nil {
+			ctx.JSON(secondFactorStatus(err), ErrorResponse(err))
+			return
+		}
+
+		rsp, err := createLoginResponse(ctx, tokenMaker, user)
+		if err != nil {
+			log.Errorf("cannot create tokens: %s", err)
+			ctx.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusOK, rsp)
+	})
+}
+
+// Logout revokes the session of the current access token.
+//
+// POST /api/auth/logout
+func Logout(router *gin.RouterGroup) {
+	router.POST("/auth/logout", func(ctx *gin.Context) {
+		payload := authPayload(ctx)
+		if payload == nil {
+			AbortUnauthorized(ctx)
+			return
+		}
+
+		session, err := entity.FindSession(payload.SessionID)
+		if err != nil {
+			AbortEntityNotFound(ctx)
+			return
+		}
+
+		if err := session.Block(); err != nil {
+			log.Errorf("cannot block session: %s", err)
+			AbortSaveFailed(ctx)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"message": "Logged out"})
+	})
+}
+
+// checkCredentials returns the user if the name and password are valid.
+func checkCredentials(req form.LoginPasswordRequest) (*entity.User, error) {
+	user, err := entity.FindUserByName(req.Name)
+	if err != nil || !user.CheckPassword(req.Password) {
+		log.Debugf("api: login failed for %s", req.Name)
+		return nil, errInvalidCredentials
+	}
+
+	return user, nil
+}
+
+// authPayload returns the token payload set by the auth middleware, if any.
+func authPayload(ctx *gin.Context) *token.Payload {
+	if payload, ok := ctx.Get(constant.AuthorizationPayloadKey); ok {
+		if p, ok := payload.(*token.Payload); ok {
+			return p
+		}
+	}
+
+	return nil
+}
+
+// createLoginResponse starts a new session for the user and issues an access and refresh token for it.
+func createLoginResponse(ctx *gin.Context, tokenMaker token.Maker, user *entity.User) (*form.LoginUserResponse, error) {
+	sessionID, err := uuid.NewRandom()
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, accessPayload, err := tokenMaker.CreateToken(
+		user.ID,
+		user.UID,
+		user.Name,
+		config.Env().AccessTokenDuration,
+		token.WithSession(sessionID),
+		token.WithRole(user.Role),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, refreshPayload, err := tokenMaker.CreateToken(
+		user.ID,
+		user.UID,
+		user.Name,
+		config.Env().RefreshTokenDuration,
+		token.WithPurpose(token.PurposeRefresh),
+		token.WithSession(sessionID),
+		token.WithRole(user.Role),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	session := entity.Session{
+		ID:             sessionID,
+		UserID:         user.ID,
+		RefreshTokenID: refreshPayload.TokenID,
+		UserAgent:      ctx.Request.UserAgent(),
+		ClientIP:       ctx.ClientIP(),
+		ExpiresAt:      refreshPayload.ExpiredAt,
+	}
+
+	if err := session.Create(); err != nil {
+		return nil, err
+	}
+
+	return &form.LoginUserResponse{
+		SessionID:             session.ID,
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  accessPayload.ExpiredAt,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: refreshPayload.ExpiredAt,
+	}, nil
+}
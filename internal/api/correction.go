@@ -0,0 +1,272 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/alexanderbkl/vidre-back/internal/clock"
+	"github.com/alexanderbkl/vidre-back/internal/config"
+	"github.com/alexanderbkl/vidre-back/internal/constant"
+	"github.com/alexanderbkl/vidre-back/internal/correction"
+	"github.com/alexanderbkl/vidre-back/internal/db"
+	"github.com/alexanderbkl/vidre-back/internal/entity"
+	"github.com/alexanderbkl/vidre-back/internal/form"
+	"github.com/alexanderbkl/vidre-back/internal/query"
+	"github.com/alexanderbkl/vidre-back/pkg/token"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// PostCorrection files a request to correct a single time of a work day,
+// e.g. a forgotten exit, with a reason. Workers and kiosks must enter the
+// PIN of the worker if it has one. Worker accounts can only file requests
+// for workers with a PIN.
+//
+// POST /api/corrections
+func PostCorrection(router *gin.RouterGroup) {
+	router.POST("/corrections", func(ctx *gin.Context) {
+		var payload form.CreateCorrectionRequest
+		if err := ctx.ShouldBindJSON(&payload); err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		worker, err := query.GetWorkerFromCode(payload.WorkerCode)
+		if err != nil {
+			AbortEntityNotFound(ctx)
+			return
+		}
+
+		request := entity.CorrectionRequest{
+			WorkerID:   worker.ID,
+			Type:       payload.Type,
+			BreakIndex: payload.BreakIndex,
+			BreakType:  payload.BreakType,
+			Reason:     payload.Reason,
+		}
+
+		p := authPayload(ctx)
+		if p != nil && p.UserID != 0 {
+			request.UserID = &p.UserID
+		}
+		if p != nil && p.DeviceID != 0 {
+			request.DeviceID = &p.DeviceID
+		}
+
+		if p == nil || !reviewsCorrections(p.Role) {
+			// Worker accounts are not bound to a worker, the PIN proves
+			// that the request is filed by the worker.
+			if p != nil && p.Role == constant.RoleWorker && !worker.HasPin() {
+				Abort(ctx, http.StatusForbidden, "The worker has no PIN, ask a manager to file the request")
+				return
+			}
+
+			if status, msg := checkPin(ctx, worker, payload.Pin, request.DeviceID); status != 0 {
+				ctx.JSON(status, gin.H{"error": msg})
+				return
+			}
+		}
+
+		if request.Date, err = time.Parse("2006-01-02", payload.Date); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format"})
+			return
+		}
+
+		if payload.Time == "" && payload.Type == entity.PunchDeleteBreak {
+			request.Time = time.Now().In(config.Location())
+		} else if request.Time, err = clock.ParseTime(payload.Time, config.Location()); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid time format"})
+			return
+		}
+
+		if err := correction.Validate(&request, time.Now(), config.Location()); err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		if err := correction.File(&request); errors.Is(err, correction.ErrInvalidBreak) {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		} else if err != nil {
+			log.Errorf("cannot file correction request: %s", err)
+			AbortSaveFailed(ctx)
+			return
+		}
+
+		ctx.JSON(http.StatusCreated, gin.H{"correction": request.In(config.Location())})
+	})
+}
+
+// GetCorrections returns correction requests with their status history,
+// optionally filtered by status, worker and work day.
+//
+// GET /api/corrections?status=&worker_code=&start_date=&end_date=
+func GetCorrections(router *gin.RouterGroup) {
+	router.GET("/corrections", func(ctx *gin.Context) {
+		var payload struct {
+			Status     string `form:"status"`
+			WorkerCode string `form:"worker_code"`
+			StartDate  string `form:"start_date"`
+			EndDate    string `form:"end_date"`
+		}
+
+		if err := ctx.ShouldBindQuery(&payload); err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		q := db.Db().Preload("History", func(tx *gorm.DB) *gorm.DB {
+			return tx.Order("id")
+		})
+
+		if payload.Status != "" {
+			q = q.Where("status = ?", payload.Status)
+		}
+
+		if payload.WorkerCode != "" {
+			workerId, err := query.GetWorkerIDFromCode(payload.WorkerCode)
+			if err != nil {
+				AbortEntityNotFound(ctx)
+				return
+			}
+			q = q.Where("worker_id = ?", workerId)
+		}
+
+		if payload.StartDate != "" {
+			startDate, err := time.Parse("2006-01-02", payload.StartDate)
+			if err != nil {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start date format"})
+				return
+			}
+			q = q.Where("date >= ?", startDate)
+		}
+
+		if payload.EndDate != "" {
+			endDate, err := time.Parse("2006-01-02", payload.EndDate)
+			if err != nil {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end date format"})
+				return
+			}
+			q = q.Where("date <= ?", endDate)
+		}
+
+		var requests entity.CorrectionRequests
+		if err := q.Order("id").Find(&requests).Error; err != nil {
+			log.Errorf("cannot find correction requests: %s", err)
+			ctx.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			return
+		}
+
+		for i := range requests {
+			requests[i].In(config.Location())
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"corrections": requests})
+	})
+}
+
+// GetCorrection returns a correction request with its status history, so
+// that the worker can follow it. Workers and kiosks can only see requests
+// they filed.
+//
+// GET /api/corrections/:uid
+func GetCorrection(router *gin.RouterGroup) {
+	router.GET("/corrections/:uid", func(ctx *gin.Context) {
+		request, err := entity.FindCorrectionRequest(ctx.Param("uid"))
+		if err != nil {
+			AbortEntityNotFound(ctx)
+			return
+		}
+
+		if !canViewCorrection(authPayload(ctx), request) {
+			AbortEntityNotFound(ctx)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"correction": request.In(config.Location())})
+	})
+}
+
+// reviewsCorrections tests if users with the role review the correction
+// requests of all workers.
+func reviewsCorrections(role string) bool {
+	return role == constant.RoleAdmin || role == constant.RoleManager
+}
+
+// canViewCorrection tests if a correction request can be seen with the
+// token. Requests of others are treated as not found.
+func canViewCorrection(p *token.Payload, request *entity.CorrectionRequest) bool {
+	if p == nil {
+		return false
+	} else if reviewsCorrections(p.Role) {
+		return true
+	}
+
+	return request.FiledBy(p.UserID, p.DeviceID)
+}
+
+// ApproveCorrection approves a pending correction request and applies it to
+// the work day as a correction punch. The reason of the request is recorded
+// in the audit log of the work day. Requests for a break that no longer
+// exists are answered with 400 Bad Request.
+//
+// POST /api/corrections/:uid/approve
+func ApproveCorrection(router *gin.RouterGroup) {
+	router.POST("/corrections/:uid/approve", func(ctx *gin.Context) {
+		var payload form.ReviewCorrectionRequest
+		if err := ctx.ShouldBindJSON(&payload); err != nil && ctx.Request.ContentLength != 0 {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		request, workSchedule, err := correction.Approve(ctx.Param("uid"), auditActor(ctx), payload.Note)
+		if err != nil {
+			abortCorrection(ctx, err)
+			return
+		}
+
+		if workSchedule != nil {
+			workSchedule.In(config.Location())
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"correction": request.In(config.Location()), "work_schedule": workSchedule})
+	})
+}
+
+// RejectCorrection rejects a pending correction request. The note explains
+// the rejection to the worker.
+//
+// POST /api/corrections/:uid/reject
+func RejectCorrection(router *gin.RouterGroup) {
+	router.POST("/corrections/:uid/reject", func(ctx *gin.Context) {
+		var payload form.ReviewCorrectionRequest
+		if err := ctx.ShouldBindJSON(&payload); err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		request, err := correction.Reject(ctx.Param("uid"), auditActor(ctx), payload.Note)
+		if err != nil {
+			abortCorrection(ctx, err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"correction": request.In(config.Location())})
+	})
+}
+
+// abortCorrection aborts with the status code of a review error.
+func abortCorrection(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		AbortEntityNotFound(ctx)
+	case errors.Is(err, correction.ErrNotPending):
+		Abort(ctx, http.StatusConflict, "Correction request has already been reviewed")
+	case errors.Is(err, correction.ErrNoteRequired), errors.Is(err, correction.ErrTextTooLong), errors.Is(err, correction.ErrInvalidBreak):
+		ctx.JSON(http.StatusBadRequest, ErrorResponse(err))
+	default:
+		log.Errorf("cannot review correction request: %s", err)
+		AbortSaveFailed(ctx)
+	}
+}
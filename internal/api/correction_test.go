@@ -0,0 +1,42 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/alexanderbkl/vidre-back/internal/constant"
+	"github.com/alexanderbkl/vidre-back/internal/entity"
+	"github.com/alexanderbkl/vidre-back/pkg/token"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanViewCorrection(t *testing.T) {
+	userID, deviceID := uint(1), uint(2)
+
+	byUser := entity.CorrectionRequest{UserID: &userID}
+	byDevice := entity.CorrectionRequest{DeviceID: &deviceID}
+
+	testCases := []struct {
+		name     string
+		payload  *token.Payload
+		request  entity.CorrectionRequest
+		expected bool
+	}{
+		{"Anonymous", nil, byUser, false},
+		{"Admin", &token.Payload{UserID: 9, Role: constant.RoleAdmin}, byUser, true},
+		{"Manager", &token.Payload{UserID: 9, Role: constant.RoleManager}, byDevice, true},
+		{"OwnRequest", &token.Payload{UserID: 1, Role: constant.RoleWorker}, byUser, true},
+		{"OtherWorker", &token.Payload{UserID: 3, Role: constant.RoleWorker}, byUser, false},
+		{"OwnDevice", &token.Payload{DeviceID: 2, Role: constant.RoleKiosk}, byDevice, true},
+		{"OtherDevice", &token.Payload{DeviceID: 4, Role: constant.RoleKiosk}, byDevice, false},
+		{"DeviceOfUser", &token.Payload{DeviceID: 2, Role: constant.RoleKiosk}, byUser, false},
+		{"NoFiler", &token.Payload{Role: constant.RoleKiosk}, entity.CorrectionRequest{}, false},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, canViewCorrection(tc.payload, &tc.request))
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/alexanderbkl/vidre-back/internal/config"
+	"github.com/alexanderbkl/vidre-back/internal/constant"
+	"github.com/alexanderbkl/vidre-back/internal/db"
+	"github.com/alexanderbkl/vidre-back/internal/entity"
+	"github.com/alexanderbkl/vidre-back/internal/form"
+	"github.com/alexanderbkl/vidre-back/pkg/token"
+	"github.com/gin-gonic/gin"
+)
+
+var errInvalidDeviceCredentials = errors.New("invalid device id or secret")
+
+// GetDevices returns all enrolled devices.
+//
+// GET /api/devices
+func GetDevices(router *gin.RouterGroup) {
+	router.GET("/devices", func(ctx *gin.Context) {
+		var devices entity.Devices
+		if err := db.Db().Order("name").Find(&devices).Error; err != nil {
+			log.Errorf("cannot find devices: %s", err)
+			ctx.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusOK, devices)
+	})
+}
+
+// CreateDevice registers a new device and returns its enrollment secret.
+//
+// The secret is only returned once; it is needed to enroll the device.
+//
+// POST /api/devices
+// - JSON body:
+//   - name: string
+//   - site: string
+func CreateDevice(router *gin.RouterGroup) {
+	router.POST("/devices", func(ctx *gin.Context) {
+		var req form.CreateDeviceRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		device := entity.Device{
+			Name:    req.Name,
+			Site:    req.Site,
+			Enabled: true,
+		}
+
+		secret, err := device.NewSecret()
+		if err != nil {
+			log.Errorf("cannot create device secret: %s", err)
+			ctx.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			return
+		}
+
+		if err := device.Create(); err != nil {
+			log.Errorf("cannot create device: %s", err)
+			ctx.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"device": device,
+			"secret": secret,
+		})
+	})
+}
+
+// UpdateDevice changes the name, site or enabled flag of a device.
+//
+// Disabled devices are rejected on their next request.
+//
+// PUT /api/devices/:uid
+func UpdateDevice(router *gin.RouterGroup) {
+	router.PUT("/devices/:uid", func(ctx *gin.Context) {
+		var req form.UpdateDeviceRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		device, err := entity.FindDeviceByUID(ctx.Param("uid"))
+		if err != nil {
+			AbortEntityNotFound(ctx)
+			return
+		}
+
+		if req.Name != "" {
+			device.Name = req.Name
+		}
+
+		if req.Site != "" {
+			device.Site = req.Site
+		}
+
+		if req.Enabled != nil {
+			device.Enabled = *req.Enabled
+		}
+
+		if err := device.Save(); err != nil {
+			log.Errorf("cannot save device: %s", err)
+			AbortSaveFailed(ctx)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"device": device})
+	})
+}
+
+// ResetDeviceSecret generates a new enrollment secret for a device and
+// revokes its tokens, so that the device must be enrolled again.
+//
+// POST /api/devices/:uid/secret
+func ResetDeviceSecret(router *gin.RouterGroup) {
+	router.POST("/devices/:uid/secret", func(ctx *gin.Context) {
+		device, err := entity.FindDeviceByUID(ctx.Param("uid"))
+		if err != nil {
+			AbortEntityNotFound(ctx)
+			return
+		}
+
+		secret, err := device.NewSecret()
+		if err != nil {
+			log.Errorf("cannot create device secret: %s", err)
+			ctx.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			return
+		}
+
+		device.RevokeTokens()
+
+		if err := device.Save(); err != nil {
+			log.Errorf("cannot save device: %s", err)
+			AbortSaveFailed(ctx)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"device": device,
+			"secret": secret,
+		})
+	})
+}
+
+// DeleteDevice removes a device.
+//
+// DELETE /api/devices/:uid
+func DeleteDevice(router *gin.RouterGroup) {
+	router.DELETE("/devices/:uid", func(ctx *gin.Context) {
+		if err := db.Db().Where("uid = ?", ctx.Param("uid")).Delete(&entity.Device{}).Error; err != nil {
+			log.Errorf("cannot delete device: %s", err)
+			AbortDeleteFailed(ctx)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"message": "Device deleted successfully"})
+	})
+}
+
+// EnrollDevice exchanges a device id and secret for a long-lived device token.
+//
+// POST /api/devices/enroll
+// - JSON body:
+//   - uid: string
+//   - secret: string
+func EnrollDevice(router *gin.RouterGroup, tokenMaker token.Maker) {
+	router.POST("/devices/enroll", func(ctx *gin.Context) {
+		var req form.EnrollDeviceRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		device, err := entity.FindDeviceByUID(req.UID)
+		if err != nil || !device.Enabled || !device.CheckSecret(req.Secret) {
+			log.Debugf("api: enrollment failed for device %s", req.UID)
+			ctx.JSON(http.StatusUnauthorized, ErrorResponse(errInvalidDeviceCredentials))
+			return
+		}
+
+		accessToken, accessPayload, err := tokenMaker.CreateToken(
+			0,
+			device.UID,
+			device.Name,
+			config.Env().DeviceTokenDuration,
+			token.WithRole(constant.RoleKiosk),
+			token.WithDevice(device.ID),
+		)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			return
+		}
+
+		if err := device.Seen(); err != nil {
+			log.Errorf("cannot update device: %s", err)
+		}
+
+		ctx.JSON(http.StatusOK, form.EnrollDeviceResponse{
+			AccessToken:          accessToken,
+			AccessTokenExpiresAt: accessPayload.ExpiredAt,
+		})
+	})
+}
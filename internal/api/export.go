@@ -0,0 +1,118 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/alexanderbkl/vidre-back/internal/config"
+	"github.com/alexanderbkl/vidre-back/internal/db"
+	"github.com/alexanderbkl/vidre-back/internal/entity"
+	"github.com/alexanderbkl/vidre-back/internal/export"
+	"github.com/gin-gonic/gin"
+)
+
+// ExportWorkDays exports the work days of the selected workers with their
+// worked time as CSV or XLSX. Workers is a comma separated list of worker
+// codes; all workers are exported if it is empty. At most export.MaxDays
+// days can be exported at once. The XLSX workbook has a summary sheet and
+// one sheet per worker.
+//
+// GET /api/work_days/export?format=csv|xlsx&from=&to=&workers=
+func ExportWorkDays(router *gin.RouterGroup) {
+	router.GET("/work_days/export", func(ctx *gin.Context) {
+		var payload struct {
+			Format  string `form:"format"`
+			From    string `form:"from"    binding:"required"`
+			To      string `form:"to"      binding:"required"`
+			Workers string `form:"workers"`
+		}
+
+		if err := ctx.ShouldBindQuery(&payload); err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		if payload.Format == "" {
+			payload.Format = "csv"
+		}
+
+		contentType, ok := export.Formats[payload.Format]
+		if !ok {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format"})
+			return
+		}
+
+		from, err := time.Parse("2006-01-02", payload.From)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date format"})
+			return
+		}
+
+		to, err := time.Parse("2006-01-02", payload.To)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to date format"})
+			return
+		}
+
+		if err := export.ValidateRange(from, to); err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		codes := export.Codes(payload.Workers)
+
+		var workers entity.Workers
+
+		query := db.Db().Order("code")
+		if len(codes) > 0 {
+			query = query.Where("code IN ?", codes)
+		}
+
+		if err := query.Find(&workers).Error; err != nil {
+			log.Errorf("cannot find workers: %s", err)
+			ctx.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			return
+		}
+
+		if len(codes) > 0 && len(workers) < len(codes) {
+			AbortEntityNotFound(ctx)
+			return
+		}
+
+		ids := make([]uint, len(workers))
+		for i, worker := range workers {
+			ids[i] = worker.ID
+		}
+
+		var schedules entity.WorkSchedules
+		if len(ids) > 0 {
+			if schedules, err = entity.FindWorkersSchedules(db.Db(), ids, from, to); err != nil {
+				log.Errorf("cannot find work schedules: %s", err)
+				ctx.JSON(http.StatusInternalServerError, ErrorResponse(err))
+				return
+			}
+		}
+
+		e := export.New(from, to, config.Location(), workers, schedules)
+
+		var buf bytes.Buffer
+
+		if payload.Format == "xlsx" {
+			err = e.WriteXLSX(&buf)
+		} else {
+			err = e.WriteCSV(&buf)
+		}
+
+		if err != nil {
+			log.Errorf("cannot export work days: %s", err)
+			ctx.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			return
+		}
+
+		filename := fmt.Sprintf("jornadas-%s-%s.%s", payload.From, payload.To, payload.Format)
+		ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		ctx.Data(http.StatusOK, contentType, buf.Bytes())
+	})
+}
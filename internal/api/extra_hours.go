@@ -3,10 +3,12 @@ package api
 import (
 	"net/http"
 
+	"github.com/alexanderbkl/vidre-back/internal/audit"
 	"github.com/alexanderbkl/vidre-back/internal/db"
 	"github.com/alexanderbkl/vidre-back/internal/entity"
 	"github.com/alexanderbkl/vidre-back/internal/query"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // GetExtraHours returns the extra hours for the given user code.
@@ -35,7 +37,8 @@ func GetExtraHours(router *gin.RouterGroup) {
 	})
 }
 
-// ToggleExtraHours toggles the extra hours for a worker.
+// ToggleExtraHours toggles the extra hours for a worker. A reason for the
+// change is required.
 //
 // POST /api/worker/extra_hours/toggle
 func ToggleExtraHours(router *gin.RouterGroup) {
@@ -47,6 +50,7 @@ func ToggleExtraHours(router *gin.RouterGroup) {
 			EndHour   string `json:"end_hour"`
 			Enabled   bool   `json:"enabled"`
 			IsEntry  bool   `json:"is_entry"`
+			Reason    string `json:"reason"`
 		}
 
 		if err := ctx.ShouldBindJSON(&payload); err != nil {
@@ -55,6 +59,10 @@ func ToggleExtraHours(router *gin.RouterGroup) {
 			return
 		}
 
+		if !checkReason(ctx, payload.Reason) {
+			return
+		}
+
 		worker_id, err := query.GetWorkerIDFromCode(payload.Code)
 		if err != nil {
 			log.Errorf("cannot get worker id from code: %s", err)
@@ -71,7 +79,13 @@ func ToggleExtraHours(router *gin.RouterGroup) {
 				EndHour:   payload.EndHour,
 				IsEntry:   payload.IsEntry,
 			}
-			if err := extraHour.Create(); err != nil {
+			if err := db.Db().Transaction(func(tx *gorm.DB) error {
+				if err := tx.Create(&extraHour).Error; err != nil {
+					return err
+				}
+
+				return audit.Record(tx, auditActor(ctx), payload.Reason, entity.AuditExtraHour, extraHour.ID, nil, &extraHour)
+			}); err != nil {
 				log.Errorf("cannot create extra hour: %s", err)
 				ctx.JSON(http.StatusInternalServerError, ErrorResponse(err))
 				return
@@ -82,7 +96,24 @@ func ToggleExtraHours(router *gin.RouterGroup) {
 			})
 		} else {
 			// Remove existing extra hours record
-			if err := db.Db().Where("worker_id = ? AND day_type = ? AND start_hour = ? AND end_hour = ?", worker_id, payload.DayType, payload.StartHour, payload.EndHour).Delete(&entity.ExtraHour{}).Error; err != nil {
+			if err := db.Db().Transaction(func(tx *gorm.DB) error {
+				var extraHours entity.ExtraHours
+				if err := tx.Where("worker_id = ? AND day_type = ? AND start_hour = ? AND end_hour = ?", worker_id, payload.DayType, payload.StartHour, payload.EndHour).Find(&extraHours).Error; err != nil {
+					return err
+				}
+
+				for i := range extraHours {
+					if err := tx.Delete(&extraHours[i]).Error; err != nil {
+						return err
+					}
+
+					if err := audit.Record(tx, auditActor(ctx), payload.Reason, entity.AuditExtraHour, extraHours[i].ID, &extraHours[i], nil); err != nil {
+						return err
+					}
+				}
+
+				return nil
+			}); err != nil {
 				log.Errorf("cannot delete extra hour: %s", err)
 				ctx.JSON(http.StatusInternalServerError, ErrorResponse(err))
 				return
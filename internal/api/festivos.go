@@ -3,9 +3,11 @@ package api
 import (
 	"net/http"
 
+	"github.com/alexanderbkl/vidre-back/internal/audit"
 	"github.com/alexanderbkl/vidre-back/internal/db"
 	"github.com/alexanderbkl/vidre-back/internal/entity"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // GetFestivos returns the list of festivo dates
@@ -22,20 +24,27 @@ func GetFestivos(router *gin.RouterGroup) {
 	})
 }
 
-// PostFestivo creates a new festivo date
+// PostFestivo creates a new festivo date, a reason is required
 //
 // POST /api/festivos
 func PostFestivo(router *gin.RouterGroup) {
 	router.POST("/festivos", func(c *gin.Context) {
 		var payload struct {
-			Date string `json:"date"`
+			Date   string `json:"date"`
+			Reason string `json:"reason"`
 		}
 		var festivo entity.Festivo
 		if err := c.ShouldBindJSON(&payload); err != nil {
 			c.AbortWithStatus(http.StatusBadRequest)
-		} else {
+		} else if checkReason(c, payload.Reason) {
 			festivo.Date = payload.Date
-			if err := festivo.Create(db.Db()); err != nil {
+			if err := db.Db().Transaction(func(tx *gorm.DB) error {
+				if err := festivo.TxCreate(tx); err != nil {
+					return err
+				}
+
+				return audit.Record(tx, auditActor(c), payload.Reason, entity.AuditFestivo, festivo.Date, nil, &festivo)
+			}); err != nil {
 				c.AbortWithStatus(http.StatusInternalServerError)
 			} else {
 				c.JSON(http.StatusOK, festivo)
@@ -44,13 +53,25 @@ func PostFestivo(router *gin.RouterGroup) {
 	})
 }
 
-// DeleteFestivo deletes a festivo date
+// DeleteFestivo deletes a festivo date, a reason is required
 //
-// DELETE /api/festivos/:date
+// DELETE /api/festivos/:date?reason=
 func DeleteFestivo(router *gin.RouterGroup) {
 	router.DELETE("/festivos/:date", func(c *gin.Context) {
 		var festivo entity.Festivo
-		if err := db.Db().Where("date = ?", c.Param("date")).Delete(&festivo).Error; err != nil {
+		if !checkReason(c, c.Query("reason")) {
+			return
+		}
+		if err := db.Db().Transaction(func(tx *gorm.DB) error {
+			if err := tx.Where("date = ?", c.Param("date")).First(&festivo).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("date = ?", festivo.Date).Delete(&entity.Festivo{}).Error; err != nil {
+				return err
+			}
+
+			return audit.Record(tx, auditActor(c), c.Query("reason"), entity.AuditFestivo, festivo.Date, &festivo, nil)
+		}); err != nil {
 			c.AbortWithStatus(http.StatusNotFound)
 		} else {
 			c.JSON(http.StatusOK, festivo)
@@ -0,0 +1,320 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/alexanderbkl/vidre-back/internal/config"
+	"github.com/alexanderbkl/vidre-back/internal/db"
+	"github.com/alexanderbkl/vidre-back/internal/entity"
+	"github.com/alexanderbkl/vidre-back/internal/form"
+	"github.com/alexanderbkl/vidre-back/internal/query"
+	"github.com/alexanderbkl/vidre-back/pkg/geo"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// outsideGeofenceMessage is shown to workers whose punch was rejected by the
+// geofence policy.
+const outsideGeofenceMessage = "FICHAJE FUERA DE LA ZONA PERMITIDA"
+
+// missingLocationMessage is shown to workers whose punch was rejected by the
+// geofence policy because it has no location.
+const missingLocationMessage = "FICHAJE SIN UBICACIÓN, ACTIVE LA LOCALIZACIÓN"
+
+var errInvalidLocation = errors.New("latitude and longitude must be given together and be valid, accuracy must not be negative")
+
+// GetGeofences returns all geofences.
+//
+// GET /api/geofences
+func GetGeofences(router *gin.RouterGroup) {
+	router.GET("/geofences", func(ctx *gin.Context) {
+		var fences entity.Geofences
+		if err := db.Db().Order("site, name").Find(&fences).Error; err != nil {
+			log.Errorf("cannot find geofences: %s", err)
+			ctx.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"geofences": fences})
+	})
+}
+
+// CreateGeofence defines an area from which the workers of a site may punch,
+// either a circle or a polygon. Geofences without site apply to all sites.
+//
+// POST /api/geofences
+// - JSON body:
+//   - site: string
+//   - name: string
+//   - kind: "circle" or "polygon"
+//   - latitude, longitude, radius: number, for circles
+//   - points: [{lat, lng}], for polygons
+//   - enabled: bool, defaults to true
+func CreateGeofence(router *gin.RouterGroup) {
+	router.POST("/geofences", func(ctx *gin.Context) {
+		var req form.GeofenceRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		fence := entity.Geofence{Enabled: true}
+		if !bindGeofence(ctx, &fence, req) {
+			return
+		}
+
+		if err := fence.Create(); err != nil {
+			log.Errorf("cannot create geofence: %s", err)
+			AbortSaveFailed(ctx)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"geofence": fence})
+	})
+}
+
+// UpdateGeofence replaces the definition of a geofence. The enabled flag is
+// kept if it is not given.
+//
+// PUT /api/geofences/:id
+func UpdateGeofence(router *gin.RouterGroup) {
+	router.PUT("/geofences/:id", func(ctx *gin.Context) {
+		var req form.GeofenceRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+		if err != nil {
+			AbortEntityNotFound(ctx)
+			return
+		}
+
+		fence, err := entity.FindGeofence(uint(id))
+		if err != nil {
+			AbortEntityNotFound(ctx)
+			return
+		}
+
+		if !bindGeofence(ctx, fence, req) {
+			return
+		}
+
+		if err := fence.Save(); err != nil {
+			log.Errorf("cannot save geofence: %s", err)
+			AbortSaveFailed(ctx)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"geofence": fence})
+	})
+}
+
+// DeleteGeofence removes a geofence. Punches keep the reference to it.
+//
+// DELETE /api/geofences/:id
+func DeleteGeofence(router *gin.RouterGroup) {
+	router.DELETE("/geofences/:id", func(ctx *gin.Context) {
+		id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+		if err != nil {
+			AbortEntityNotFound(ctx)
+			return
+		}
+
+		if err := db.Db().Delete(&entity.Geofence{}, id).Error; err != nil {
+			log.Errorf("cannot delete geofence: %s", err)
+			AbortDeleteFailed(ctx)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"message": "Geofence deleted successfully"})
+	})
+}
+
+// GetPunchesOutsideGeofence returns the punches whose location was outside
+// the geofences of their site, whether they were rejected or only flagged,
+// optionally of a worker in a date frame.
+//
+// GET /api/punches/geofence?worker_code=&start_date=&end_date=
+func GetPunchesOutsideGeofence(router *gin.RouterGroup) {
+	router.GET("/punches/geofence", func(ctx *gin.Context) {
+		var payload struct {
+			WorkerCode string `form:"worker_code"`
+			StartDate  string `form:"start_date"`
+			EndDate    string `form:"end_date"`
+		}
+
+		if err := ctx.ShouldBindQuery(&payload); err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		q := db.Db().Where("geofence_status = ?", entity.PunchOutsideGeofence)
+
+		if payload.WorkerCode != "" {
+			workerId, err := query.GetWorkerIDFromCode(payload.WorkerCode)
+			if err != nil {
+				AbortEntityNotFound(ctx)
+				return
+			}
+			q = q.Where("worker_id = ?", workerId)
+		}
+
+		if payload.StartDate != "" {
+			startDate, err := time.Parse("2006-01-02", payload.StartDate)
+			if err != nil {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start date format"})
+				return
+			}
+			q = q.Where("date >= ?", startDate)
+		}
+
+		if payload.EndDate != "" {
+			endDate, err := time.Parse("2006-01-02", payload.EndDate)
+			if err != nil {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end date format"})
+				return
+			}
+			q = q.Where("date <= ?", endDate)
+		}
+
+		q = q.Preload("Geofence", func(tx *gorm.DB) *gorm.DB {
+			return tx.Unscoped()
+		})
+
+		var punches entity.Punches
+		if err := q.Order("id").Find(&punches).Error; err != nil {
+			log.Errorf("cannot find punches outside geofence: %s", err)
+			ctx.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			return
+		}
+
+		for i := range punches {
+			punches[i].In(config.Location())
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"punches": punches})
+	})
+}
+
+// bindGeofence sets the definition of the request on the geofence. It
+// responds with an error and returns false if the definition is invalid.
+func bindGeofence(ctx *gin.Context, fence *entity.Geofence, req form.GeofenceRequest) bool {
+	fence.Site = req.Site
+	fence.Name = req.Name
+	fence.Kind = req.Kind
+	fence.Latitude, fence.Longitude, fence.Radius, fence.Points = 0, 0, 0, nil
+
+	switch req.Kind {
+	case entity.GeofenceCircle:
+		fence.Latitude, fence.Longitude, fence.Radius = req.Latitude, req.Longitude, req.Radius
+	case entity.GeofencePolygon:
+		fence.Points = req.Points
+	}
+
+	if req.Enabled != nil {
+		fence.Enabled = *req.Enabled
+	}
+
+	if err := fence.Validate(); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse(err))
+		return false
+	}
+
+	return true
+}
+
+// locatePunch records the location of a punch and checks it against the
+// geofences of the site of its device, or all geofences if it has none.
+// Punches of sites without geofences are not checked. Depending on the
+// geofence policy, punches outside the geofences or without location are
+// only marked or flagged for review, see checkGeofences.
+func locatePunch(punch *entity.Punch, location form.Location) error {
+	var p *geo.Point
+
+	if location.Latitude != nil || location.Longitude != nil {
+		if location.Latitude == nil || location.Longitude == nil {
+			return errInvalidLocation
+		}
+
+		p = &geo.Point{Lat: *location.Latitude, Lng: *location.Longitude}
+		if p.Validate() != nil || (location.Accuracy != nil && !(*location.Accuracy >= 0)) {
+			return errInvalidLocation
+		}
+
+		punch.Latitude, punch.Longitude, punch.Accuracy = location.Latitude, location.Longitude, location.Accuracy
+	}
+
+	policy := config.Env().GeofencePolicy
+	if policy == config.GeofenceOff {
+		return nil
+	}
+
+	var site string
+	if punch.DeviceID != nil {
+		device, err := entity.FindDeviceByID(*punch.DeviceID)
+		if err != nil {
+			return err
+		}
+		site = device.Site
+	}
+
+	fences, err := entity.FindGeofences(site)
+	if err != nil {
+		return err
+	}
+
+	var accuracy float64
+	if location.Accuracy != nil {
+		accuracy = *location.Accuracy
+	}
+
+	checkGeofences(punch, fences, p, accuracy, float64(config.Env().GeofenceMaxAccuracy), policy)
+
+	return nil
+}
+
+// checkGeofences marks a punch at the point as inside or outside the nearest
+// geofence. A punch without point is outside if there are geofences, as its
+// location cannot be checked. With the reject policy, punches outside are
+// flagged for review with a message for the worker. Punches are not changed
+// if there are no geofences.
+func checkGeofences(punch *entity.Punch, fences entity.Geofences, p *geo.Point, accuracy, maxAccuracy float64, policy string) {
+	if policy == config.GeofenceOff {
+		return
+	}
+
+	msg := outsideGeofenceMessage
+
+	if p == nil {
+		if !fences.Active() {
+			return
+		}
+
+		msg = missingLocationMessage
+	} else {
+		fence, distance, inside := fences.Locate(*p, accuracy, maxAccuracy)
+		if fence == nil {
+			return
+		}
+
+		punch.GeofenceID = &fence.ID
+		punch.GeofenceDistance = &distance
+		punch.GeofenceStatus = entity.PunchInsideGeofence
+
+		if inside {
+			return
+		}
+	}
+
+	punch.GeofenceStatus = entity.PunchOutsideGeofence
+
+	if policy == config.GeofenceReject {
+		punch.Review = entity.PunchReviewOutsideGeofence
+		punch.Message = msg
+	}
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/alexanderbkl/vidre-back/internal/config"
+	"github.com/alexanderbkl/vidre-back/internal/entity"
+	"github.com/alexanderbkl/vidre-back/pkg/geo"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckGeofences(t *testing.T) {
+	fences := entity.Geofences{{ID: 1, Kind: entity.GeofenceCircle, Latitude: 0, Longitude: 0, Radius: 100}}
+
+	inside := &geo.Point{Lat: 0, Lng: 0.0005}
+	outside := &geo.Point{Lat: 0, Lng: 0.01}
+
+	testCases := []struct {
+		name    string
+		fences  entity.Geofences
+		point   *geo.Point
+		policy  string
+		status  string
+		review  string
+		message string
+	}{
+		{name: "Off", fences: fences, point: outside, policy: config.GeofenceOff},
+		{name: "Inside", fences: fences, point: inside, policy: config.GeofenceReject, status: entity.PunchInsideGeofence},
+		{name: "OutsideFlag", fences: fences, point: outside, policy: config.GeofenceFlag, status: entity.PunchOutsideGeofence},
+		{name: "OutsideReject", fences: fences, point: outside, policy: config.GeofenceReject, status: entity.PunchOutsideGeofence, review: entity.PunchReviewOutsideGeofence, message: outsideGeofenceMessage},
+		{name: "NoFences", point: outside, policy: config.GeofenceReject},
+		{name: "MissingOff", fences: fences, policy: config.GeofenceOff},
+		{name: "MissingFlag", fences: fences, policy: config.GeofenceFlag, status: entity.PunchOutsideGeofence},
+		{name: "MissingReject", fences: fences, policy: config.GeofenceReject, status: entity.PunchOutsideGeofence, review: entity.PunchReviewOutsideGeofence, message: missingLocationMessage},
+		{name: "MissingNoFences", policy: config.GeofenceReject},
+		{name: "MissingUnknownKind", fences: entity.Geofences{{ID: 2, Kind: "square"}}, policy: config.GeofenceReject},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			var punch entity.Punch
+			checkGeofences(&punch, tc.fences, tc.point, 0, 50, tc.policy)
+
+			require.Equal(t, tc.status, punch.GeofenceStatus)
+			require.Equal(t, tc.review, punch.Review)
+			require.Equal(t, tc.message, punch.Message)
+
+			if tc.point == nil {
+				require.Nil(t, punch.GeofenceID)
+				require.Nil(t, punch.GeofenceDistance)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/alexanderbkl/vidre-back/internal/config"
+	"github.com/alexanderbkl/vidre-back/internal/db"
+	"github.com/alexanderbkl/vidre-back/internal/entity"
+	"github.com/alexanderbkl/vidre-back/internal/importer"
+	"github.com/gin-gonic/gin"
+)
+
+// maxImportSize limits the size of an import file.
+const maxImportSize = 10 << 20
+
+// ImportWorkers creates workers from a CSV file with the columns code and
+// name. The file is sent as multipart form field "file" or as request body.
+// Nothing is imported if a row is invalid; the errors of all rows are
+// reported. With dry_run=true the changes are only previewed. The reason is
+// recorded in the audit log.
+//
+// POST /api/import/workers?dry_run=&reason=
+func ImportWorkers(router *gin.RouterGroup) {
+	router.POST("/import/workers", func(ctx *gin.Context) {
+		file, dryRun, ok := importFile(ctx)
+		if !ok {
+			return
+		}
+		defer file.Close()
+
+		rows, report, err := importer.ParseWorkers(file)
+		if err != nil {
+			ctx.JSON(importErrorStatus(err), ErrorResponse(err))
+			return
+		}
+
+		report.DryRun = dryRun
+
+		if err := importer.ImportWorkers(db.Db(), rows, auditActor(ctx), ctx.Query("reason"), report); err != nil {
+			log.Errorf("cannot import workers: %s", err)
+			ctx.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			return
+		}
+
+		importResponse(ctx, report)
+	})
+}
+
+// ImportWorkDays imports historical work days from a CSV file with the
+// columns worker_code, date, entry_hour, exit_hour and optionally breaks,
+// e.g. "10:00-10:15 R, 14:00-15:00" where "R" marks paid breaks. Existing
+// work days are replaced with correction punches. The file is sent as
+// multipart form field "file" or as request body. Nothing is imported if a
+// row is invalid; the errors of all rows are reported. With dry_run=true the
+// changes are only previewed. The reason is recorded in the audit log.
+//
+// POST /api/import/work_days?dry_run=&reason=
+func ImportWorkDays(router *gin.RouterGroup) {
+	router.POST("/import/work_days", func(ctx *gin.Context) {
+		file, dryRun, ok := importFile(ctx)
+		if !ok {
+			return
+		}
+		defer file.Close()
+
+		rows, report, err := importer.ParseWorkDays(file, config.Location(), config.Env().MaxShiftDuration)
+		if err != nil {
+			ctx.JSON(importErrorStatus(err), ErrorResponse(err))
+			return
+		}
+
+		report.DryRun = dryRun
+
+		base := correctionPunch(ctx)
+		base.Source = entity.PunchSourceImport
+
+		if err := importer.ImportWorkDays(db.Db(), rows, base, auditActor(ctx), ctx.Query("reason"), report); err != nil {
+			log.Errorf("cannot import work days: %s", err)
+			ctx.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			return
+		}
+
+		importResponse(ctx, report)
+	})
+}
+
+// importFile returns the uploaded import file and whether it is a dry run.
+// It aborts the request if there is no file or no reason, or if the file is
+// larger than maxImportSize.
+func importFile(ctx *gin.Context) (io.ReadCloser, bool, bool) {
+	dryRun := false
+
+	if !checkReason(ctx, ctx.Query("reason")) {
+		return nil, false, false
+	}
+
+	if value := ctx.Query("dry_run"); value != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(value); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid dry_run value"})
+			return nil, false, false
+		}
+	}
+
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxImportSize)
+
+	if !strings.HasPrefix(ctx.ContentType(), "multipart/") {
+		return ctx.Request.Body, dryRun, true
+	}
+
+	header, err := ctx.FormFile("file")
+	if err != nil {
+		ctx.JSON(importErrorStatus(err), ErrorResponse(err))
+		return nil, false, false
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		ctx.JSON(importErrorStatus(err), ErrorResponse(err))
+		return nil, false, false
+	}
+
+	return file, dryRun, true
+}
+
+// importErrorStatus returns the response status of an error reading an
+// import file, 413 if it is larger than maxImportSize.
+func importErrorStatus(err error) int {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return http.StatusRequestEntityTooLarge
+	}
+
+	return http.StatusBadRequest
+}
+
+// importResponse responds with the import report, with status 422 if rows
+// are invalid.
+func importResponse(ctx *gin.Context, report *importer.Report) {
+	if report.Changes == nil {
+		report.Changes = []importer.Change{}
+	}
+
+	if report.Errors == nil {
+		report.Errors = []importer.RowError{}
+	}
+
+	if !report.Valid() {
+		ctx.JSON(http.StatusUnprocessableEntity, report)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportTooLarge(t *testing.T) {
+	csv := "code,name\n" + strings.Repeat("0042,Begoña Núñez\n", maxImportSize/16)
+
+	var form bytes.Buffer
+	w := multipart.NewWriter(&form)
+	part, err := w.CreateFormFile("file", "workers.csv")
+	require.NoError(t, err)
+	_, err = part.Write([]byte(csv))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	testCases := []struct {
+		name        string
+		body        []byte
+		contentType string
+	}{
+		{name: "Body", body: []byte(csv), contentType: "text/csv"},
+		{name: "Multipart", body: form.Bytes(), contentType: w.FormDataContentType()},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			router := gin.New()
+			ImportWorkers(router.Group("/api"))
+
+			request := httptest.NewRequest(http.MethodPost, "/api/import/workers?reason=Alta+masiva", bytes.NewReader(tc.body))
+			request.Header.Set("Content-Type", tc.contentType)
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+
+			require.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code, recorder.Body.String())
+		})
+	}
+}
+
+func TestImportErrorStatus(t *testing.T) {
+	require.Equal(t, http.StatusRequestEntityTooLarge, importErrorStatus(fmt.Errorf("read: %w", &http.MaxBytesError{Limit: maxImportSize})))
+	require.Equal(t, http.StatusBadRequest, importErrorStatus(fmt.Errorf("invalid header")))
+}
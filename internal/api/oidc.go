@@ -0,0 +1,158 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/alexanderbkl/vidre-back/internal/constant"
+	"github.com/alexanderbkl/vidre-back/internal/entity"
+	"github.com/alexanderbkl/vidre-back/internal/form"
+	"github.com/alexanderbkl/vidre-back/pkg/oidc"
+	"github.com/alexanderbkl/vidre-back/pkg/token"
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	errInvalidOidcState = errors.New("login state is invalid, expired or already used")
+	errOidcLoginFailed  = errors.New("identity provider login failed")
+	errOidcNoRole       = errors.New("identity provider account has no role")
+	errOidcNameTaken    = errors.New("a user with the same name already exists")
+)
+
+// OidcLogin redirects to the login page of the identity provider.
+//
+// GET /api/auth/oidc/login
+func OidcLogin(router *gin.RouterGroup, provider *oidc.Provider) {
+	router.GET("/auth/oidc/login", func(ctx *gin.Context) {
+		if provider == nil {
+			AbortFeatureDisabled(ctx)
+			return
+		}
+
+		state, err := entity.NewOidcState()
+		if err != nil {
+			log.Errorf("cannot create oidc state: %s", err)
+			ctx.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			return
+		}
+
+		url, err := provider.AuthCodeURL(ctx, state.State, state.Nonce, oidc.CodeChallenge(state.CodeVerifier))
+		if err != nil {
+			log.Errorf("oidc: %s", err)
+			ctx.JSON(http.StatusBadGateway, ErrorResponse(errOidcLoginFailed))
+			return
+		}
+
+		ctx.Redirect(http.StatusFound, url)
+	})
+}
+
+// OidcCallback completes the login at the identity provider and returns an
+// access and refresh token. Users are created on their first login and their
+// role is updated from the mapped claim on every login.
+//
+// POST /api/auth/oidc/callback
+// - JSON body:
+//   - code: string
+//   - state: string
+func OidcCallback(router *gin.RouterGroup, provider *oidc.Provider, roles oidc.RoleMapping, tokenMaker token.Maker) {
+	router.POST("/auth/oidc/callback", func(ctx *gin.Context) {
+		if provider == nil {
+			AbortFeatureDisabled(ctx)
+			return
+		}
+
+		var req form.OidcCallbackRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		state, ok, err := entity.UseOidcState(req.State)
+		if err != nil {
+			log.Errorf("cannot use oidc state: %s", err)
+			ctx.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			return
+		} else if !ok {
+			ctx.JSON(http.StatusUnauthorized, ErrorResponse(errInvalidOidcState))
+			return
+		}
+
+		rsp, err := provider.Exchange(ctx, req.Code, state.CodeVerifier)
+		if err != nil {
+			log.Infof("oidc: %s", err)
+			ctx.JSON(http.StatusUnauthorized, ErrorResponse(errOidcLoginFailed))
+			return
+		}
+
+		claims, err := provider.VerifyIDToken(ctx, rsp.IDToken, state.Nonce)
+		if err != nil {
+			log.Infof("oidc: %s", err)
+			ctx.JSON(http.StatusUnauthorized, ErrorResponse(errOidcLoginFailed))
+			return
+		}
+
+		role, ok := roles.Role(claims, constant.Roles)
+		if !ok {
+			log.Infof("oidc: no role mapped for %s", claims.Subject)
+			ctx.JSON(http.StatusForbidden, ErrorResponse(errOidcNoRole))
+			return
+		}
+
+		user, err := oidcUser(claims, role)
+		if errors.Is(err, errOidcNameTaken) {
+			ctx.JSON(http.StatusConflict, ErrorResponse(err))
+			return
+		} else if err != nil {
+			log.Errorf("cannot save oidc user: %s", err)
+			AbortSaveFailed(ctx)
+			return
+		}
+
+		login, err := createLoginResponse(ctx, tokenMaker, user)
+		if err != nil {
+			log.Errorf("cannot create tokens: %s", err)
+			ctx.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusOK, login)
+	})
+}
+
+// oidcUser returns the user linked to the subject of the claims with the
+// given role, and creates it if needed. Sessions of a user whose role
+// changed are revoked.
+func oidcUser(claims *oidc.Claims, role string) (*entity.User, error) {
+	if user, err := entity.FindUserByOidcSubject(claims.Subject); err == nil {
+		if user.Role == role {
+			return user, nil
+		}
+
+		user.Role = role
+
+		if err := user.Save(); err != nil {
+			return nil, err
+		}
+
+		// Tokens of earlier sessions still carry the old role.
+		return user, entity.BlockUserSessions(user.ID)
+	}
+
+	name := claims.PreferredUsername
+	if name == "" {
+		name = claims.Email
+	}
+	if name == "" {
+		name = claims.Subject
+	}
+
+	if _, err := entity.FindUserByName(name); err == nil {
+		return nil, errOidcNameTaken
+	}
+
+	subject := claims.Subject
+	user := &entity.User{Name: name, Role: role, OidcSubject: &subject}
+
+	return user, user.Create()
+}
@@ -0,0 +1,270 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/alexanderbkl/vidre-back/internal/clock"
+	"github.com/alexanderbkl/vidre-back/internal/config"
+	"github.com/alexanderbkl/vidre-back/internal/db"
+	"github.com/alexanderbkl/vidre-back/internal/entity"
+	"github.com/alexanderbkl/vidre-back/internal/form"
+	"github.com/alexanderbkl/vidre-back/internal/query"
+	"github.com/gin-gonic/gin"
+)
+
+// GetPunches returns all punches of a worker in a date frame, including
+// rejected punches and corrections, in the order they were recorded.
+//
+// GET /api/worker/punches?worker_code=&start_date=&end_date=
+func GetPunches(router *gin.RouterGroup) {
+	router.GET("/worker/punches", func(ctx *gin.Context) {
+		var payload struct {
+			WorkerCode string `form:"worker_code" binding:"required"`
+			StartDate  string `form:"start_date"  binding:"required"`
+			EndDate    string `form:"end_date"    binding:"required"`
+		}
+
+		if err := ctx.ShouldBindQuery(&payload); err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		workerId, err := query.GetWorkerIDFromCode(payload.WorkerCode)
+		if err != nil {
+			AbortEntityNotFound(ctx)
+			return
+		}
+
+		startDate, err := time.Parse("2006-01-02", payload.StartDate)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start date format"})
+			return
+		}
+
+		endDate, err := time.Parse("2006-01-02", payload.EndDate)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end date format"})
+			return
+		}
+
+		var punches entity.Punches
+		if err := db.Db().Where("worker_id = ? AND date >= ? AND date <= ?", workerId, startDate, endDate).Order("id").Find(&punches).Error; err != nil {
+			log.Errorf("cannot find punches: %s", err)
+			ctx.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			return
+		}
+
+		for i := range punches {
+			punches[i].In(config.Location())
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"punches": punches})
+	})
+}
+
+// maxSyncPunches limits the number of punches synced in one request.
+const maxSyncPunches = 500
+
+// SyncPunches records punches that a kiosk queued while offline.
+//
+// Punches are replayed in the order of their device sequence through the same
+// clock logic as live punches. A punch whose sequence has been synced before
+// is reported as a duplicate before the worker and PIN are checked, so the
+// whole batch can be sent again after a failure without counting PIN attempts
+// again. Punches that arrive out of order or conflict with the work day are
+// recorded, but flagged for review and left out of the work day. Locations
+// of punches are checked against the geofences as in PostWorkDay.
+//
+// POST /api/punches/sync
+func SyncPunches(router *gin.RouterGroup) {
+	router.POST("/punches/sync", func(ctx *gin.Context) {
+		p := authPayload(ctx)
+		if p == nil || p.DeviceID == 0 {
+			Abort(ctx, http.StatusForbidden, "Only devices can sync punches")
+			return
+		}
+		deviceId := p.DeviceID
+
+		var payload form.SyncPunchesRequest
+		if err := ctx.ShouldBindJSON(&payload); err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		} else if len(payload.Punches) > maxSyncPunches {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Too many punches, at most %d can be synced at once", maxSyncPunches)})
+			return
+		}
+
+		sort.SliceStable(payload.Punches, func(i, j int) bool {
+			return *payload.Punches[i].Sequence < *payload.Punches[j].Sequence
+		})
+
+		results := make([]form.SyncPunchResult, 0, len(payload.Punches))
+		workers := make(map[string]*entity.Worker)
+
+		for _, queued := range payload.Punches {
+			sequence := *queued.Sequence
+			result := form.SyncPunchResult{Sequence: sequence, Status: "invalid"}
+
+			// A resent punch is answered from the recorded punch without
+			// checking the PIN again, it was verified when it was recorded.
+			if existing, err := entity.FindDevicePunch(db.Db(), deviceId, sequence); err != nil {
+				log.Errorf("cannot find punch %d of device %d: %s", sequence, deviceId, err)
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Could not sync punches"})
+				return
+			} else if existing != nil {
+				results = append(results, syncResult(result, existing, clock.SyncDuplicate, existing.Message))
+				continue
+			}
+
+			worker, ok := workers[queued.WorkerCode]
+			if !ok {
+				var err error
+				if worker, err = query.GetWorkerFromCode(queued.WorkerCode); err != nil {
+					worker = nil
+				}
+				workers[queued.WorkerCode] = worker
+			}
+
+			if worker == nil {
+				result.Error = "Worker not found"
+				results = append(results, result)
+				continue
+			}
+
+			if status, msg := checkPin(ctx, worker, queued.Pin, &deviceId); status != 0 {
+				result.Error = msg
+				results = append(results, result)
+				continue
+			}
+
+			t, err := clock.ParseTime(queued.Time, config.Location())
+			if err != nil {
+				result.Error = "Invalid time format"
+				results = append(results, result)
+				continue
+			}
+
+			punch := entity.Punch{
+				WorkerID:       worker.ID,
+				Date:           clock.Date(t, config.Location()),
+				Type:           queued.Type,
+				Time:           t,
+				BreakType:      queued.BreakType,
+				Source:         entity.PunchSourceClock,
+				DeviceID:       &deviceId,
+				DeviceSequence: &sequence,
+			}
+
+			if err := locatePunch(&punch, queued.Location); errors.Is(err, errInvalidLocation) {
+				result.Error = "Invalid location"
+				results = append(results, result)
+				continue
+			} else if err != nil {
+				log.Errorf("cannot check geofences of punch %d of device %d: %s", sequence, deviceId, err)
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Could not sync punches"})
+				return
+			}
+
+			status, applied, err := clock.Sync(&punch, config.Env().MaxShiftDuration)
+			if errors.Is(err, clock.ErrInvalidType) {
+				result.Error = "Invalid type"
+				results = append(results, result)
+				continue
+			} else if errors.Is(err, clock.ErrInvalidBreakType) {
+				result.Error = "Invalid break type"
+				results = append(results, result)
+				continue
+			} else if err != nil {
+				// Punches synced so far are kept, the device sends the batch again.
+				log.Errorf("cannot sync punch %d of device %d: %s", sequence, deviceId, err)
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Could not sync punches"})
+				return
+			}
+
+			msg := applied.Message
+			if status == clock.SyncDuplicate {
+				msg = punch.Message
+			}
+
+			results = append(results, syncResult(result, &punch, status, msg))
+		}
+
+		ctx.JSON(http.StatusOK, form.SyncPunchesResponse{Results: results})
+	})
+}
+
+// syncResult returns the result of a synced punch with its status.
+func syncResult(result form.SyncPunchResult, punch *entity.Punch, status, msg string) form.SyncPunchResult {
+	result.Status = status
+	result.PunchID = punch.ID
+	result.Date = punch.Date.Format("2006-01-02")
+	result.Review = punch.Review
+	result.Geofence = punch.GeofenceStatus
+	result.Message = msg
+	return result
+}
+
+// GetPunchesForReview returns the punches that have been flagged for review
+// when they were synced, optionally of a worker in a date frame.
+//
+// GET /api/punches/review?worker_code=&start_date=&end_date=
+func GetPunchesForReview(router *gin.RouterGroup) {
+	router.GET("/punches/review", func(ctx *gin.Context) {
+		var payload struct {
+			WorkerCode string `form:"worker_code"`
+			StartDate  string `form:"start_date"`
+			EndDate    string `form:"end_date"`
+		}
+
+		if err := ctx.ShouldBindQuery(&payload); err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		q := db.Db().Where("review <> ''")
+
+		if payload.WorkerCode != "" {
+			workerId, err := query.GetWorkerIDFromCode(payload.WorkerCode)
+			if err != nil {
+				AbortEntityNotFound(ctx)
+				return
+			}
+			q = q.Where("worker_id = ?", workerId)
+		}
+
+		if payload.StartDate != "" {
+			startDate, err := time.Parse("2006-01-02", payload.StartDate)
+			if err != nil {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start date format"})
+				return
+			}
+			q = q.Where("date >= ?", startDate)
+		}
+
+		if payload.EndDate != "" {
+			endDate, err := time.Parse("2006-01-02", payload.EndDate)
+			if err != nil {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end date format"})
+				return
+			}
+			q = q.Where("date <= ?", endDate)
+		}
+
+		var punches entity.Punches
+		if err := q.Order("id").Find(&punches).Error; err != nil {
+			log.Errorf("cannot find punches for review: %s", err)
+			ctx.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			return
+		}
+
+		for i := range punches {
+			punches[i].In(config.Location())
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"punches": punches})
+	})
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/alexanderbkl/vidre-back/internal/config"
+	"github.com/alexanderbkl/vidre-back/internal/db"
+	"github.com/alexanderbkl/vidre-back/internal/entity"
+	"github.com/alexanderbkl/vidre-back/internal/query"
+	"github.com/alexanderbkl/vidre-back/internal/register"
+	"github.com/gin-gonic/gin"
+)
+
+// GetWorkerRegister returns the monthly working time register of a worker
+// (registro de jornada) as PDF document.
+//
+// GET /api/worker/:code/register.pdf?month=YYYY-MM
+func GetWorkerRegister(router *gin.RouterGroup) {
+	router.GET("/worker/:code/register.pdf", func(ctx *gin.Context) {
+		worker, err := query.GetWorkerFromCode(ctx.Param("code"))
+		if err != nil {
+			AbortEntityNotFound(ctx)
+			return
+		}
+
+		month, err := time.Parse("2006-01", ctx.Query("month"))
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid month format"})
+			return
+		}
+
+		schedules, err := entity.FindWorkSchedules(db.Db(), worker.ID, month, month.AddDate(0, 1, -1))
+		if err != nil {
+			log.Errorf("cannot find work schedules: %s", err)
+			ctx.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			return
+		}
+
+		var festivos entity.Festivos
+		if err := db.Db().Find(&festivos).Error; err != nil {
+			log.Errorf("cannot find festivos: %s", err)
+			ctx.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			return
+		}
+
+		var buf bytes.Buffer
+		if err := register.New(config.Env().CompanyName, *worker, month, config.Location(), schedules, festivos).WritePDF(&buf); err != nil {
+			log.Errorf("cannot create register: %s", err)
+			ctx.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			return
+		}
+
+		filename := fmt.Sprintf("registro-jornada-%s-%s.pdf", worker.Code, month.Format("2006-01"))
+		ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		ctx.Data(http.StatusOK, "application/pdf", buf.Bytes())
+	})
+}
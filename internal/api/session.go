@@ -0,0 +1,79 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/alexanderbkl/vidre-back/internal/entity"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// GetUserSessions returns the active sessions of a user.
+//
+// GET /api/users/:uid/sessions
+func GetUserSessions(router *gin.RouterGroup) {
+	router.GET("/users/:uid/sessions", func(ctx *gin.Context) {
+		user, err := entity.FindUserByUID(ctx.Param("uid"))
+		if err != nil {
+			AbortEntityNotFound(ctx)
+			return
+		}
+
+		sessions, err := entity.ActiveUserSessions(user.ID)
+		if err != nil {
+			log.Errorf("cannot find sessions: %s", err)
+			ctx.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusOK, sessions)
+	})
+}
+
+// RevokeUserSessions revokes all sessions of a user.
+//
+// DELETE /api/users/:uid/sessions
+func RevokeUserSessions(router *gin.RouterGroup) {
+	router.DELETE("/users/:uid/sessions", func(ctx *gin.Context) {
+		user, err := entity.FindUserByUID(ctx.Param("uid"))
+		if err != nil {
+			AbortEntityNotFound(ctx)
+			return
+		}
+
+		if err := entity.BlockUserSessions(user.ID); err != nil {
+			log.Errorf("cannot revoke sessions: %s", err)
+			AbortSaveFailed(ctx)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"message": "Sessions revoked"})
+	})
+}
+
+// RevokeSession revokes a single session.
+//
+// DELETE /api/sessions/:id
+func RevokeSession(router *gin.RouterGroup) {
+	router.DELETE("/sessions/:id", func(ctx *gin.Context) {
+		id, err := uuid.Parse(ctx.Param("id"))
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		session, err := entity.FindSession(id)
+		if err != nil {
+			AbortEntityNotFound(ctx)
+			return
+		}
+
+		if err := session.Block(); err != nil {
+			log.Errorf("cannot revoke session: %s", err)
+			AbortSaveFailed(ctx)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"message": "Session revoked"})
+	})
+}
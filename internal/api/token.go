@@ -1,15 +1,27 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/alexanderbkl/vidre-back/internal/config"
+	"github.com/alexanderbkl/vidre-back/internal/entity"
 	"github.com/alexanderbkl/vidre-back/internal/form"
 	"github.com/alexanderbkl/vidre-back/pkg/token"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
-// RenewAccessToken
+var (
+	errSessionNotFound = errors.New("session not found")
+	errSessionRevoked  = errors.New("session has been revoked")
+	errSessionMismatch = errors.New("session does not belong to token user")
+	errTokenReused     = errors.New("refresh token has already been used")
+)
+
+// RenewAccessToken issues a new access token and rotates the refresh token.
+//
+// Reusing a refresh token that has already been rotated revokes the whole session.
 //
 // POST /api/token/renew
 func RenewAccessToken(router *gin.RouterGroup, tokenMaker token.Maker) {
@@ -24,15 +36,65 @@ func RenewAccessToken(router *gin.RouterGroup, tokenMaker token.Maker) {
 		if err != nil {
 			ctx.JSON(http.StatusUnauthorized, ErrorResponse(err))
 			return
+		} else if refreshPayload.Purpose != token.PurposeRefresh {
+			ctx.JSON(http.StatusUnauthorized, ErrorResponse(token.ErrWrongPurpose))
+			return
+		}
+
+		session, err := findSession(refreshPayload.SessionID)
+		if err != nil {
+			ctx.JSON(http.StatusUnauthorized, ErrorResponse(errSessionNotFound))
+			return
+		}
+
+		if err := verifySession(session, refreshPayload); errors.Is(err, errTokenReused) {
+			revokeReusedSession(session)
+			ctx.JSON(http.StatusUnauthorized, ErrorResponse(err))
+			return
+		} else if err != nil {
+			ctx.JSON(http.StatusUnauthorized, ErrorResponse(err))
+			return
+		}
+
+		// Reload the user so that role changes apply on the next renewal.
+		user, err := entity.FindUserByID(refreshPayload.UserID)
+		if err != nil {
+			ctx.JSON(http.StatusUnauthorized, ErrorResponse(errSessionMismatch))
+			return
 		}
 
-		// TO-DO check session
+		refreshToken, nextRefreshPayload, err := tokenMaker.CreateToken(
+			user.ID,
+			user.UID,
+			user.Name,
+			config.Env().RefreshTokenDuration,
+			token.WithPurpose(token.PurposeRefresh),
+			token.WithSession(session.ID),
+			token.WithRole(user.Role),
+		)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			return
+		}
+
+		if rotated, err := session.Rotate(refreshPayload.TokenID, nextRefreshPayload.TokenID, nextRefreshPayload.ExpiredAt); err != nil {
+			log.Errorf("cannot rotate session: %s", err)
+			ctx.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			return
+		} else if !rotated {
+			// Another request rotated the same token first.
+			revokeReusedSession(session)
+			ctx.JSON(http.StatusUnauthorized, ErrorResponse(errTokenReused))
+			return
+		}
 
 		accessToken, accessPayload, err := tokenMaker.CreateToken(
-			refreshPayload.UserID,
-			refreshPayload.UserUID,
-			refreshPayload.UserName,
+			user.ID,
+			user.UID,
+			user.Name,
 			config.Env().AccessTokenDuration,
+			token.WithSession(session.ID),
+			token.WithRole(user.Role),
 		)
 		if err != nil {
 			ctx.JSON(http.StatusInternalServerError, ErrorResponse(err))
@@ -40,9 +102,72 @@ func RenewAccessToken(router *gin.RouterGroup, tokenMaker token.Maker) {
 		}
 
 		rsp := form.RenewAccessTokenResponse{
-			AccessToken:          accessToken,
-			AccessTokenExpiresAt: accessPayload.ExpiredAt,
+			AccessToken:           accessToken,
+			AccessTokenExpiresAt:  accessPayload.ExpiredAt,
+			RefreshToken:          refreshToken,
+			RefreshTokenExpiresAt: nextRefreshPayload.ExpiredAt,
 		}
 		ctx.JSON(http.StatusOK, rsp)
 	})
 }
+
+// findSession returns the session with the given id. Tests replace it.
+var findSession = entity.FindSession
+
+// VerifySession returns an error if the login session of a token has been
+// revoked or is gone, e.g. after a logout, so that access tokens issued for
+// it are no longer accepted. Tokens without session, like device tokens, are
+// not checked.
+func VerifySession(payload *token.Payload) error {
+	if payload.SessionID == uuid.Nil {
+		return nil
+	}
+
+	session, err := findSession(payload.SessionID)
+	if err != nil {
+		return errSessionNotFound
+	}
+
+	return verifySession(session, payload)
+}
+
+// verifySession checks that the session is active and belongs to the user of
+// the token. Refresh tokens must also be the current one of the session;
+// older refresh tokens have been rotated and are being reused.
+func verifySession(session *entity.Session, payload *token.Payload) error {
+	if session.UserID != payload.UserID {
+		return errSessionMismatch
+	} else if !session.Active() {
+		return errSessionRevoked
+	} else if payload.Purpose == token.PurposeRefresh && session.RefreshTokenID != payload.TokenID {
+		return errTokenReused
+	}
+
+	return nil
+}
+
+// revokeReusedSession blocks a session after one of its old refresh tokens was presented.
+func revokeReusedSession(session *entity.Session) {
+	log.Warnf("api: refresh token reuse detected, revoking session %s", session.ID)
+
+	if err := session.Block(); err != nil {
+		log.Errorf("cannot block session: %s", err)
+	}
+}
+
+// GetTokenKeys returns the public keys that verify access tokens.
+//
+// The list is empty if tokens are encrypted with a symmetric key.
+//
+// GET /api/auth/keys
+func GetTokenKeys(router *gin.RouterGroup, tokenMaker token.Maker) {
+	router.GET("/auth/keys", func(ctx *gin.Context) {
+		keys := []token.PublicKey{}
+
+		if provider, ok := tokenMaker.(token.PublicKeyProvider); ok {
+			keys = provider.PublicKeys()
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"keys": keys})
+	})
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alexanderbkl/vidre-back/internal/entity"
+	"github.com/alexanderbkl/vidre-back/pkg/token"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestVerifySession(t *testing.T) {
+	current, rotated := uuid.New(), uuid.New()
+
+	active := entity.Session{ID: uuid.New(), UserID: 1, RefreshTokenID: current, ExpiresAt: time.Now().Add(time.Hour)}
+	blocked := active
+	blocked.IsBlocked = true
+	expired := active
+	expired.ExpiresAt = time.Now().Add(-time.Minute)
+
+	testCases := []struct {
+		name     string
+		session  entity.Session
+		payload  token.Payload
+		expected error
+	}{
+		{name: "Access", session: active, payload: token.Payload{TokenID: uuid.New(), Purpose: token.PurposeAccess, UserID: 1}},
+		{name: "Refresh", session: active, payload: token.Payload{TokenID: current, Purpose: token.PurposeRefresh, UserID: 1}},
+		{name: "RotatedRefresh", session: active, payload: token.Payload{TokenID: rotated, Purpose: token.PurposeRefresh, UserID: 1}, expected: errTokenReused},
+		{name: "OtherUser", session: active, payload: token.Payload{TokenID: current, Purpose: token.PurposeRefresh, UserID: 2}, expected: errSessionMismatch},
+		{name: "BlockedAccess", session: blocked, payload: token.Payload{TokenID: uuid.New(), Purpose: token.PurposeAccess, UserID: 1}, expected: errSessionRevoked},
+		{name: "BlockedRefresh", session: blocked, payload: token.Payload{TokenID: current, Purpose: token.PurposeRefresh, UserID: 1}, expected: errSessionRevoked},
+		{name: "Expired", session: expired, payload: token.Payload{TokenID: uuid.New(), Purpose: token.PurposeAccess, UserID: 1}, expected: errSessionRevoked},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, verifySession(&tc.session, &tc.payload))
+		})
+	}
+}
+
+func TestVerifySessionLookup(t *testing.T) {
+	sessions := map[uuid.UUID]*entity.Session{}
+
+	defer func(find func(uuid.UUID) (*entity.Session, error)) { findSession = find }(findSession)
+	findSession = func(id uuid.UUID) (*entity.Session, error) {
+		if session, ok := sessions[id]; ok {
+			return session, nil
+		}
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	session := &entity.Session{ID: uuid.New(), UserID: 1, ExpiresAt: time.Now().Add(time.Hour)}
+	sessions[session.ID] = session
+
+	payload := &token.Payload{TokenID: uuid.New(), Purpose: token.PurposeAccess, SessionID: session.ID, UserID: 1}
+	require.NoError(t, VerifySession(payload))
+
+	// A logout blocks the session of the access token.
+	session.IsBlocked = true
+	require.Equal(t, errSessionRevoked, VerifySession(payload))
+
+	// The session of a deleted user is gone.
+	delete(sessions, session.ID)
+	require.Equal(t, errSessionNotFound, VerifySession(payload))
+
+	// Device tokens are not bound to a session.
+	require.NoError(t, VerifySession(&token.Payload{TokenID: uuid.New(), Purpose: token.PurposeAccess, DeviceID: 1}))
+}
@@ -0,0 +1,321 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/alexanderbkl/vidre-back/internal/constant"
+	"github.com/alexanderbkl/vidre-back/internal/entity"
+	"github.com/alexanderbkl/vidre-back/internal/form"
+	"github.com/gin-gonic/gin"
+)
+
+var errInvalidRole = errors.New("invalid role")
+
+// checkSecondFactor verifies the TOTP or recovery code if the
+// user has two-factor authentication enabled, and rejects users without it
+// if their role requires it.
+func checkSecondFactor(user *entity.User, otp, recoveryCode string) error {
+	if !user.TotpEnabled {
+		policy, err := entity.FindRolePolicy(user.Role)
+		if err != nil {
+			return err
+		}
+
+		if policy.RequireMFA {
+			return entity.ErrTotpEnrollment
+		}
+
+		return nil
+	}
+
+	var ok bool
+	var err error
+
+	switch {
+	case otp != "":
+		ok, err = user.VerifyTotp(otp)
+	case recoveryCode != "":
+		ok, err = entity.UseRecoveryCode(user.ID, recoveryCode)
+	default:
+		return entity.ErrTotpRequired
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		log.Debugf("api: invalid two-factor code for %s", user.Name)
+		return entity.ErrTotpInvalid
+	}
+
+	return nil
+}
+
+// secondFactorStatus returns the response status code for errors of checkSecondFactor.
+func secondFactorStatus(err error) int {
+	switch {
+	case errors.Is(err, entity.ErrTotpRequired), errors.Is(err, entity.ErrTotpInvalid):
+		return http.StatusUnauthorized
+	case errors.Is(err, entity.ErrTotpEnrollment):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// SetupTotp creates a new TOTP secret for the user and returns it with a provisioning URI.
+// The secret is not required at login until it has been confirmed with EnableTotp.
+//
+// POST /api/auth/totp/setup
+// - JSON body:
+//   - name: string
+//   - password: string
+func SetupTotp(router *gin.RouterGroup) {
+	router.POST("/auth/totp/setup", func(ctx *gin.Context) {
+		var req form.LoginPasswordRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		user, err := checkCredentials(req)
+		if err != nil {
+			ctx.JSON(http.StatusUnauthorized, ErrorResponse(err))
+			return
+		}
+
+		if err := user.SetupTotp(); err != nil {
+			ctx.JSON(http.StatusConflict, ErrorResponse(err))
+			return
+		}
+
+		if err := user.Save(); err != nil {
+			log.Errorf("cannot save user: %s", err)
+			AbortSaveFailed(ctx)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, form.TotpSetupResponse{
+			Secret: user.TotpSecret,
+			URI:    user.TotpURI(constant.TotpIssuer),
+		})
+	})
+}
+
+// EnableTotp confirms the TOTP secret with a code, enables two-factor
+// authentication and returns new recovery codes.
+//
+// POST /api/auth/totp/enable
+// - JSON body:
+//   - name: string
+//   - password: string
+//   - otp: string
+func EnableTotp(router *gin.RouterGroup) {
+	router.POST("/auth/totp/enable", func(ctx *gin.Context) {
+		var req form.LoginPasswordRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		user, err := checkCredentials(req)
+		if err != nil {
+			ctx.JSON(http.StatusUnauthorized, ErrorResponse(err))
+			return
+		}
+
+		if user.TotpEnabled {
+			ctx.JSON(http.StatusConflict, ErrorResponse(entity.ErrTotpEnabled))
+			return
+		} else if user.TotpSecret == "" {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse(entity.ErrTotpNotSetup))
+			return
+		}
+
+		if ok, err := user.VerifyTotp(req.Otp); err != nil {
+			log.Errorf("cannot verify totp: %s", err)
+			AbortUnexpected(ctx)
+			return
+		} else if !ok {
+			ctx.JSON(http.StatusUnauthorized, ErrorResponse(entity.ErrTotpInvalid))
+			return
+		}
+
+		if err := user.EnableTotp(); err != nil {
+			log.Errorf("cannot enable totp: %s", err)
+			AbortSaveFailed(ctx)
+			return
+		}
+
+		codes, err := entity.NewRecoveryCodes(user.ID)
+		if err != nil {
+			log.Errorf("cannot create recovery codes: %s", err)
+			AbortSaveFailed(ctx)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, form.RecoveryCodesResponse{RecoveryCodes: codes})
+	})
+}
+
+// DisableTotp disables two-factor authentication of the user, unless it is required for the role.
+//
+// POST /api/auth/totp/disable
+// - JSON body:
+//   - name: string
+//   - password: string
+//   - otp: string (or recovery_code)
+func DisableTotp(router *gin.RouterGroup) {
+	router.POST("/auth/totp/disable", func(ctx *gin.Context) {
+		var req form.LoginPasswordRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		user, err := checkCredentials(req)
+		if err != nil {
+			ctx.JSON(http.StatusUnauthorized, ErrorResponse(err))
+			return
+		}
+
+		if !user.TotpEnabled {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse(entity.ErrTotpNotSetup))
+			return
+		}
+
+		if err := checkSecondFactor(user, req.Otp, req.RecoveryCode); err != nil {
+			ctx.JSON(secondFactorStatus(err), ErrorResponse(err))
+			return
+		}
+
+		if policy, err := entity.FindRolePolicy(user.Role); err != nil {
+			log.Errorf("cannot find role policy: %s", err)
+			AbortUnexpected(ctx)
+			return
+		} else if policy.RequireMFA {
+			ctx.JSON(http.StatusForbidden, ErrorResponse(entity.ErrTotpEnrollment))
+			return
+		}
+
+		if err := user.DisableTotp(); err != nil {
+			log.Errorf("cannot disable totp: %s", err)
+			AbortSaveFailed(ctx)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+	})
+}
+
+// RenewRecoveryCodes replaces the recovery codes of the user.
+//
+// POST /api/auth/totp/recovery_codes
+// - JSON body:
+//   - name: string
+//   - password: string
+//   - otp: string
+func RenewRecoveryCodes(router *gin.RouterGroup) {
+	router.POST("/auth/totp/recovery_codes", func(ctx *gin.Context) {
+		var req form.LoginPasswordRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		user, err := checkCredentials(req)
+		if err != nil {
+			ctx.JSON(http.StatusUnauthorized, ErrorResponse(err))
+			return
+		}
+
+		if !user.TotpEnabled {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse(entity.ErrTotpNotSetup))
+			return
+		}
+
+		// Recovery codes cannot be used to create new ones.
+		if err := checkSecondFactor(user, req.Otp, ""); err != nil {
+			ctx.JSON(secondFactorStatus(err), ErrorResponse(err))
+			return
+		}
+
+		codes, err := entity.NewRecoveryCodes(user.ID)
+		if err != nil {
+			log.Errorf("cannot create recovery codes: %s", err)
+			AbortSaveFailed(ctx)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, form.RecoveryCodesResponse{RecoveryCodes: codes})
+	})
+}
+
+// ResetUserTotp disables two-factor authentication of a user, e.g. after losing the device.
+//
+// DELETE /api/users/:uid/totp
+func ResetUserTotp(router *gin.RouterGroup) {
+	router.DELETE("/users/:uid/totp", func(ctx *gin.Context) {
+		user, err := entity.FindUserByUID(ctx.Param("uid"))
+		if err != nil {
+			AbortEntityNotFound(ctx)
+			return
+		}
+
+		if err := user.DisableTotp(); err != nil {
+			log.Errorf("cannot disable totp: %s", err)
+			AbortSaveFailed(ctx)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication reset"})
+	})
+}
+
+// GetRolePolicies returns the security policies of all roles.
+//
+// GET /api/auth/policies
+func GetRolePolicies(router *gin.RouterGroup) {
+	router.GET("/auth/policies", func(ctx *gin.Context) {
+		policies, err := entity.AllRolePolicies()
+		if err != nil {
+			log.Errorf("cannot find role policies: %s", err)
+			ctx.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusOK, policies)
+	})
+}
+
+// UpdateRolePolicy sets whether users with a role must use two-factor authentication.
+//
+// PUT /api/auth/policies
+// - JSON body:
+//   - role: string
+//   - require_mfa: bool
+func UpdateRolePolicy(router *gin.RouterGroup) {
+	router.PUT("/auth/policies", func(ctx *gin.Context) {
+		var req form.RolePolicyRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		if !constant.ValidRole(req.Role) {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse(errInvalidRole))
+			return
+		}
+
+		policy := entity.RolePolicy{Role: req.Role, RequireMFA: req.RequireMFA}
+		if err := policy.Save(); err != nil {
+			log.Errorf("cannot save role policy: %s", err)
+			AbortSaveFailed(ctx)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, policy)
+	})
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/alexanderbkl/vidre-back/internal/constant"
+	"github.com/alexanderbkl/vidre-back/internal/entity"
+	"github.com/alexanderbkl/vidre-back/internal/form"
+	"github.com/alexanderbkl/vidre-back/pkg/ethsig"
+	"github.com/alexanderbkl/vidre-back/pkg/token"
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	errInvalidNonce     = errors.New("nonce is invalid, expired or already used")
+	errSignatureInvalid = errors.New("signature does not match wallet address")
+	errWalletNotLinked  = errors.New("wallet is not linked to a user")
+)
+
+// GetLoginNonce returns a single-use nonce and the message a wallet must sign to log in.
+//
+// GET /api/auth/nonce
+func GetLoginNonce(router *gin.RouterGroup) {
+	router.GET("/auth/nonce", func(ctx *gin.Context) {
+		nonce, err := entity.NewLoginNonce()
+		if err != nil {
+			log.Errorf("cannot create login nonce: %s", err)
+			ctx.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusOK, form.LoginNonceResponse{
+			Nonce:     nonce.Nonce,
+			Message:   string(constant.BuildLoginMessage(nonce.Nonce)),
+			ExpiresAt: nonce.ExpiresAt,
+		})
+	})
+}
+
+// LoginWallet authenticates the user linked to a wallet with a personal_sign
+// signature of the login message and returns an access and refresh token.
+//
+// POST /api/auth/wallet
+// - JSON body:
+//   - wallet_address: string
+//   - signature: string
+//   - nonce: string
+//   - otp: string (optional)
+//   - recovery_code: string (optional)
+func LoginWallet(router *gin.RouterGroup, tokenMaker token.Maker) {
+	router.POST("/auth/wallet", func(ctx *gin.Context) {
+		var req form.LoginUserRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		address, err := ethsig.NormalizeAddress(req.WalletAddress)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		if ok, err := entity.UseLoginNonce(req.Nonce); err != nil {
+			log.Errorf("cannot use login nonce: %s", err)
+			ctx.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			return
+		} else if !ok {
+			ctx.JSON(http.StatusUnauthorized, ErrorResponse(errInvalidNonce))
+			return
+		}
+
+		signer, err := ethsig.RecoverAddress(constant.BuildLoginMessage(req.Nonce), req.Signature)
+		if err != nil || signer != address {
+			log.Debugf("api: wallet login failed for %s", address)
+			ctx.JSON(http.StatusUnauthorized, ErrorResponse(errSignatureInvalid))
+			return
+		}
+
+		user, err := entity.FindUserByWallet(address)
+		if err != nil {
+			ctx.JSON(http.StatusUnauthorized, ErrorResponse(errWalletNotLinked))
+			return
+		}
+
+		if err := checkSecondFactor(user, req.Otp, req.RecoveryCode); err != nil {
+			ctx.JSON(secondFactorStatus(err), ErrorResponse(err))
+			return
+		}
+
+		rsp, err := createLoginResponse(ctx, tokenMaker, user)
+		if err != nil {
+			log.Errorf("cannot create tokens: %s", err)
+			ctx.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			return
+		}
+
+		rsp.WalletAddress = address
+
+		ctx.JSON(http.StatusOK, rsp)
+	})
+}
@@ -1,13 +1,18 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
+	"github.com/alexanderbkl/vidre-back/internal/clock"
+	"github.com/alexanderbkl/vidre-back/internal/config"
 	"github.com/alexanderbkl/vidre-back/internal/db"
 	"github.com/alexanderbkl/vidre-back/internal/entity"
+	"github.com/alexanderbkl/vidre-back/internal/form"
 	"github.com/alexanderbkl/vidre-back/internal/query"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // GetWorkDay returns the work schedule for a worker on a given date frame.
@@ -49,32 +54,44 @@ func GetWorkDay(router *gin.RouterGroup) {
 		}
 
 		// Find the work schedules for the worker on the given date frame
-		var workSchedules []entity.WorkSchedule
-
-		result := db.Db().Where("worker_id = ? AND date >= ? AND date <= ?", workerId, startDate, endDate).Find(&workSchedules)
-
-		if result.Error != nil {
-			log.Errorf("Error finding work schedules: %v", result.Error)
+		workSchedules, err := entity.FindWorkSchedules(db.Db(), workerId, startDate, endDate)
+		if err != nil {
+			log.Errorf("Error finding work schedules: %v", err)
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Could not find work schedules"})
 			return
 		}
 
+		for i := range workSchedules {
+			workSchedules[i].In(config.Location())
+		}
+
 		ctx.JSON(http.StatusOK, gin.H{"work_schedules": workSchedules})
 	})
 }
 
 // PostWorkDay records the entry, exit and rest times for a worker's workday.
+// Clients can send an Idempotency-Key header to retry the request safely, see
+// middlewares.Idempotency.
+//
+// Punches from phones can send the location of the worker. With the reject
+// geofence policy, punches outside the geofences of the site or without
+// location are recorded for review but not applied, and answered with 403
+// Forbidden. Punches that the work day rejects are answered with 409
+// Conflict. Punches recorded with assumed times are answered with 200 OK and
+// the message for the worker.
 //
 // POST /api/worker/work_day
 func PostWorkDay(router *gin.RouterGroup) {
 	router.POST("/worker/work_day", func(ctx *gin.Context) {
 		var payload struct {
-			WorkerCode string `json:"worker_code"`
-			Date       string `json:"date"` // Assuming the date comes in as a string like "2006-01-02"
-			Type       string `json:"type"` // Type can be "entry", "startRest", "endRest", "exit"
-			Time       string `json:"time"` // Assuming time comes in as a string like "15:04"
+			WorkerCode    string `json:"worker_code"`
+			Date          string `json:"date"`       // Ignored, the date is the day of the time in the business time zone
+			Type          string `json:"type"`       // Type can be "entry", "startRest", "endRest", "exit"
+			Time          string `json:"time"`       // RFC 3339 timestamp, local time in the business time zone without offset
+			Pin           string `json:"pin"`        // Only required if the worker has a PIN
+			BreakType     string `json:"break_type"` // "paid" or "unpaid" for startRest, defaults to "unpaid"
+			form.Location        // Optional latitude, longitude and accuracy of phones
 		}
-		headersWritten := false
 
 		if err := ctx.ShouldBindJSON(&payload); err != nil {
 			log.Errorf("Error binding JSON: %v", err)
@@ -82,232 +99,87 @@ func PostWorkDay(router *gin.RouterGroup) {
 			return
 		}
 
-		log.Printf("Payload: %v", payload)
-		workerId, err := query.GetWorkerIDFromCode(payload.WorkerCode)
+		log.Printf("Payload: %v %v %v %v", payload.WorkerCode, payload.Date, payload.Type, payload.Time)
+		worker, err := query.GetWorkerFromCode(payload.WorkerCode)
 		if err != nil {
-			log.Errorf("Error getting worker ID from code: %v", err)
+			log.Errorf("Error getting worker from code: %v", err)
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Could not get worker ID from code"})
 			return
 		}
+		workerId := worker.ID
 
-		// Parse the date and time strings
-		date, err := time.Parse("2006-01-02", payload.Date)
-		if err != nil {
-			log.Errorf("Error parsing date: %v", err)
-			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format"})
+		var deviceId *uint
+		if p := authPayload(ctx); p != nil && p.DeviceID != 0 {
+			deviceId = &p.DeviceID
+		}
+
+		if status, msg := checkPin(ctx, worker, payload.Pin, deviceId); status != 0 {
+			ctx.JSON(status, gin.H{"error": msg})
 			return
 		}
-		timeParsed, err := time.Parse("2006-01-02T15:04:05.000Z", payload.Time)
+
+		// Parse the time, the work day is the day of the time in the business time zone
+		timeParsed, err := clock.ParseTime(payload.Time, config.Location())
 		if err != nil {
 			log.Errorf("Error parsing time: %v", err)
 			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid time format"})
 			return
 		}
+		date := clock.Date(timeParsed, config.Location())
 
-		// Find or initialize the work schedule for the worker on the given date
-		var workSchedule entity.WorkSchedule
-		var workSchedulePreviousDay entity.WorkSchedule
-		log.Printf("Worker ID: %v", workerId)
-		result := db.Db().FirstOrCreate(&workSchedule, entity.WorkSchedule{
-			WorkerID: workerId,
-			Date:     date,
-		})
-
-		if result.Error != nil {
-			log.Errorf("Error finding or creating work schedule: %v", result.Error)
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Could not find or create work schedule"})
-			return
+		// Record the punch, the work schedule of the day is recomputed from the punches
+		punch := entity.Punch{
+			WorkerID:  workerId,
+			Date:      date,
+			Type:      payload.Type,
+			Time:      timeParsed,
+			BreakType: payload.BreakType,
+			Source:    entity.PunchSourceClock,
+			DeviceID:  deviceId,
 		}
 
-		// Find the work schedule for the worker on the previous day
-		result = db.Db().FirstOrCreate(&workSchedulePreviousDay, entity.WorkSchedule{
-			WorkerID: workerId,
-			Date:     date.AddDate(0, 0, -1),
-		})
-
-		if result.Error != nil {
-			log.Errorf("Error finding or creating work schedule: %v", result.Error)
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Could not find or create work schedule"})
+		if err := locatePunch(&punch, payload.Location); errors.Is(err, errInvalidLocation) {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		} else if err != nil {
+			log.Errorf("Error checking geofences: %v", err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Could not check location"})
 			return
 		}
 
-		// Update the relevant time based on the type of payload
-		switch payload.Type {
-		case "entry":
-			if workSchedule.EntryHour.IsZero() {
-				workSchedule.EntryHour = timeParsed
-				if workSchedulePreviousDay.ExitHour.IsZero() && !workSchedulePreviousDay.EntryHour.IsZero() {
-					// it means that the worker has not exited the previous day
-					log.Errorf("Error: entry time cannot be set without exit time")
-					headersWritten = true
-					ctx.JSON(http.StatusInternalServerError, gin.H{"error": "NO HA MARCADO SALIDA EN EL DIA DE AYER, COMUNIQUE LA SALIDA A GERENCIA"})
-				}
-			} else if !workSchedule.ExitHour.IsZero() {
-				log.Errorf("Error: entry time cannot be set after exit time")
-				headersWritten = true
-				ctx.JSON(http.StatusInternalServerError, gin.H{"error": "NO SE PUEDE REGISTRAR LA ENTRADA DESPUÉS DE LA SALIDA"})
-			} else {
-				log.Errorf("Error: entry time cannot be set twice")
-				headersWritten = true
-				ctx.JSON(http.StatusInternalServerError, gin.H{"error": "NO SE PUEDE REGISTRAR LA ENTRADA DOS VECES"})
-			}
-		case "startRest":
-			if workSchedule.LunchStartHour.IsZero() {
-				//lunch not started so it is breakfast
-				if workSchedule.BreakfastStartHour.IsZero() && !workSchedule.EntryHour.IsZero() {
-					workSchedule.BreakfastStartHour = timeParsed
-				} else if !workSchedule.BreakfastStartHour.IsZero() && !workSchedule.BreakfastEndHour.IsZero() && workSchedule.LunchStartHour.IsZero() {
-					workSchedule.LunchStartHour = timeParsed
-				} else if !workSchedule.BreakfastStartHour.IsZero() && !workSchedule.LunchStartHour.IsZero() {
-					log.Errorf("Error: startRest time cannot be set twice")
-					headersWritten = true
-					ctx.JSON(http.StatusInternalServerError, gin.H{"error": "NO SE PUEDE REGISTRAR EL INICIO DEL DESCANSO DOS VECES"})
-				} else if workSchedule.BreakfastStartHour.IsZero() && workSchedule.EntryHour.IsZero() {
-					//log.Errorf("Error: startRest time cannot be set without entry time")
-					headersWritten = true
-					workSchedule.EntryHour = timeParsed
-					workSchedule.BreakfastStartHour = timeParsed
-					ctx.JSON(http.StatusInternalServerError, gin.H{"error": "NO HA MARCADO ENTRADA, COMUNIQUE LA ENTRADA A GERENCIA"})
-				} else {
-					log.Errorf("Error: startRest time cannot be set after endRest time")
-					headersWritten = true
-					ctx.JSON(http.StatusInternalServerError, gin.H{"error": "NO SE PUEDE REGISTRAR EL INICIO DEL DESCANSO DESPUÉS DE REGISTRAR EL FIN DEL DESCANSO"})
-				}
-			} else {
-				//lunch started so it is lunch
-				if workSchedule.LunchStartHour.IsZero() && !workSchedule.EntryHour.IsZero() {
-					workSchedule.LunchStartHour = timeParsed
-				} else if !workSchedule.LunchStartHour.IsZero() && !workSchedule.LunchEndHour.IsZero() {
-					log.Errorf("Error: startRest time cannot be set twice")
-					headersWritten = true
-					ctx.JSON(http.StatusInternalServerError, gin.H{"error": "NO SE PUEDE REGISTRAR EL INICIO DEL DESCANSO DOS VECES"})
-				} else if workSchedule.LunchStartHour.IsZero() && workSchedule.EntryHour.IsZero() {
-					//log.Errorf("Error: startRest time cannot be set without entry time")
-					headersWritten = true
-					workSchedule.EntryHour = timeParsed
-					workSchedule.LunchStartHour = timeParsed
-					ctx.JSON(http.StatusInternalServerError, gin.H{"error": "NO HA MARCADO ENTRADA, COMUNIQUE LA ENTRADA A GERENCIA"})
-				} else {
-					log.Errorf("Error: startRest time cannot be set after endRest time")
-					headersWritten = true
-					ctx.JSON(http.StatusInternalServerError, gin.H{"error": "NO SE PUEDE REGISTRAR EL INICIO DEL DESCANSO DESPUÉS DE REGISTRAR EL FIN DEL DESCANSO"})
-				}
-			}
-		case "endRest":
-			// if payload.Type is endRest and startRest is not set, return error
-			if workSchedule.EntryHour.IsZero() {
-				log.Errorf("Error: endRest time cannot be set without entry time")
-				headersWritten = true
-				time := timeParsed.Add(-30 * time.Minute)
-				if workSchedule.BreakfastStartHour.IsZero() && workSchedule.BreakfastEndHour.IsZero() {
-					workSchedule.EntryHour = timeParsed
-					workSchedule.BreakfastStartHour = time
-					workSchedule.BreakfastEndHour = timeParsed
-					ctx.JSON(http.StatusInternalServerError, gin.H{"error": "NO HA MARCADO ENTRADA, COMUNIQUE LA ENTRADA A GERENCIA, SE INDICA INICIO DEL PRIMER DESCANSO A LAS " + time.Format("15:04")})
-				} else if !workSchedule.BreakfastStartHour.IsZero() && workSchedule.BreakfastEndHour.IsZero() {
-					workSchedule.BreakfastEndHour = timeParsed
-					ctx.JSON(http.StatusInternalServerError, gin.H{"error": "NO HA MARCADO EL FIN DEL PRIMER DESCANSO, SE INDICA EL FIN A LAS " + time.Format("15:04")})
-				} else if !workSchedule.BreakfastStartHour.IsZero() && !workSchedule.BreakfastEndHour.IsZero() && workSchedule.LunchStartHour.IsZero() {
-					workSchedule.LunchStartHour = timeParsed
-					ctx.JSON(http.StatusInternalServerError, gin.H{"error": "NO HA MARCADO EL INICIO DEL SEGUNDO DESCANSO, SE INDICA EL INICIO A LAS " + time.Format("15:04")})
-				} else if !workSchedule.BreakfastStartHour.IsZero() && !workSchedule.BreakfastEndHour.IsZero() && !workSchedule.LunchStartHour.IsZero() && workSchedule.LunchEndHour.IsZero() {
-					workSchedule.LunchEndHour = timeParsed
-					ctx.JSON(http.StatusInternalServerError, gin.H{"error": "NO HA MARCADO EL FIN DEL SEGUNDO DESCANSO, SE INDICA EL FIN A LAS " + time.Format("15:04")})
-				} else {
-					ctx.JSON(http.StatusInternalServerError, gin.H{"error": "NO HA MARCADO ENTRADA, COMUNIQUE LA ENTRADA A ADMINISTRADORES"})
-				}
-
-			} else if workSchedule.BreakfastStartHour.IsZero() {
-				log.Errorf("Error: endRest time cannot be set without startRest time")
-				//time 30 minutes before the end of the rest in spanish timezones
-				time := timeParsed.Add(-30 * time.Minute)
-				//set the startRest time to the time calculated
-				workSchedule.BreakfastStartHour = time
-				workSchedule.BreakfastEndHour = timeParsed
-				headersWritten = true
-				ctx.JSON(http.StatusInternalServerError, gin.H{"error": "NO HA INDICADO EL INICIO DEL PRIMER DESCANSO, SE INDICA EL INICIO A LAS " + time.Format("15:04")})
-			} else if !workSchedule.BreakfastStartHour.IsZero() && workSchedule.BreakfastEndHour.IsZero() && workSchedule.LunchStartHour.IsZero() && workSchedule.LunchEndHour.IsZero() {
-				/*
-					log.Errorf("Error: endRest time cannot be set without startRest time")
-					//time 30 minutes before the end of the rest in spanish timezones
-					time := timeParsed.Add(-30 * time.Minute)
-					//set the startRest time to the time calculated
-					workSchedule.LunchStartHour = time
-					headersWritten = true
-					ctx.JSON(http.StatusInternalServerError, gin.H{"error": "NO HA INDICADO EL INICIO DEL SEGUNDO DESCANSO, SE INDICA EL INICIO A LAS " + time.Format("15:04")})
-				*/
-				workSchedule.BreakfastEndHour = timeParsed
-
-			} else if !workSchedule.LunchStartHour.IsZero() && workSchedule.LunchEndHour.IsZero() {
-				workSchedule.LunchEndHour = timeParsed
-			} else if !workSchedule.LunchStartHour.IsZero() && !workSchedule.LunchEndHour.IsZero() {
-				log.Errorf("Error: endRest time cannot be set twice")
-				headersWritten = true
-				ctx.JSON(http.StatusInternalServerError, gin.H{"error": "NO SE PUEDE REGISTRAR EL FIN DEL SEGUNDO DESCANSO DOS VECES"})
-			} else if !workSchedule.ExitHour.IsZero() {
-				log.Errorf("Error: endRest time cannot be set after exit time")
-				headersWritten = true
-				ctx.JSON(http.StatusInternalServerError, gin.H{"error": "NO SE PUEDE REGISTRAR EL FIN DEL DESCANSO DESPUÉS DE LA SALIDA"})
-			} else if workSchedule.EntryHour.IsZero() {
-				log.Errorf("Error: endRest time cannot be set without entry time")
-				headersWritten = true
-				ctx.JSON(http.StatusInternalServerError, gin.H{"error": "NO SE PUEDE REGISTRAR EL FIN DEL DESCANSO SIN REGISTRAR LA ENTRADA"})
-			} else {
-				log.Errorf("Error: endRest time cannot be set without startRest time")
-				//time 30 minutes before the end of the rest in spanish timezones
-				time := timeParsed.Add(-30 * time.Minute)
-				//set the startRest time to the time calculated
-				workSchedule.LunchStartHour = time
-				workSchedule.LunchEndHour = timeParsed
-				headersWritten = true
-				ctx.JSON(http.StatusInternalServerError, gin.H{"error": "NO HA INDICADO EL INICIO DEL SEGUNDO DESCANSO, SE INDICA EL INICIO A LAS " + time.Format("15:04")})
-
-			}
-		case "exit":
-			if workSchedule.EntryHour.IsZero() {
-				log.Errorf("Error: exit time cannot be set without entry time")
-				headersWritten = true
-				ctx.JSON(http.StatusInternalServerError, gin.H{"error": "NO SE PUEDE REGISTRAR LA SALIDA SIN REGISTRAR LA ENTRADA"})
-				/*
-					} else if workSchedule.BreakfastEndHour.IsZero() || workSchedule.LunchEndHour.IsZero() {
-						log.Errorf("Error: exit time cannot be set without endRest time")
-						headersWritten = true
-						ctx.JSON(http.StatusInternalServerError, gin.H{"error": "NO SE PUEDE REGISTRAR LA SALIDA SIN REGISTRAR EL FIN DEL DESCANSO"})
-					} else if workSchedule.BreakfastStartHour.IsZero() || workSchedule.LunchStartHour.IsZero() {
-						log.Errorf("Error: exit time cannot be set without startRest time")
-						headersWritten = true
-						ctx.JSON(http.StatusInternalServerError, gin.H{"error": "NO SE PUEDE REGISTRAR LA SALIDA SIN REGISTRAR EL INICIO DEL DESCANSO"})
-				*/
-			} else if !workSchedule.ExitHour.IsZero() {
-				log.Errorf("Error: exit time cannot be set twice")
-				headersWritten = true
-				ctx.JSON(http.StatusInternalServerError, gin.H{"error": "NO SE PUEDE REGISTRAR LA SALIDA DOS VECES"})
-			} else if workSchedule.EntryHour.IsZero() {
-				log.Errorf("Error: exit time cannot be set without entry time")
-				headersWritten = true
-				ctx.JSON(http.StatusInternalServerError, gin.H{"error": "NO SE PUEDE REGISTRAR LA SALIDA SIN REGISTRAR LA ENTRADA"})
-			} else {
-				workSchedule.ExitHour = timeParsed
-			}
-		default:
+		workSchedule, result, err := clock.Punch(&punch, config.Env().MaxShiftDuration)
+		if errors.Is(err, clock.ErrInvalidType) {
 			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid type"})
 			return
+		} else if errors.Is(err, clock.ErrInvalidBreakType) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid break type"})
+			return
+		} else if err != nil {
+			log.Errorf("Error recording punch: %v", err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Could not update work schedule"})
+			return
 		}
 
-		// Save the updated work schedule
-		if err := db.Db().Save(&workSchedule).Error; err != nil {
-			log.Errorf("Error updating work schedule: %v", err)
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Could not update work schedule"})
+		if status := punchStatus(&punch, result); status != http.StatusOK {
+			ctx.JSON(status, gin.H{"error": result.Message})
 			return
 		}
 
-		if !headersWritten {
-			ctx.JSON(http.StatusOK, gin.H{"message": "Work schedule updated successfully", "work_schedule": workSchedule})
+		msg := result.Message
+		if msg == "" {
+			msg = "Work schedule updated successfully"
 		}
+
+		ctx.JSON(http.StatusOK, gin.H{"message": msg, "work_schedule": workSchedule.In(config.Location())})
 	})
 }
 
 // AddWorkDay adds a workday by worker code and workday.
+//
+// The breaks of the day are given as a list of intervals with a type, either
+// "paid" or "unpaid". The breakfast and lunch fields are still accepted if
+// no breaks are given. A reason for the change is required.
+//
 // POST /api/worker/work_day/add
 func AddWorkDay(router *gin.RouterGroup) {
 	router.POST("/worker/work_day/add", func(ctx *gin.Context) {
@@ -320,6 +192,12 @@ func AddWorkDay(router *gin.RouterGroup) {
 			EndBreakfastHour   string `json:"endBreakfastHour"`
 			StartLunchHour     string `json:"startLunchHour"`
 			EndLunchHour       string `json:"endLunchHour"`
+			Breaks             []struct {
+				Type      string `json:"type"`
+				StartHour string `json:"start_hour"`
+				EndHour   string `json:"end_hour"`
+			} `json:"breaks"`
+			Reason string `json:"reason"`
 		}
 
 		if err := ctx.ShouldBindJSON(&payload); err != nil {
@@ -328,6 +206,10 @@ func AddWorkDay(router *gin.RouterGroup) {
 			return
 		}
 
+		if !checkReason(ctx, payload.Reason) {
+			return
+		}
+
 		workerId, err := query.GetWorkerIDFromCode(payload.WorkerCode)
 		if err != nil {
 			log.Errorf("Error getting worker ID from code: %v", err)
@@ -342,80 +224,95 @@ func AddWorkDay(router *gin.RouterGroup) {
 			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format"})
 			return
 		}
-		enterHour, err := time.Parse("2006-01-02T15:04:05.000Z", payload.EnterHour)
+		enterHour, err := clock.ParseTime(payload.EnterHour, config.Location())
 		if err != nil {
 			log.Errorf("Error parsing enterHour: %v", err)
 			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid time format"})
 			return
 		}
-		exitHour, err := time.Parse("2006-01-02T15:04:05.000Z", payload.ExitHour)
+		exitHour, err := clock.ParseTime(payload.ExitHour, config.Location())
 		if err != nil {
 			log.Errorf("Error parsing exitHour: %v", err)
 			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid time format"})
 			return
 		}
-		startBreakfastHour, err := time.Parse("2006-01-02T15:04:05.000Z", payload.StartBreakfastHour)
-		if err != nil {
-			log.Errorf("Error parsing startRestHour: %v", err)
-			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid time format"})
-			return
-		}
-		endBreakfastHour, err := time.Parse("2006-01-02T15:04:05.000Z", payload.EndBreakfastHour)
-		if err != nil {
-			log.Errorf("Error parsing endRestHour: %v", err)
-			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid time format"})
-			return
-		}
-		startLunchHour, err := time.Parse("2006-01-02T15:04:05.000Z", payload.StartLunchHour)
-		if err != nil {
-			log.Errorf("Error parsing startRestHour: %v", err)
-			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid time format"})
-			return
-		}
-		endLunchHour, err := time.Parse("2006-01-02T15:04:05.000Z", payload.EndLunchHour)
-		if err != nil {
-			log.Errorf("Error parsing endRestHour: %v", err)
-			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid time format"})
-			return
-		}
-		// Find or initialize the work schedule for the worker on the given date
-		var workSchedule entity.WorkSchedule
-		result := db.Db().FirstOrCreate(&workSchedule, entity.WorkSchedule{
-			WorkerID: workerId,
-			Date:     date,
-		})
 
-		if result.Error != nil {
-			log.Errorf("Error finding or creating work schedule: %v", result.Error)
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Could not find or create work schedule"})
-			return
+		day := entity.WorkSchedule{EntryHour: enterHour, ExitHour: exitHour}
+
+		if len(payload.Breaks) > 0 {
+			for _, b := range payload.Breaks {
+				if b.Type == "" {
+					b.Type = entity.BreakUnpaid
+				} else if !entity.ValidBreakType(b.Type) {
+					ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid break type"})
+					return
+				}
+				startHour, err := clock.ParseTime(b.StartHour, config.Location())
+				if err != nil {
+					log.Errorf("Error parsing break start_hour: %v", err)
+					ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid time format"})
+					return
+				}
+				endHour, err := clock.ParseTime(b.EndHour, config.Location())
+				if err != nil {
+					log.Errorf("Error parsing break end_hour: %v", err)
+					ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid time format"})
+					return
+				}
+				day.Breaks = append(day.Breaks, entity.WorkBreak{Type: b.Type, StartHour: startHour, EndHour: endHour})
+			}
+		} else {
+			legacy := []struct{ start, end string }{
+				{payload.StartBreakfastHour, payload.EndBreakfastHour},
+				{payload.StartLunchHour, payload.EndLunchHour},
+			}
+			for _, b := range legacy {
+				startHour, err := clock.ParseTime(b.start, config.Location())
+				if err != nil {
+					log.Errorf("Error parsing startRestHour: %v", err)
+					ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid time format"})
+					return
+				}
+				endHour, err := clock.ParseTime(b.end, config.Location())
+				if err != nil {
+					log.Errorf("Error parsing endRestHour: %v", err)
+					ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid time format"})
+					return
+				}
+				day.Breaks = append(day.Breaks, entity.WorkBreak{Type: entity.BreakUnpaid, StartHour: startHour, EndHour: endHour})
+			}
 		}
 
-		// Update the relevant time based on the type of payload
-		workSchedule.EntryHour = enterHour
-		workSchedule.ExitHour = exitHour
-		workSchedule.BreakfastStartHour = startBreakfastHour
-		workSchedule.BreakfastEndHour = endBreakfastHour
-		workSchedule.LunchStartHour = startLunchHour
-		workSchedule.LunchEndHour = endLunchHour
+		// Replace all times of the day with correction punches
+		base := correctionPunch(ctx)
+		reset := base
+		reset.Type = entity.PunchReset
+		reset.Time = time.Now().UTC()
+
+		punches := append(entity.Punches{reset}, clock.SetPunches(&day, base)...)
 
-		// Save the updated work schedule
-		if err := db.Db().Save(&workSchedule).Error; err != nil {
+		var workSchedule *entity.WorkSchedule
+		err = auditWorkDays(ctx, payload.Reason, workerId, []time.Time{date}, func(tx *gorm.DB) (err error) {
+			workSchedule, err = clock.TxCorrect(tx, workerId, date, punches)
+			return err
+		})
+		if err != nil {
 			log.Errorf("Error updating work schedule: %v", err)
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Could not update work schedule"})
 			return
 		}
 
-		ctx.JSON(http.StatusOK, gin.H{"message": "Work schedule updated successfully", "work_schedule": workSchedule})
+		ctx.JSON(http.StatusOK, gin.H{"message": "Work schedule updated successfully", "work_schedule": workSchedule.In(config.Location())})
 	})
 }
 
 // DeleteWorkday deletes a workday by worker code and workday.
-// DELETE /api/worker/delete
+// DELETE /api/worker/work_day/delete?worker_code=&date=&reason=
 func DeleteWorkDay(router *gin.RouterGroup) {
 	router.DELETE("/worker/work_day/delete", func(ctx *gin.Context) {
 		code := ctx.Query("worker_code") // assuming code is passed as a query parameter
 		dateStr := ctx.Query("date")     // assuming date is passed as a query parameter
+		reason := ctx.Query("reason")
 
 		if code == "" {
 			log.Errorf("No code provided")
@@ -423,6 +320,10 @@ func DeleteWorkDay(router *gin.RouterGroup) {
 			return
 		}
 
+		if !checkReason(ctx, reason) {
+			return
+		}
+
 		workerId, err := query.GetWorkerIDFromCode(code)
 		if err != nil {
 			log.Errorf("Error getting worker ID from code: %v", err)
@@ -444,8 +345,15 @@ func DeleteWorkDay(router *gin.RouterGroup) {
 			return
 		}
 
-		// Delete the worker with the provided code
-		if err := db.Db().Where("worker_id = ? AND date = ?", workerId, date).Delete(&entity.WorkSchedule{}).Error; err != nil {
+		// Delete the work day with a correction punch
+		punch := correctionPunch(ctx)
+		punch.Type = entity.PunchDelete
+		punch.Time = time.Now().UTC()
+
+		if err := auditWorkDays(ctx, reason, workerId, []time.Time{date}, func(tx *gorm.DB) error {
+			_, err := clock.TxCorrect(tx, workerId, date, entity.Punches{punch})
+			return err
+		}); err != nil {
 			log.Errorf("cannot delete worker: %s", err)
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete worker"})
 			return
@@ -455,16 +363,20 @@ func DeleteWorkDay(router *gin.RouterGroup) {
 	})
 }
 
-// UpdateWorkDay updates a workday by worker code and workday.
+// UpdateWorkDay updates a workday by worker code and workday. A reason for
+// the change is required. Break corrections must refer to a break of the day,
+// or to the next one to add a break.
 // PUT /api/worker/work_day/update
 func UpdateWorkDay(router *gin.RouterGroup) {
 	router.PUT("/worker/work_day/update", func(ctx *gin.Context) {
-		log.Printf("test")
 		var payload struct {
 			WorkerCode string `json:"worker_code"`
-			Date       string `json:"date"` // Assuming the date comes in as a string like "2006-01-02"
-			Type       string `json:"type"` // Type can be "entry", "startRest", "endRest", "exit"
-			Time       string `json:"time"` // Assuming time comes in as a string like "15:04"
+			Date       string `json:"date"`        // Assuming the date comes in as a string like "2006-01-02"
+			Type       string `json:"type"`        // Type can be "date", "enterHour", "exitHour", "startBreakHour", "endBreakHour" or "deleteBreak"
+			Time       string `json:"time"`        // Assuming time comes in as a string like "15:04"
+			BreakIndex int    `json:"break_index"` // Position of the break for break types
+			BreakType  string `json:"break_type"`  // Optional "paid" or "unpaid" for break types
+			Reason     string `json:"reason"`
 		}
 
 		if err := ctx.ShouldBindJSON(&payload); err != nil {
@@ -473,6 +385,10 @@ func UpdateWorkDay(router *gin.RouterGroup) {
 			return
 		}
 
+		if !checkReason(ctx, payload.Reason) {
+			return
+		}
+
 		workerId, err := query.GetWorkerIDFromCode(payload.WorkerCode)
 		if err != nil {
 			log.Errorf("Error getting worker ID from code: %v", err)
@@ -488,9 +404,9 @@ func UpdateWorkDay(router *gin.RouterGroup) {
 			return
 		}
 
-		timeParsed, err := time.Parse("2006-01-02T15:04:05.000Z", payload.Time)
+		timeParsed, err := clock.ParseTime(payload.Time, config.Location())
 		if err != nil {
-			timeParsed, err = time.Parse("2006-01-02", payload.Time)
+			timeParsed, err = time.ParseInLocation("2006-01-02", payload.Time, config.Location())
 			if err != nil {
 				log.Errorf("Error parsing time date: %v", err)
 				ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid time format"})
@@ -498,53 +414,113 @@ func UpdateWorkDay(router *gin.RouterGroup) {
 			}
 		}
 
-		// Find or initialize the work schedule for the worker on the given date
-		var workSchedule entity.WorkSchedule
-		result := db.Db().FirstOrCreate(&workSchedule, entity.WorkSchedule{
-			WorkerID: workerId,
-			Date:     date,
-		})
+		// Update the relevant time with a correction punch
+		punch := correctionPunch(ctx)
+		punch.Type = payload.Type
+		punch.Time = timeParsed
+		punch.BreakIndex = payload.BreakIndex
+		punch.BreakType = payload.BreakType
 
-		log.Printf("test6")
-		log.Printf("Worker ID: %v", result.Error)
-		if result.Error != nil {
-			log.Printf("Error finding or creating work schedule: %v", result.Error)
-			log.Errorf("Error finding or creating work schedule: %v", result.Error)
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Could not find or create work schedule"})
+		if payload.BreakIndex < 0 || (payload.BreakType != "" && !entity.ValidBreakType(payload.BreakType)) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid break"})
 			return
 		}
 
-		// Update the relevant time based on the type of payload
-		log.Printf("Type: %v", payload.Type)
+		var workSchedule *entity.WorkSchedule
+
 		switch payload.Type {
 		case "date":
-			workSchedule.Date = timeParsed
-		case "enterHour":
-			workSchedule.EntryHour = timeParsed
-		case "startBreakfastHour":
-			workSchedule.BreakfastStartHour = timeParsed
-		case "endBreakfastHour":
-			workSchedule.BreakfastEndHour = timeParsed
-		case "startLunchHour":
-			workSchedule.LunchStartHour = timeParsed
-		case "endLunchHour":
-			workSchedule.LunchEndHour = timeParsed
-		case "exitHour":
-			workSchedule.ExitHour = timeParsed
+			to := clock.Date(timeParsed, config.Location())
+			dates := []time.Time{date}
+			if !to.Equal(date) {
+				dates = append(dates, to)
+			}
+			err = auditWorkDays(ctx, payload.Reason, workerId, dates, func(tx *gorm.DB) (err error) {
+				workSchedule, err = clock.TxMove(tx, workerId, date, to, punch, config.Location())
+				return err
+			})
+		case entity.PunchSetEntry, entity.PunchSetExit,
+			entity.PunchSetBreakStart, entity.PunchSetBreakEnd, entity.PunchDeleteBreak,
+			entity.PunchSetBreakfastStart, entity.PunchSetBreakfastEnd,
+			entity.PunchSetLunchStart, entity.PunchSetLunchEnd:
+			err = auditWorkDays(ctx, payload.Reason, workerId, []time.Time{date}, func(tx *gorm.DB) (err error) {
+				workSchedule, err = clock.TxCorrect(tx, workerId, date, entity.Punches{punch})
+				return err
+			})
 		default:
 			log.Errorf("Invalid type: %v", payload.Type)
 			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid type"})
 			return
 		}
 
-		// Save the updated work schedule
-		log.Printf("workSchedule date: %v", workSchedule.Date)
-		if err := db.Db().Save(&workSchedule).Error; err != nil {
+		if errors.Is(err, clock.ErrInvalidBreakIndex) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid break"})
+			return
+		} else if err != nil {
 			log.Errorf("Error updating work schedule: %v", err)
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Could not update work schedule"})
 			return
 		}
 
-		ctx.JSON(http.StatusOK, gin.H{"message": "Work schedule updated successfully", "work_schedule": workSchedule})
+		ctx.JSON(http.StatusOK, gin.H{"message": "Work schedule updated successfully", "work_schedule": workSchedule.In(config.Location())})
 	})
 }
+
+// checkPin verifies the PIN of a worker that has one and records failed
+// attempts. It returns the status code and message of a failed verification,
+// or zero if the punch may be recorded.
+func checkPin(ctx *gin.Context, worker *entity.Worker, pin string, deviceId *uint) (int, string) {
+	if !worker.HasPin() {
+		return 0, ""
+	}
+
+	err := worker.VerifyPin(pin, config.Env().PinMaxAttempts, config.Env().PinLockDuration)
+	if err == nil {
+		return 0, ""
+	}
+
+	attempt := entity.PinAttempt{
+		WorkerID: worker.ID,
+		DeviceID: deviceId,
+		ClientIP: ctx.ClientIP(),
+		Locked:   worker.PinLocked(time.Now()),
+	}
+	if err := attempt.Create(); err != nil {
+		log.Errorf("Error recording pin attempt: %v", err)
+	}
+
+	switch err {
+	case entity.ErrPinLocked:
+		return http.StatusLocked, "PIN BLOQUEADO POR DEMASIADOS INTENTOS, INTÉNTELO MÁS TARDE"
+	case entity.ErrPinWrong:
+		return http.StatusUnauthorized, "PIN INCORRECTO"
+	default:
+		log.Errorf("Error verifying pin: %v", err)
+		return http.StatusInternalServerError, "Could not verify pin"
+	}
+}
+
+// punchStatus returns the response status of a recorded punch. Rejected
+// punches are client errors, so that their responses are replayed to
+// retries like those of applied punches.
+func punchStatus(punch *entity.Punch, result clock.Result) int {
+	switch {
+	case punch.Review == entity.PunchReviewOutsideGeofence:
+		return http.StatusForbidden
+	case result.Rejected:
+		return http.StatusConflict
+	default:
+		return http.StatusOK
+	}
+}
+
+// correctionPunch returns a manual punch entered by the current user.
+func correctionPunch(ctx *gin.Context) entity.Punch {
+	punch := entity.Punch{Source: entity.PunchSourceManual}
+
+	if p := authPayload(ctx); p != nil && p.UserID != 0 {
+		punch.UserID = &p.UserID
+	}
+
+	return punch
+}
@@ -0,0 +1,32 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/alexanderbkl/vidre-back/internal/clock"
+	"github.com/alexanderbkl/vidre-back/internal/entity"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPunchStatus(t *testing.T) {
+	testCases := []struct {
+		name     string
+		punch    entity.Punch
+		result   clock.Result
+		expected int
+	}{
+		{name: "Applied", expected: http.StatusOK},
+		{name: "AssumedTimes", result: clock.Result{Message: "NO HA INDICADO EL INICIO DEL DESCANSO"}, expected: http.StatusOK},
+		{name: "Rejected", result: clock.Result{Message: "NO SE PUEDE REGISTRAR LA ENTRADA DOS VECES", Rejected: true}, expected: http.StatusConflict},
+		{name: "OutsideGeofence", punch: entity.Punch{Review: entity.PunchReviewOutsideGeofence}, result: clock.Result{Rejected: true}, expected: http.StatusForbidden},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, punchStatus(&tc.punch, tc.result))
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/alexanderbkl/vidre-back/internal/config"
+	"github.com/alexanderbkl/vidre-back/internal/db"
+	"github.com/alexanderbkl/vidre-back/internal/entity"
+	"github.com/alexanderbkl/vidre-back/internal/form"
+	"github.com/alexanderbkl/vidre-back/internal/query"
+	"github.com/alexanderbkl/vidre-back/internal/worktime"
+	"github.com/gin-gonic/gin"
+)
+
+// GetWorkDaysSummary returns the gross, break and net worked time of a worker
+// per day and in total over a date frame. Days without entry or exit are
+// listed as incomplete without worked time.
+//
+// GET /api/worker/work_days/summary?worker_code=&start_date=&end_date=
+func GetWorkDaysSummary(router *gin.RouterGroup) {
+	router.GET("/worker/work_days/summary", func(ctx *gin.Context) {
+		var payload struct {
+			WorkerCode string `form:"worker_code" binding:"required"`
+			StartDate  string `form:"start_date"  binding:"required"`
+			EndDate    string `form:"end_date"    binding:"required"`
+		}
+
+		if err := ctx.ShouldBindQuery(&payload); err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		worker, err := query.GetWorkerFromCode(payload.WorkerCode)
+		if err != nil {
+			AbortEntityNotFound(ctx)
+			return
+		}
+
+		startDate, err := time.Parse("2006-01-02", payload.StartDate)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start date format"})
+			return
+		}
+
+		endDate, err := time.Parse("2006-01-02", payload.EndDate)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end date format"})
+			return
+		}
+
+		schedules, err := entity.FindWorkSchedules(db.Db(), worker.ID, startDate, endDate)
+		if err != nil {
+			log.Errorf("cannot find work schedules: %s", err)
+			ctx.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			return
+		}
+
+		summary := worktime.Summarize(schedules)
+
+		response := form.WorkDaysSummaryResponse{
+			WorkerCode:     worker.Code,
+			StartDate:      payload.StartDate,
+			EndDate:        payload.EndDate,
+			Days:           make([]form.WorkDaySummary, 0, len(summary.Days)),
+			Total:          workTimes(summary.Total),
+			IncompleteDays: summary.Incomplete,
+		}
+
+		for _, day := range summary.Days {
+			response.Days = append(response.Days, form.WorkDaySummary{
+				Date:      day.Date.Format("2006-01-02"),
+				Complete:  day.Complete,
+				WorkTimes: workTimes(day.Times),
+			})
+		}
+
+		ctx.JSON(http.StatusOK, response)
+	})
+}
+
+// GetOvertime returns the regular time and overtime of a worker per day, per
+// month and in total over a date frame. Overtime is the time worked within
+// the extra hour windows of the worker, on weekends and on festivos.
+//
+// GET /api/worker/overtime?worker_code=&start_date=&end_date=
+func GetOvertime(router *gin.RouterGroup) {
+	router.GET("/worker/overtime", func(ctx *gin.Context) {
+		var payload struct {
+			WorkerCode string `form:"worker_code" binding:"required"`
+			StartDate  string `form:"start_date"  binding:"required"`
+			EndDate    string `form:"end_date"    binding:"required"`
+		}
+
+		if err := ctx.ShouldBindQuery(&payload); err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		worker, err := query.GetWorkerFromCode(payload.WorkerCode)
+		if err != nil {
+			AbortEntityNotFound(ctx)
+			return
+		}
+
+		startDate, err := time.Parse("2006-01-02", payload.StartDate)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start date format"})
+			return
+		}
+
+		endDate, err := time.Parse("2006-01-02", payload.EndDate)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end date format"})
+			return
+		}
+
+		schedules, err := entity.FindWorkSchedules(db.Db(), worker.ID, startDate, endDate)
+		if err != nil {
+			log.Errorf("cannot find work schedules: %s", err)
+			ctx.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			return
+		}
+
+		calendar, err := overtimeCalendar(worker.ID)
+		if err != nil {
+			log.Errorf("cannot load overtime calendar: %s", err)
+			ctx.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			return
+		}
+
+		summary := calendar.Summarize(schedules)
+
+		response := form.OvertimeResponse{
+			WorkerCode: worker.Code,
+			StartDate:  payload.StartDate,
+			EndDate:    payload.EndDate,
+			Days:       make([]form.OvertimeDay, 0, len(summary.Days)),
+			Months:     make([]form.OvertimeMonth, 0, len(summary.Months)),
+			Total:      overtimeTimes(summary.Total),
+		}
+
+		for _, day := range summary.Days {
+			response.Days = append(response.Days, form.OvertimeDay{
+				Date:          day.Date.Format("2006-01-02"),
+				OvertimeTimes: overtimeTimes(day.Overtime),
+			})
+		}
+
+		for _, month := range summary.Months {
+			response.Months = append(response.Months, form.OvertimeMonth{
+				Month:         month.Month,
+				OvertimeTimes: overtimeTimes(month.Overtime),
+			})
+		}
+
+		ctx.JSON(http.StatusOK, response)
+	})
+}
+
+// overtimeCalendar returns the calendar with the extra hour windows of the
+// worker and all festivos. Invalid extra hour settings are skipped.
+func overtimeCalendar(workerID uint) (*worktime.Calendar, error) {
+	var extraHours entity.ExtraHours
+	if err := db.Db().Where("worker_id = ?", workerID).Find(&extraHours).Error; err != nil {
+		return nil, err
+	}
+
+	var festivos entity.Festivos
+	if err := db.Db().Find(&festivos).Error; err != nil {
+		return nil, err
+	}
+
+	windows := make([]worktime.Window, 0, len(extraHours))
+
+	for _, extraHour := range extraHours {
+		w, err := worktime.ParseWindow(extraHour)
+		if err != nil {
+			log.Warnf("overtime: %s (extra hour %d)", err, extraHour.ID)
+			continue
+		}
+
+		windows = append(windows, w)
+	}
+
+	return worktime.NewCalendar(config.Location(), festivos, windows), nil
+}
+
+// overtimeTimes returns the regular time and overtime in seconds.
+func overtimeTimes(o worktime.Overtime) form.OvertimeTimes {
+	return form.OvertimeTimes{
+		Regular:  int64(o.Regular / time.Second),
+		Overtime: int64(o.Total() / time.Second),
+		Window:   int64(o.Window / time.Second),
+		Weekend:  int64(o.Weekend / time.Second),
+		Festivo:  int64(o.Festivo / time.Second),
+	}
+}
+
+// workTimes returns the worked times in seconds.
+func workTimes(t worktime.Times) form.WorkTimes {
+	return form.WorkTimes{
+		Gross:        int64(t.Gross / time.Second),
+		Breaks:       int64(t.Breaks / time.Second),
+		PaidBreaks:   int64(t.PaidBreaks / time.Second),
+		UnpaidBreaks: int64(t.UnpaidBreaks / time.Second),
+		Net:          int64(t.Net / time.Second),
+	}
+}
@@ -3,10 +3,13 @@ package api
 import (
 	"net/http"
 
+	"github.com/alexanderbkl/vidre-back/internal/audit"
 	"github.com/alexanderbkl/vidre-back/internal/db"
 	"github.com/alexanderbkl/vidre-back/internal/entity"
 	"github.com/alexanderbkl/vidre-back/internal/form"
+	"github.com/alexanderbkl/vidre-back/internal/query"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // CreateWorker creates a worker.
@@ -15,9 +18,9 @@ import (
 // - JSON body:
 //   - name: string
 //   - code: string
+//   - reason: string
 func CreateWorker(router *gin.RouterGroup) {
 	router.POST("/worker/create", func(ctx *gin.Context) {
-		tx := db.Db().Begin()
 		var req form.CreateWorkerRequest
 
 		if err := ctx.ShouldBindJSON(&req); err != nil {
@@ -26,11 +29,17 @@ func CreateWorker(router *gin.RouterGroup) {
 			return
 		}
 
+		if !checkReason(ctx, req.Reason) {
+			return
+		}
+
 		worker := entity.Worker{
 			Name: req.Name,
 			Code: req.Code,
 		}
 
+		tx := db.Db().Begin()
+
 		if err := worker.TxCreate(tx); err != nil {
 			log.Errorf("cannot create worker: %s", err)
 			tx.Rollback()
@@ -38,6 +47,13 @@ func CreateWorker(router *gin.RouterGroup) {
 			return
 		}
 
+		if err := audit.Record(tx, auditActor(ctx), req.Reason, entity.AuditWorker, worker.ID, nil, &worker); err != nil {
+			log.Errorf("cannot audit worker: %s", err)
+			tx.Rollback()
+			ctx.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			return
+		}
+
 		tx.Commit()
 
 		ctx.JSON(http.StatusOK, gin.H{
@@ -53,6 +69,7 @@ func CreateWorker(router *gin.RouterGroup) {
 // - JSON body:
 //   - code: string
 //   - name: string
+//   - reason: string
 func ModifyWorker(router *gin.RouterGroup) {
 	router.POST("/worker/update", func(ctx *gin.Context) {
 		var req form.ModifyWorkerRequest
@@ -64,6 +81,10 @@ func ModifyWorker(router *gin.RouterGroup) {
 			return
 		}
 
+		if !checkReason(ctx, req.Reason) {
+			return
+		}
+
 		var worker entity.Worker
 		if err := db.Db().Model(&worker).Where("code = ?", req.Code).First(&worker).Error; err != nil {
 			log.Errorf("worker not founad: %s", err)
@@ -71,8 +92,15 @@ func ModifyWorker(router *gin.RouterGroup) {
 			return
 		}
 
+		before := worker
 		worker.Name = req.Name
-		if err := worker.Save(); err != nil {
+		if err := db.Db().Transaction(func(tx *gorm.DB) error {
+			if err := worker.TxSave(tx); err != nil {
+				return err
+			}
+
+			return audit.Record(tx, auditActor(ctx), req.Reason, entity.AuditWorker, worker.ID, &before, &worker)
+		}); err != nil {
 			log.Errorf("cannot save worker: %s", err)
 			ctx.JSON(http.StatusInternalServerError, ErrorResponse(err))
 			return
@@ -88,7 +116,7 @@ func ModifyWorker(router *gin.RouterGroup) {
 }
 
 // DeleteWorker deletes a worker by code.
-// DELETE /api/worker/delete
+// DELETE /api/worker/delete?code=&reason=
 func DeleteWorker(router *gin.RouterGroup) {
 	router.DELETE("/worker/delete", func(ctx *gin.Context) {
 		code := ctx.Query("code") // assuming code is passed as a query parameter
@@ -98,8 +126,25 @@ func DeleteWorker(router *gin.RouterGroup) {
 			return
 		}
 
+		reason := ctx.Query("reason")
+		if !checkReason(ctx, reason) {
+			return
+		}
+
+		worker, err := query.GetWorkerFromCode(code)
+		if err != nil {
+			AbortEntityNotFound(ctx)
+			return
+		}
+
 		// Delete the worker with the provided code
-		if err := db.Db().Where("code = ?", code).Delete(&entity.Worker{}).Error; err != nil {
+		if err := db.Db().Transaction(func(tx *gorm.DB) error {
+			if err := tx.Delete(worker).Error; err != nil {
+				return err
+			}
+
+			return audit.Record(tx, auditActor(ctx), reason, entity.AuditWorker, worker.ID, worker, nil)
+		}); err != nil {
 			log.Errorf("cannot delete worker: %s", err)
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete worker"})
 			return
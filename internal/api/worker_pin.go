@@ -0,0 +1,146 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/alexanderbkl/vidre-back/internal/audit"
+	"github.com/alexanderbkl/vidre-back/internal/db"
+	"github.com/alexanderbkl/vidre-back/internal/entity"
+	"github.com/alexanderbkl/vidre-back/internal/form"
+	"github.com/alexanderbkl/vidre-back/internal/query"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// SetWorkerPin sets or replaces the punch PIN of a worker and unlocks it.
+//
+// PUT /api/worker/pin
+// - JSON body:
+//   - code: string
+//   - pin: string
+//   - reason: string
+func SetWorkerPin(router *gin.RouterGroup) {
+	router.PUT("/worker/pin", func(ctx *gin.Context) {
+		var req form.SetWorkerPinRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		if !checkReason(ctx, req.Reason) {
+			return
+		}
+
+		worker, err := query.GetWorkerFromCode(req.Code)
+		if err != nil {
+			AbortEntityNotFound(ctx)
+			return
+		}
+
+		before := pinAudit(*worker, "")
+
+		if err := worker.SetPin(req.Pin); err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		after := pinAudit(*worker, pinSet)
+		if before.Pin == pinSet {
+			after.Pin = pinReplaced
+		}
+
+		if err := saveWorkerPin(ctx, req.Reason, before, after); err != nil {
+			log.Errorf("cannot save worker: %s", err)
+			AbortSaveFailed(ctx)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"message": "PIN updated"})
+	})
+}
+
+// ResetWorkerPin removes the punch PIN of a worker and unlocks it.
+//
+// DELETE /api/worker/pin?code=&reason=
+func ResetWorkerPin(router *gin.RouterGroup) {
+	router.DELETE("/worker/pin", func(ctx *gin.Context) {
+		reason := ctx.Query("reason")
+		if !checkReason(ctx, reason) {
+			return
+		}
+
+		worker, err := query.GetWorkerFromCode(ctx.Query("code"))
+		if err != nil {
+			AbortEntityNotFound(ctx)
+			return
+		}
+
+		before := pinAudit(*worker, "")
+
+		worker.ResetPin()
+
+		if err := saveWorkerPin(ctx, reason, before, pinAudit(*worker, "")); err != nil {
+			log.Errorf("cannot save worker: %s", err)
+			AbortSaveFailed(ctx)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"message": "PIN removed"})
+	})
+}
+
+// GetPinAttempts returns the failed PIN attempts of a worker, newest first.
+//
+// GET /api/worker/pin/attempts?code=
+func GetPinAttempts(router *gin.RouterGroup) {
+	router.GET("/worker/pin/attempts", func(ctx *gin.Context) {
+		workerId, err := query.GetWorkerIDFromCode(ctx.Query("code"))
+		if err != nil || workerId == 0 {
+			AbortEntityNotFound(ctx)
+			return
+		}
+
+		var attempts entity.PinAttempts
+		if err := db.Db().Where("worker_id = ?", workerId).Order("created_at DESC").Limit(500).Find(&attempts).Error; err != nil {
+			log.Errorf("cannot find pin attempts: %s", err)
+			ctx.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusOK, attempts)
+	})
+}
+
+// States of the PIN in the audit log, which never contains the PIN itself.
+const (
+	pinSet      = "set"
+	pinReplaced = "replaced"
+)
+
+// workerPinAudit is a worker with the state of its PIN in the audit log.
+type workerPinAudit struct {
+	entity.Worker
+	Pin string `json:"pin,omitempty"`
+}
+
+// pinAudit returns the worker with the state of its PIN. The state is
+// pinSet if the worker has a PIN and no state is given.
+func pinAudit(worker entity.Worker, state string) workerPinAudit {
+	if state == "" && worker.HasPin() {
+		state = pinSet
+	}
+
+	return workerPinAudit{Worker: worker, Pin: state}
+}
+
+// saveWorkerPin saves the changed PIN of the worker and records the change
+// in the audit log.
+func saveWorkerPin(ctx *gin.Context, reason string, before, after workerPinAudit) error {
+	return db.Db().Transaction(func(tx *gorm.DB) error {
+		if err := after.Worker.TxSave(tx); err != nil {
+			return err
+		}
+
+		return audit.Record(tx, auditActor(ctx), reason, entity.AuditWorker, after.ID, &before, &after)
+	})
+}
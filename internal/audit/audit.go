@@ -0,0 +1,210 @@
+/*
+Package audit records who changed workers, work days, extra hours and
+festivos, when and why, with the values of the changed fields before and
+after the change.
+
+Changes are computed from the JSON representation of the entities, so that
+fields are named as in the API. Ids, timestamps of the rows and associations
+that are audited on their own are left out. Timestamps are compared in UTC.
+
+Work days that change because of clock punches are not audited here, as
+their punches already record who changed them and when.
+*/
+package audit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+	"unicode/utf8"
+
+	"github.com/alexanderbkl/vidre-back/internal/entity"
+	"gorm.io/gorm"
+)
+
+// MaxReasonLength is the maximum number of characters of a reason.
+const MaxReasonLength = 500
+
+var (
+	ErrReasonRequired = errors.New("a reason is required")
+	ErrReasonTooLong  = errors.New("reason is too long")
+)
+
+// ignored are the fields that are left out of the changes.
+var ignored = map[string]bool{
+	"id":               true,
+	"created_at":       true,
+	"updated_at":       true,
+	"deleted_at":       true,
+	"work_schedule_id": true,
+	"worker":           true,
+}
+
+// Actor is the user, device or api key that makes a change.
+type Actor struct {
+	UserID   *uint
+	UserName string
+	DeviceID *uint
+	ApiKeyID *uint
+}
+
+// ValidateReason checks that a reason is given and not too long.
+func ValidateReason(reason string) error {
+	if reason == "" {
+		return ErrReasonRequired
+	} else if utf8.RuneCountInString(reason) > MaxReasonLength {
+		return ErrReasonTooLong
+	}
+
+	return nil
+}
+
+// Record stores the audit log of a change of an entity within the
+// transaction. Before is nil for created entities and after for deleted
+// ones. Nothing is stored if no field has changed.
+func Record(tx *gorm.DB, actor Actor, reason, name string, id interface{}, before, after interface{}) error {
+	if err := ValidateReason(reason); err != nil {
+		return err
+	}
+
+	changes, err := Diff(before, after)
+	if err != nil {
+		return err
+	} else if len(changes) == 0 {
+		return nil
+	}
+
+	action := entity.AuditUpdate
+	if isNil(before) {
+		action = entity.AuditCreate
+	} else if isNil(after) {
+		action = entity.AuditDelete
+	}
+
+	return tx.Create(&entity.AuditLog{
+		Entity:   name,
+		EntityID: fmt.Sprint(id),
+		Action:   action,
+		Changes:  changes,
+		Reason:   reason,
+		UserID:   actor.UserID,
+		UserName: actor.UserName,
+		DeviceID: actor.DeviceID,
+		ApiKeyID: actor.ApiKeyID,
+	}).Error
+}
+
+// WorkDay stores the audit log of a change of a work day. Before and after
+// are nil if the work day did not exist before or after the change.
+func WorkDay(tx *gorm.DB, actor Actor, reason string, before, after *entity.WorkSchedule) error {
+	if before == nil && after == nil {
+		return ValidateReason(reason)
+	}
+
+	day := after
+	if day == nil {
+		day = before
+	}
+
+	return Record(tx, actor, reason, entity.AuditWorkSchedule, day.ID, before, after)
+}
+
+// Diff returns the fields that differ between two values of an entity,
+// sorted by name. Before is nil for created entities and after for deleted
+// ones.
+func Diff(before, after interface{}) (entity.AuditChanges, error) {
+	b, err := values(before)
+	if err != nil {
+		return nil, err
+	}
+
+	a, err := values(after)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make([]string, 0, len(a))
+	for field := range a {
+		fields = append(fields, field)
+	}
+	for field := range b {
+		if _, ok := a[field]; !ok {
+			fields = append(fields, field)
+		}
+	}
+
+	sort.Strings(fields)
+
+	var changes entity.AuditChanges
+	for _, field := range fields {
+		if !reflect.DeepEqual(b[field], a[field]) {
+			changes = append(changes, entity.AuditChange{Field: field, Before: b[field], After: a[field]})
+		}
+	}
+
+	return changes, nil
+}
+
+// values returns the fields of an entity as they are serialized to JSON.
+func values(v interface{}) (map[string]interface{}, error) {
+	if isNil(v) {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	return normalize(fields).(map[string]interface{}), nil
+}
+
+// normalize removes ignored fields from objects and converts timestamps to
+// UTC, so that equal values compare equal. Empty lists and zero timestamps
+// are treated as missing values.
+func normalize(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if ignored[key] {
+				delete(v, key)
+			} else {
+				v[key] = normalize(value)
+			}
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			return nil
+		}
+		for i := range v {
+			v[i] = normalize(v[i])
+		}
+	case string:
+		if t, err := time.Parse(time.RFC3339Nano, v); err == nil && t.IsZero() {
+			return nil
+		} else if err == nil {
+			return t.UTC().Format(time.RFC3339Nano)
+		}
+	}
+
+	return v
+}
+
+// isNil tests if v is nil or a nil pointer.
+func isNil(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+
+	value := reflect.ValueOf(v)
+
+	return value.Kind() == reflect.Ptr && value.IsNil()
+}
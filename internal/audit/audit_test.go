@@ -0,0 +1,128 @@
+package audit
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexanderbkl/vidre-back/internal/entity"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	madrid, err := time.LoadLocation("Europe/Madrid")
+	require.NoError(t, err)
+
+	at := func(hour, min int) time.Time {
+		return time.Date(2024, 3, 4, hour, min, 0, 0, time.UTC)
+	}
+
+	day := entity.WorkSchedule{
+		ID:        7,
+		WorkerID:  3,
+		Date:      time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC),
+		EntryHour: at(8, 0),
+		ExitHour:  at(17, 0),
+		Breaks: entity.WorkBreaks{
+			{ID: 1, WorkScheduleID: 7, Type: entity.BreakUnpaid, StartHour: at(10, 0), EndHour: at(10, 15)},
+		},
+	}
+
+	testCases := []struct {
+		name     string
+		before   interface{}
+		after    interface{}
+		expected entity.AuditChanges
+	}{
+		{
+			name:  "CreateWorker",
+			after: &entity.Worker{ID: 1, Name: "Ana", Code: "A1", CreatedAt: time.Now()},
+			expected: entity.AuditChanges{
+				{Field: "code", After: "A1"},
+				{Field: "failed_pin_attempts", After: float64(0)},
+				{Field: "name", After: "Ana"},
+			},
+		},
+		{
+			name:     "RenameWorker",
+			before:   &entity.Worker{ID: 1, Name: "Ana", Code: "A1"},
+			after:    &entity.Worker{ID: 1, Name: "Ana María", Code: "A1", UpdatedAt: time.Now()},
+			expected: entity.AuditChanges{{Field: "name", Before: "Ana", After: "Ana María"}},
+		},
+		{
+			name:     "DeleteFestivo",
+			before:   &entity.Festivo{Date: "2024-01-01"},
+			after:    (*entity.Festivo)(nil),
+			expected: entity.AuditChanges{{Field: "date", Before: "2024-01-01"}},
+		},
+		{
+			name:   "ExtraHourWithoutWorker",
+			before: &entity.ExtraHour{WorkerID: 3, DayType: "weekday", StartHour: "18:00", EndHour: "20:00"},
+			after: &entity.ExtraHour{WorkerID: 3, DayType: "weekday", StartHour: "18:00", EndHour: "21:00",
+				Worker: entity.Worker{ID: 3, Name: "Ana", Code: "A1"}},
+			expected: entity.AuditChanges{{Field: "end_hour", Before: "20:00", After: "21:00"}},
+		},
+		{
+			name:   "SameTimesInOtherZone",
+			before: &day,
+			after: func() *entity.WorkSchedule {
+				d := day
+				d.EntryHour = d.EntryHour.In(madrid)
+				d.ExitHour = d.ExitHour.In(madrid)
+				d.Breaks = entity.WorkBreaks{{ID: 9, WorkScheduleID: 7, Type: entity.BreakUnpaid, StartHour: at(10, 0).In(madrid), EndHour: at(10, 15)}}
+				return &d
+			}(),
+		},
+		{
+			name:   "CorrectExit",
+			before: &day,
+			after: func() *entity.WorkSchedule {
+				d := day
+				d.ExitHour = at(17, 2)
+				return &d
+			}(),
+			expected: entity.AuditChanges{{Field: "exit_hour", Before: "2024-03-04T17:00:00Z", After: "2024-03-04T17:02:00Z"}},
+		},
+		{
+			name:   "DeleteBreaks",
+			before: &day,
+			after: func() *entity.WorkSchedule {
+				d := day
+				d.Breaks = entity.WorkBreaks{}
+				return &d
+			}(),
+			expected: entity.AuditChanges{{Field: "breaks", Before: []interface{}{map[string]interface{}{
+				"position":   float64(0),
+				"type":       entity.BreakUnpaid,
+				"start_hour": "2024-03-04T10:00:00Z",
+				"end_hour":   "2024-03-04T10:15:00Z",
+			}}}},
+		},
+		{
+			name:   "OpenDay",
+			before: (*entity.WorkSchedule)(nil),
+			after:  &entity.WorkSchedule{WorkerID: 3, Date: day.Date, EntryHour: at(8, 0)},
+			expected: entity.AuditChanges{
+				{Field: "date", After: "2024-03-04T00:00:00Z"},
+				{Field: "entry_hour", After: "2024-03-04T08:00:00Z"},
+				{Field: "worker_id", After: float64(3)},
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			changes, err := Diff(tc.before, tc.after)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, changes)
+		})
+	}
+}
+
+func TestValidateReason(t *testing.T) {
+	require.NoError(t, ValidateReason("Olvidó marcar la salida"))
+	require.ErrorIs(t, ValidateReason(""), ErrReasonRequired)
+	require.ErrorIs(t, ValidateReason(strings.Repeat("ñ", MaxReasonLength+1)), ErrReasonTooLong)
+}
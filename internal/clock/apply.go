@@ -0,0 +1,129 @@
+/*
+Package clock turns the punches of a worker into work schedules.
+
+Punches are append-only. A work schedule is the projection of the punches
+recorded for its day, so it can always be recomputed from them.
+*/
+package clock
+
+import (
+	"errors"
+	"time"
+
+	"github.com/alexanderbkl/vidre-back/internal/entity"
+)
+
+var (
+	ErrInvalidType       = errors.New("invalid punch type")
+	ErrInvalidBreakType  = errors.New("invalid break type")
+	ErrInvalidBreakIndex = errors.New("break index is out of range")
+)
+
+// Result describes how a punch was applied to a work day.
+type Result struct {
+	// Message is shown to the worker if the punch was rejected or times had
+	// to be assumed. It is empty if the punch was recorded as is.
+	Message string
+	// Rejected is true if the punch left the work day unchanged.
+	Rejected bool
+}
+
+// Apply records a clock punch on the work day. The previous day is only used
+// to warn about a missing exit and may be nil.
+func Apply(day, previous *entity.WorkSchedule, punch entity.Punch) (Result, error) {
+	switch punch.Type {
+	case entity.PunchEntry:
+		return applyEntry(day, previous, punch.Time), nil
+	case entity.PunchStartRest:
+		breakType := punch.BreakType
+		if breakType == "" {
+			breakType = entity.BreakUnpaid
+		} else if !entity.ValidBreakType(breakType) {
+			return Result{}, ErrInvalidBreakType
+		}
+		return applyStartRest(day, breakType, punch.Time), nil
+	case entity.PunchEndRest:
+		return applyEndRest(day, punch.Time), nil
+	case entity.PunchExit:
+		return applyExit(day, punch.Time), nil
+	default:
+		return Result{}, ErrInvalidType
+	}
+}
+
+func message(msg string) Result {
+	return Result{Message: msg}
+}
+
+func reject(msg string) Result {
+	return Result{Message: msg, Rejected: true}
+}
+
+func applyEntry(day, previous *entity.WorkSchedule, t time.Time) Result {
+	if day.EntryHour.IsZero() {
+		day.EntryHour = t
+		if previous != nil && previous.ExitHour.IsZero() && !previous.EntryHour.IsZero() {
+			// it means that the worker has not exited the previous day
+			return message("NO HA MARCADO SALIDA EN EL DIA DE AYER, SOLICITE LA CORRECCIÓN DE LA SALIDA")
+		}
+		return Result{}
+	} else if !day.ExitHour.IsZero() {
+		return reject("NO SE PUEDE REGISTRAR LA ENTRADA DESPUÉS DE LA SALIDA")
+	}
+
+	return reject("NO SE PUEDE REGISTRAR LA ENTRADA DOS VECES")
+}
+
+// applyStartRest opens a new break unless one is open already.
+func applyStartRest(day *entity.WorkSchedule, breakType string, t time.Time) Result {
+	switch {
+	case day.EntryHour.IsZero():
+		day.EntryHour = t
+		day.Breaks = append(day.Breaks, entity.WorkBreak{Type: breakType, StartHour: t})
+		return message("NO HA MARCADO ENTRADA, SOLICITE LA CORRECCIÓN DE LA ENTRADA")
+	case !day.ExitHour.IsZero():
+		return reject("NO SE PUEDE REGISTRAR EL INICIO DEL DESCANSO DESPUÉS DE LA SALIDA")
+	case day.OpenBreak() != nil:
+		return reject("NO SE PUEDE REGISTRAR EL INICIO DEL DESCANSO DOS VECES")
+	}
+
+	day.Breaks = append(day.Breaks, entity.WorkBreak{Type: breakType, StartHour: t})
+
+	return Result{}
+}
+
+// applyEndRest closes the open break. Without an open break, the break is
+// assumed to have started 30 minutes earlier.
+func applyEndRest(day *entity.WorkSchedule, t time.Time) Result {
+	assumed := t.Add(-30 * time.Minute)
+
+	switch {
+	case day.EntryHour.IsZero():
+		day.EntryHour = t
+		day.Breaks = append(day.Breaks, entity.WorkBreak{Type: entity.BreakUnpaid, StartHour: assumed, EndHour: t})
+		return message("NO HA MARCADO ENTRADA, SOLICITE LA CORRECCIÓN DE LA ENTRADA, SE INDICA INICIO DEL DESCANSO A LAS " + assumed.Format("15:04"))
+	case !day.ExitHour.IsZero():
+		return reject("NO SE PUEDE REGISTRAR EL FIN DEL DESCANSO DESPUÉS DE LA SALIDA")
+	}
+
+	if open := day.OpenBreak(); open != nil {
+		open.EndHour = t
+		return Result{}
+	}
+
+	day.Breaks = append(day.Breaks, entity.WorkBreak{Type: entity.BreakUnpaid, StartHour: assumed, EndHour: t})
+
+	return message("NO HA INDICADO EL INICIO DEL DESCANSO, SE INDICA EL INICIO A LAS " + assumed.Format("15:04"))
+}
+
+func applyExit(day *entity.WorkSchedule, t time.Time) Result {
+	if day.EntryHour.IsZero() {
+		return reject("NO SE PUEDE REGISTRAR LA SALIDA SIN REGISTRAR LA ENTRADA")
+	} else if !day.ExitHour.IsZero() {
+		return reject("NO SE PUEDE REGISTRAR LA SALIDA DOS VECES")
+	}
+
+	day.ExitHour = t
+
+	return Result{}
+}
@@ -0,0 +1,399 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alexanderbkl/vidre-back/internal/entity"
+	"github.com/stretchr/testify/require"
+)
+
+func at(hour, min int) time.Time {
+	return time.Date(2024, 3, 4, hour, min, 0, 0, time.UTC)
+}
+
+func punch(punchType string, t time.Time) entity.Punch {
+	return entity.Punch{Type: punchType, Time: t}
+}
+
+func unpaid(start, end time.Time) entity.WorkBreak {
+	return entity.WorkBreak{Type: entity.BreakUnpaid, StartHour: start, EndHour: end}
+}
+
+func TestApply(t *testing.T) {
+	testCases := []struct {
+		name     string
+		punches  entity.Punches
+		next     entity.Punch
+		expected entity.WorkSchedule
+		message  string
+		rejected bool
+	}{
+		{
+			name:     "Entry",
+			next:     punch(entity.PunchEntry, at(8, 0)),
+			expected: entity.WorkSchedule{EntryHour: at(8, 0)},
+		},
+		{
+			name:     "EntryTwice",
+			punches:  entity.Punches{punch(entity.PunchEntry, at(8, 0))},
+			next:     punch(entity.PunchEntry, at(8, 5)),
+			expected: entity.WorkSchedule{EntryHour: at(8, 0)},
+			message:  "NO SE PUEDE REGISTRAR LA ENTRADA DOS VECES",
+			rejected: true,
+		},
+		{
+			name:     "StartRest",
+			punches:  entity.Punches{punch(entity.PunchEntry, at(8, 0))},
+			next:     punch(entity.PunchStartRest, at(10, 0)),
+			expected: entity.WorkSchedule{EntryHour: at(8, 0), Breaks: entity.WorkBreaks{unpaid(at(10, 0), time.Time{})}},
+		},
+		{
+			name:    "PaidRest",
+			punches: entity.Punches{punch(entity.PunchEntry, at(8, 0))},
+			next:    entity.Punch{Type: entity.PunchStartRest, Time: at(10, 0), BreakType: entity.BreakPaid},
+			expected: entity.WorkSchedule{EntryHour: at(8, 0), Breaks: entity.WorkBreaks{
+				{Type: entity.BreakPaid, StartHour: at(10, 0)},
+			}},
+		},
+		{
+			name: "ThirdRest",
+			punches: entity.Punches{
+				punch(entity.PunchEntry, at(22, 0)),
+				punch(entity.PunchStartRest, at(0, 0)),
+				punch(entity.PunchEndRest, at(0, 15)),
+				punch(entity.PunchStartRest, at(2, 0)),
+				punch(entity.PunchEndRest, at(2, 15)),
+				punch(entity.PunchStartRest, at(4, 0)),
+			},
+			next: punch(entity.PunchEndRest, at(4, 15)),
+			expected: entity.WorkSchedule{EntryHour: at(22, 0), Breaks: entity.WorkBreaks{
+				unpaid(at(0, 0), at(0, 15)),
+				unpaid(at(2, 0), at(2, 15)),
+				unpaid(at(4, 0), at(4, 15)),
+			}},
+		},
+		{
+			name: "StartRestTwice",
+			punches: entity.Punches{
+				punch(entity.PunchEntry, at(8, 0)),
+				punch(entity.PunchStartRest, at(10, 0)),
+			},
+			next:     punch(entity.PunchStartRest, at(10, 5)),
+			expected: entity.WorkSchedule{EntryHour: at(8, 0), Breaks: entity.WorkBreaks{unpaid(at(10, 0), time.Time{})}},
+			message:  "NO SE PUEDE REGISTRAR EL INICIO DEL DESCANSO DOS VECES",
+			rejected: true,
+		},
+		{
+			name:     "StartRestWithoutEntry",
+			next:     punch(entity.PunchStartRest, at(10, 0)),
+			expected: entity.WorkSchedule{EntryHour: at(10, 0), Breaks: entity.WorkBreaks{unpaid(at(10, 0), time.Time{})}},
+			message:  "NO HA MARCADO ENTRADA, SOLICITE LA CORRECCIÓN DE LA ENTRADA",
+		},
+		{
+			name:     "EndRestWithoutStart",
+			punches:  entity.Punches{punch(entity.PunchEntry, at(8, 0))},
+			next:     punch(entity.PunchEndRest, at(10, 30)),
+			expected: entity.WorkSchedule{EntryHour: at(8, 0), Breaks: entity.WorkBreaks{unpaid(at(10, 0), at(10, 30))}},
+			message:  "NO HA INDICADO EL INICIO DEL DESCANSO, SE INDICA EL INICIO A LAS 10:00",
+		},
+		{
+			name:     "EndRestAfterExit",
+			punches:  entity.Punches{punch(entity.PunchEntry, at(8, 0)), punch(entity.PunchExit, at(17, 0))},
+			next:     punch(entity.PunchEndRest, at(17, 30)),
+			expected: entity.WorkSchedule{EntryHour: at(8, 0), ExitHour: at(17, 0)},
+			message:  "NO SE PUEDE REGISTRAR EL FIN DEL DESCANSO DESPUÉS DE LA SALIDA",
+			rejected: true,
+		},
+		{
+			name:     "ExitWithoutEntry",
+			next:     punch(entity.PunchExit, at(17, 0)),
+			expected: entity.WorkSchedule{},
+			message:  "NO SE PUEDE REGISTRAR LA SALIDA SIN REGISTRAR LA ENTRADA",
+			rejected: true,
+		},
+		{
+			name:     "Exit",
+			punches:  entity.Punches{punch(entity.PunchEntry, at(8, 0))},
+			next:     punch(entity.PunchExit, at(17, 0)),
+			expected: entity.WorkSchedule{EntryHour: at(8, 0), ExitHour: at(17, 0)},
+		},
+		{
+			name:     "EntryAfterExit",
+			punches:  entity.Punches{punch(entity.PunchEntry, at(8, 0)), punch(entity.PunchExit, at(17, 0))},
+			next:     punch(entity.PunchEntry, at(18, 0)),
+			expected: entity.WorkSchedule{EntryHour: at(8, 0), ExitHour: at(17, 0)},
+			message:  "NO SE PUEDE REGISTRAR LA ENTRADA DESPUÉS DE LA SALIDA",
+			rejected: true,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			var day entity.WorkSchedule
+			Project(&day, tc.punches)
+
+			result, err := Apply(&day, nil, tc.next)
+			require.NoError(t, err)
+			require.Equal(t, tc.message, result.Message)
+			require.Equal(t, tc.rejected, result.Rejected)
+			require.Equal(t, tc.expected, day)
+
+			// The projection of all punches matches the day after applying the punch.
+			var projected entity.WorkSchedule
+			Project(&projected, append(tc.punches, tc.next))
+			require.Equal(t, day, projected)
+		})
+	}
+}
+
+func TestApplyPreviousDayWithoutExit(t *testing.T) {
+	previous := entity.WorkSchedule{EntryHour: at(8, 0)}
+
+	var day entity.WorkSchedule
+	result, err := Apply(&day, &previous, punch(entity.PunchEntry, at(8, 0)))
+	require.NoError(t, err)
+	require.Equal(t, at(8, 0), day.EntryHour)
+	require.Equal(t, "NO HA MARCADO SALIDA EN EL DIA DE AYER, SOLICITE LA CORRECCIÓN DE LA SALIDA", result.Message)
+}
+
+func TestApplyInvalidType(t *testing.T) {
+	var day entity.WorkSchedule
+	_, err := Apply(&day, nil, punch("lunch", at(8, 0)))
+	require.ErrorIs(t, err, ErrInvalidType)
+
+	_, err = Apply(&day, nil, entity.Punch{Type: entity.PunchStartRest, Time: at(10, 0), BreakType: "lunch"})
+	require.ErrorIs(t, err, ErrInvalidBreakType)
+}
+
+func TestApplySynced(t *testing.T) {
+	testCases := []struct {
+		name     string
+		punches  entity.Punches
+		next     entity.Punch
+		expected entity.WorkSchedule
+		review   string
+	}{
+		{
+			name:     "InOrder",
+			punches:  entity.Punches{punch(entity.PunchEntry, at(8, 0))},
+			next:     punch(entity.PunchStartRest, at(10, 0)),
+			expected: entity.WorkSchedule{EntryHour: at(8, 0), Breaks: entity.WorkBreaks{unpaid(at(10, 0), time.Time{})}},
+		},
+		{
+			name:     "BeforeEntry",
+			punches:  entity.Punches{punch(entity.PunchEntry, at(8, 0))},
+			next:     punch(entity.PunchStartRest, at(7, 30)),
+			expected: entity.WorkSchedule{EntryHour: at(8, 0)},
+			review:   entity.PunchReviewOutOfOrder,
+		},
+		{
+			name: "BeforeBreak",
+			punches: entity.Punches{
+				punch(entity.PunchEntry, at(8, 0)),
+				punch(entity.PunchStartRest, at(10, 0)),
+				punch(entity.PunchEndRest, at(10, 30)),
+			},
+			next: punch(entity.PunchExit, at(10, 15)),
+			expected: entity.WorkSchedule{EntryHour: at(8, 0), Breaks: entity.WorkBreaks{
+				unpaid(at(10, 0), at(10, 30)),
+			}},
+			review: entity.PunchReviewOutOfOrder,
+		},
+		{
+			name:     "Conflict",
+			punches:  entity.Punches{punch(entity.PunchEntry, at(8, 0))},
+			next:     punch(entity.PunchEntry, at(8, 5)),
+			expected: entity.WorkSchedule{EntryHour: at(8, 0)},
+			review:   entity.PunchReviewConflict,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			var day entity.WorkSchedule
+			Project(&day, tc.punches)
+
+			_, review, err := ApplySynced(&day, nil, tc.next)
+			require.NoError(t, err)
+			require.Equal(t, tc.review, review)
+			require.Equal(t, tc.expected, day)
+
+			// Flagged punches are left out of the projection.
+			tc.next.Review = review
+			var projected entity.WorkSchedule
+			Project(&projected, append(tc.punches, tc.next))
+			require.Equal(t, day, projected)
+		})
+	}
+}
+
+func TestApplySyncedInvalidType(t *testing.T) {
+	var day entity.WorkSchedule
+	_, _, err := ApplySynced(&day, nil, punch("lunch", at(8, 0)))
+	require.ErrorIs(t, err, ErrInvalidType)
+
+	_, _, err = ApplySynced(&day, nil, entity.Punch{Type: entity.PunchStartRest, Time: at(10, 0), BreakType: "lunch"})
+	require.ErrorIs(t, err, ErrInvalidBreakType)
+}
+
+func TestProjectCorrections(t *testing.T) {
+	punches := entity.Punches{
+		punch(entity.PunchEntry, at(8, 0)),
+		punch(entity.PunchExit, at(15, 0)),
+		punch(entity.PunchSetExit, at(17, 0)),
+	}
+
+	var day entity.WorkSchedule
+	require.True(t, Project(&day, punches))
+	require.Equal(t, entity.WorkSchedule{EntryHour: at(8, 0), ExitHour: at(17, 0)}, day)
+
+	// Reset clears the day before the following punches.
+	punches = append(punches, punch(entity.PunchReset, at(18, 0)), punch(entity.PunchSetEntry, at(9, 0)))
+	require.True(t, Project(&day, punches))
+	require.Equal(t, entity.WorkSchedule{EntryHour: at(9, 0)}, day)
+
+	// Delete removes the day until a later punch.
+	punches = append(punches, punch(entity.PunchDelete, at(18, 0)))
+	require.False(t, Project(&day, punches))
+	require.Equal(t, entity.WorkSchedule{}, day)
+
+	punches = append(punches, punch(entity.PunchEntry, at(19, 0)))
+	require.True(t, Project(&day, punches))
+	require.Equal(t, entity.WorkSchedule{EntryHour: at(19, 0)}, day)
+}
+
+func TestProjectBreakCorrections(t *testing.T) {
+	punches := entity.Punches{
+		punch(entity.PunchEntry, at(8, 0)),
+		punch(entity.PunchStartRest, at(10, 0)),
+		punch(entity.PunchEndRest, at(10, 30)),
+		{Type: entity.PunchSetBreakStart, Time: at(14, 0), BreakIndex: 1, BreakType: entity.BreakPaid},
+		{Type: entity.PunchSetBreakEnd, Time: at(14, 45), BreakIndex: 1},
+		{Type: entity.PunchSetBreakEnd, Time: at(10, 20), BreakIndex: 0},
+	}
+
+	var day entity.WorkSchedule
+	require.True(t, Project(&day, punches))
+	require.Equal(t, entity.WorkBreaks{
+		unpaid(at(10, 0), at(10, 20)),
+		{Type: entity.BreakPaid, StartHour: at(14, 0), EndHour: at(14, 45)},
+	}, day.Breaks)
+
+	// The set punches of a day reproduce it.
+	var copied entity.WorkSchedule
+	require.True(t, Project(&copied, SetPunches(&day, entity.Punch{})))
+	require.Equal(t, day, copied)
+
+	punches = append(punches, entity.Punch{Type: entity.PunchDeleteBreak, BreakIndex: 0})
+	require.True(t, Project(&day, punches))
+	require.Equal(t, entity.WorkBreaks{{Type: entity.BreakPaid, StartHour: at(14, 0), EndHour: at(14, 45)}}, day.Breaks)
+}
+
+func TestProjectLegacyBreaks(t *testing.T) {
+	punches := entity.Punches{
+		punch(entity.PunchReset, at(18, 0)),
+		punch(entity.PunchSetEntry, at(8, 0)),
+		punch(entity.PunchSetBreakfastStart, at(10, 0)),
+		punch(entity.PunchSetBreakfastEnd, at(10, 30)),
+		punch(entity.PunchSetLunchStart, at(14, 0)),
+		punch(entity.PunchSetLunchEnd, at(15, 0)),
+		punch(entity.PunchSetExit, at(17, 0)),
+	}
+
+	var day entity.WorkSchedule
+	require.True(t, Project(&day, punches))
+	require.Equal(t, entity.WorkSchedule{
+		EntryHour: at(8, 0),
+		ExitHour:  at(17, 0),
+		Breaks:    entity.WorkBreaks{unpaid(at(10, 0), at(10, 30)), unpaid(at(14, 0), at(15, 0))},
+	}, day)
+
+	// A lunch without breakfast becomes the only break of the day.
+	require.True(t, Project(&day, entity.Punches{punch(entity.PunchSetLunchStart, at(14, 0))}))
+	require.Equal(t, entity.WorkBreaks{unpaid(at(14, 0), time.Time{})}, day.Breaks)
+}
+
+func TestContinuesShift(t *testing.T) {
+	date := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	night := entity.WorkSchedule{Date: date, EntryHour: at(22, 0)}
+	next := func(hour, min int) time.Time {
+		return at(hour, min).AddDate(0, 0, 1)
+	}
+
+	testCases := []struct {
+		name     string
+		day      entity.WorkSchedule
+		previous *entity.WorkSchedule
+		punch    entity.Punch
+		expected bool
+	}{
+		{name: "Exit", previous: &night, punch: punch(entity.PunchExit, next(6, 0)), expected: true},
+		{name: "Rest", previous: &night, punch: punch(entity.PunchStartRest, next(2, 0)), expected: true},
+		{name: "Entry", previous: &night, punch: punch(entity.PunchEntry, next(6, 0))},
+		{name: "TooLong", previous: &night, punch: punch(entity.PunchExit, next(15, 0))},
+		{name: "NoPrevious", punch: punch(entity.PunchExit, next(6, 0))},
+		{
+			name:     "PreviousClosed",
+			previous: &entity.WorkSchedule{Date: date, EntryHour: at(22, 0), ExitHour: at(23, 0)},
+			punch:    punch(entity.PunchExit, next(6, 0)),
+		},
+		{
+			name:     "DayStarted",
+			day:      entity.WorkSchedule{EntryHour: next(5, 0)},
+			previous: &night,
+			punch:    punch(entity.PunchExit, next(6, 0)),
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, ContinuesShift(&tc.day, tc.previous, tc.punch, 16*time.Hour))
+		})
+	}
+}
+
+func TestCheckBreakIndexes(t *testing.T) {
+	testCases := []struct {
+		name     string
+		breaks   int
+		punches  entity.Punches
+		expected error
+	}{
+		{name: "Existing", breaks: 2, punches: entity.Punches{{Type: entity.PunchSetBreakEnd, BreakIndex: 1}}},
+		{name: "Next", breaks: 2, punches: entity.Punches{{Type: entity.PunchSetBreakStart, BreakIndex: 2}}},
+		{name: "NextTwice", breaks: 0, punches: entity.Punches{{Type: entity.PunchSetBreakStart, BreakIndex: 0}, {Type: entity.PunchSetBreakStart, BreakIndex: 1}}},
+		{name: "Gap", breaks: 1, punches: entity.Punches{{Type: entity.PunchSetBreakStart, BreakIndex: 2}}, expected: ErrInvalidBreakIndex},
+		{name: "Huge", breaks: 1, punches: entity.Punches{{Type: entity.PunchSetBreakEnd, BreakIndex: 2_000_000_000}}, expected: ErrInvalidBreakIndex},
+		{name: "Negative", breaks: 1, punches: entity.Punches{{Type: entity.PunchSetBreakStart, BreakIndex: -1}}, expected: ErrInvalidBreakIndex},
+		{name: "Delete", breaks: 1, punches: entity.Punches{{Type: entity.PunchDeleteBreak, BreakIndex: 0}}},
+		{name: "DeleteMissing", breaks: 1, punches: entity.Punches{{Type: entity.PunchDeleteBreak, BreakIndex: 1}}, expected: ErrInvalidBreakIndex},
+		{name: "AfterReset", breaks: 3, punches: entity.Punches{{Type: entity.PunchReset}, {Type: entity.PunchSetBreakStart, BreakIndex: 1}}, expected: ErrInvalidBreakIndex},
+		{name: "OtherTypes", punches: entity.Punches{{Type: entity.PunchSetEntry, BreakIndex: 5}, {Type: entity.PunchSetLunchStart}}},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, CheckBreakIndexes(tc.breaks, tc.punches))
+		})
+	}
+}
+
+func TestProjectHugeBreakIndex(t *testing.T) {
+	punches := entity.Punches{
+		punch(entity.PunchEntry, at(8, 0)),
+		{Type: entity.PunchSetBreakStart, Time: at(14, 0), BreakIndex: 2_000_000_000},
+	}
+
+	var day entity.WorkSchedule
+	require.True(t, Project(&day, punches))
+	require.Equal(t, entity.WorkSchedule{EntryHour: at(8, 0)}, day)
+}
@@ -0,0 +1,135 @@
+package clock
+
+import (
+	"time"
+
+	"github.com/alexanderbkl/vidre-back/internal/entity"
+)
+
+// Project replays the punches of a work day in the order they were recorded
+// and sets the times of the day accordingly. Punches flagged for review are
+// left out. It returns false if the day has been deleted by the last
+// correction.
+func Project(day *entity.WorkSchedule, punches entity.Punches) bool {
+	resetDay(day)
+	exists := false
+
+	for _, punch := range punches {
+		if punch.Review != "" {
+			continue
+		}
+
+		exists = true
+
+		switch punch.Type {
+		case entity.PunchSetEntry:
+			day.EntryHour = punch.Time
+		case entity.PunchSetBreakStart:
+			setBreak(day, punch.BreakIndex, punch.BreakType).StartHour = punch.Time
+		case entity.PunchSetBreakEnd:
+			setBreak(day, punch.BreakIndex, punch.BreakType).EndHour = punch.Time
+		case entity.PunchDeleteBreak:
+			if punch.BreakIndex >= 0 && punch.BreakIndex < len(day.Breaks) {
+				day.Breaks = append(day.Breaks[:punch.BreakIndex], day.Breaks[punch.BreakIndex+1:]...)
+			}
+		case entity.PunchSetBreakfastStart:
+			setBreak(day, 0, "").StartHour = punch.Time
+		case entity.PunchSetBreakfastEnd:
+			setBreak(day, 0, "").EndHour = punch.Time
+		case entity.PunchSetLunchStart:
+			setBreak(day, 1, "").StartHour = punch.Time
+		case entity.PunchSetLunchEnd:
+			setBreak(day, 1, "").EndHour = punch.Time
+		case entity.PunchSetExit:
+			day.ExitHour = punch.Time
+		case entity.PunchReset:
+			resetDay(day)
+		case entity.PunchDelete:
+			resetDay(day)
+			exists = false
+		default:
+			// Rejected punches leave the day unchanged, just like when they were recorded.
+			compactBreaks(day)
+			_, _ = Apply(day, nil, punch)
+		}
+	}
+
+	compactBreaks(day)
+
+	return exists
+}
+
+// maxBreakIndex limits the index of break corrections, so that a stored
+// punch with a huge index cannot exhaust the memory when it is replayed.
+const maxBreakIndex = 64
+
+// setBreak returns the break at index, adding empty breaks up to it if needed.
+// Indexes beyond maxBreakIndex are ignored and return a break that is not
+// part of the day.
+func setBreak(day *entity.WorkSchedule, index int, breakType string) *entity.WorkBreak {
+	if index < 0 {
+		index = 0
+	} else if index > maxBreakIndex {
+		return &entity.WorkBreak{}
+	}
+
+	for len(day.Breaks) <= index {
+		day.Breaks = append(day.Breaks, entity.WorkBreak{Type: entity.BreakUnpaid})
+	}
+
+	if entity.ValidBreakType(breakType) {
+		day.Breaks[index].Type = breakType
+	}
+
+	return &day.Breaks[index]
+}
+
+// CheckBreakIndexes checks that the break corrections refer to an existing
+// break of a day with the number of breaks, or add the next one. It returns
+// ErrInvalidBreakIndex otherwise.
+func CheckBreakIndexes(breaks int, punches entity.Punches) error {
+	for _, punch := range punches {
+		switch punch.Type {
+		case entity.PunchSetBreakStart, entity.PunchSetBreakEnd:
+			if punch.BreakIndex < 0 || punch.BreakIndex > breaks || punch.BreakIndex > maxBreakIndex {
+				return ErrInvalidBreakIndex
+			} else if punch.BreakIndex == breaks {
+				breaks++
+			}
+		case entity.PunchDeleteBreak:
+			if punch.BreakIndex < 0 || punch.BreakIndex >= breaks {
+				return ErrInvalidBreakIndex
+			}
+			breaks--
+		case entity.PunchReset, entity.PunchDelete:
+			breaks = 0
+		}
+	}
+
+	return nil
+}
+
+// compactBreaks removes breaks without start and end, which were only added
+// to reach the index of a later break.
+func compactBreaks(day *entity.WorkSchedule) {
+	breaks := day.Breaks[:0]
+
+	for _, b := range day.Breaks {
+		if !b.StartHour.IsZero() || !b.EndHour.IsZero() {
+			breaks = append(breaks, b)
+		}
+	}
+
+	if len(breaks) == 0 {
+		breaks = nil
+	}
+
+	day.Breaks = breaks
+}
+
+// resetDay resets all times of the day.
+func resetDay(day *entity.WorkSchedule) {
+	day.EntryHour = time.Time{}
+	day.ExitHour = time.Time{}
+	day.Breaks = nil
+}
@@ -0,0 +1,267 @@
+package clock
+
+import (
+	"time"
+
+	"github.com/alexanderbkl/vidre-back/internal/db"
+	"github.com/alexanderbkl/vidre-back/internal/entity"
+	"gorm.io/gorm"
+)
+
+// Punch appends a clock punch and updates the work schedule of its shift.
+// A punch that continues the open shift of the previous day, e.g. the exit of
+// a night shift, is attributed to the day the shift started. Rejected punches
+// are recorded too, together with the message shown to the worker.
+//
+// Punches that are already flagged for review, e.g. outside the geofences,
+// are recorded with their message but left out of the work day.
+func Punch(punch *entity.Punch, maxShift time.Duration) (*entity.WorkSchedule, Result, error) {
+	var day, previous entity.WorkSchedule
+	var result Result
+
+	err := db.Db().Transaction(func(tx *gorm.DB) error {
+		if err := lockWorker(tx, punch.WorkerID); err != nil {
+			return err
+		}
+
+		if err := projectShift(tx, &day, &previous, punch, maxShift); err != nil {
+			return err
+		}
+
+		if punch.Review != "" {
+			if err := validate(*punch); err != nil {
+				return err
+			}
+
+			result = Result{Message: punch.Message, Rejected: true}
+		} else {
+			var err error
+			if result, err = Apply(&day, &previous, *punch); err != nil {
+				return err
+			}
+
+			if err := day.TxSave(tx); err != nil {
+				return err
+			}
+		}
+
+		punch.WorkScheduleID = day.ID
+		punch.Message = result.Message
+
+		return punch.TxCreate(tx)
+	})
+
+	if err != nil {
+		return nil, Result{}, err
+	}
+
+	return &day, result, nil
+}
+
+// Correct appends correction punches for a work day and recomputes it.
+// It returns nil if the day has been deleted.
+func Correct(workerID uint, date time.Time, punches entity.Punches) (day *entity.WorkSchedule, err error) {
+	err = db.Db().Transaction(func(tx *gorm.DB) error {
+		day, err = TxCorrect(tx, workerID, date, punches)
+
+		return err
+	})
+
+	return day, err
+}
+
+// TxCorrect appends correction punches for a work day and recomputes it
+// within a transaction, e.g. to correct several days at once. It returns
+// ErrInvalidBreakIndex if a break correction refers to a missing break.
+func TxCorrect(tx *gorm.DB, workerID uint, date time.Time, punches entity.Punches) (*entity.WorkSchedule, error) {
+	if err := lockWorker(tx, workerID); err != nil {
+		return nil, err
+	}
+
+	return correct(tx, workerID, date, punches)
+}
+
+// Move deletes a work day and recreates it on another date with correction
+// punches. Its times keep their wall clock time in the location.
+func Move(workerID uint, from, to time.Time, base entity.Punch, loc *time.Location) (day *entity.WorkSchedule, err error) {
+	err = db.Db().Transaction(func(tx *gorm.DB) error {
+		day, err = TxMove(tx, workerID, from, to, base, loc)
+
+		return err
+	})
+
+	return day, err
+}
+
+// TxMove moves a work day to another date within a transaction.
+func TxMove(tx *gorm.DB, workerID uint, from, to time.Time, base entity.Punch, loc *time.Location) (*entity.WorkSchedule, error) {
+	if err := lockWorker(tx, workerID); err != nil {
+		return nil, err
+	}
+
+	var old entity.WorkSchedule
+	if err := findDay(tx, &old, workerID, from); err != nil {
+		return nil, err
+	}
+
+	deletion := base
+	deletion.Type = entity.PunchDelete
+	if _, err := correct(tx, workerID, from, entity.Punches{deletion}); err != nil {
+		return nil, err
+	}
+
+	punches := SetPunches(&old, base)
+	days := int(to.Sub(from).Hours() / 24)
+
+	for i := range punches {
+		punches[i].Time = AddDays(punches[i].Time, days, loc)
+	}
+
+	return correct(tx, workerID, to, punches)
+}
+
+// TxDay locks the worker until the transaction ends and returns the stored
+// work day, or nil if there is none, e.g. to compare it with the work day
+// after a correction.
+func TxDay(tx *gorm.DB, workerID uint, date time.Time) (*entity.WorkSchedule, error) {
+	if err := lockWorker(tx, workerID); err != nil {
+		return nil, err
+	}
+
+	var day entity.WorkSchedule
+	if err := findDay(tx, &day, workerID, date); err != nil {
+		return nil, err
+	} else if day.ID == 0 {
+		return nil, nil
+	}
+
+	return &day, nil
+}
+
+// SetPunches returns correction punches that set the times and breaks of the day that are not empty.
+func SetPunches(day *entity.WorkSchedule, base entity.Punch) entity.Punches {
+	punches := make(entity.Punches, 0, 2+2*len(day.Breaks))
+
+	add := func(punchType string, t time.Time, index int, breakType string) {
+		if t.IsZero() {
+			return
+		}
+
+		punch := base
+		punch.Type = punchType
+		punch.Time = t
+		punch.BreakIndex = index
+		punch.BreakType = breakType
+		punches = append(punches, punch)
+	}
+
+	add(entity.PunchSetEntry, day.EntryHour, 0, "")
+
+	for i, b := range day.Breaks {
+		add(entity.PunchSetBreakStart, b.StartHour, i, b.Type)
+		add(entity.PunchSetBreakEnd, b.EndHour, i, b.Type)
+	}
+
+	add(entity.PunchSetExit, day.ExitHour, 0, "")
+
+	return punches
+}
+
+// correct appends the punches and recomputes the day within a transaction.
+// Break corrections must refer to a break of the day or the next one.
+func correct(tx *gorm.DB, workerID uint, date time.Time, punches entity.Punches) (*entity.WorkSchedule, error) {
+	var day entity.WorkSchedule
+
+	if err := findDay(tx, &day, workerID, date); err != nil {
+		return nil, err
+	}
+
+	if err := CheckBreakIndexes(len(day.Breaks), punches); err != nil {
+		return nil, err
+	}
+
+	if day.ID == 0 {
+		if err := tx.Omit("Breaks").Create(&day).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range punches {
+		punches[i].WorkerID = workerID
+		punches[i].Date = date
+		punches[i].WorkScheduleID = day.ID
+
+		if err := punches[i].TxCreate(tx); err != nil {
+			return nil, err
+		}
+	}
+
+	all, err := entity.FindDayPunches(tx, workerID, date)
+	if err != nil {
+		return nil, err
+	}
+
+	if !Project(&day, all) {
+		return nil, day.TxDelete(tx)
+	}
+
+	return &day, day.TxSave(tx)
+}
+
+// projectShift loads the work day of the punch and the day before it. A punch
+// that continues the open shift of the previous day is moved to that day.
+func projectShift(tx *gorm.DB, day, previous *entity.WorkSchedule, punch *entity.Punch, maxShift time.Duration) error {
+	if err := projectDay(tx, day, punch.WorkerID, punch.Date); err != nil {
+		return err
+	}
+
+	if err := projectDay(tx, previous, punch.WorkerID, punch.Date.AddDate(0, 0, -1)); err != nil {
+		return err
+	}
+
+	if !ContinuesShift(day, previous, *punch, maxShift) {
+		return nil
+	}
+
+	*day = *previous
+	punch.Date = previous.Date
+
+	return projectDay(tx, previous, punch.WorkerID, punch.Date.AddDate(0, 0, -1))
+}
+
+// projectDay loads the work schedule of the day and replays its punches.
+func projectDay(tx *gorm.DB, day *entity.WorkSchedule, workerID uint, date time.Time) error {
+	*day = entity.WorkSchedule{}
+
+	if err := findDay(tx, day, workerID, date); err != nil {
+		return err
+	}
+
+	punches, err := entity.FindDayPunches(tx, workerID, date)
+	if err != nil {
+		return err
+	}
+
+	Project(day, punches)
+
+	return nil
+}
+
+// lockWorker serializes punches of the same worker until the transaction ends.
+func lockWorker(tx *gorm.DB, workerID uint) error {
+	return tx.Exec("SELECT pg_advisory_xact_lock(?)", int64(workerID)).Error
+}
+
+// findDay loads the work schedule of the day or prepares a new one.
+func findDay(tx *gorm.DB, day *entity.WorkSchedule, workerID uint, date time.Time) error {
+	if err := tx.Preload("Breaks", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position")
+	}).Where("worker_id = ? AND date = ?", workerID, date).Limit(1).Find(day).Error; err != nil {
+		return err
+	}
+
+	day.WorkerID = workerID
+	day.Date = date
+
+	return nil
+}
@@ -0,0 +1,25 @@
+package clock
+
+import (
+	"time"
+
+	"github.com/alexanderbkl/vidre-back/internal/entity"
+)
+
+// ContinuesShift tests if a punch belongs to the shift that was started on
+// the previous day and is still open, e.g. the exit of a night shift. This is
+// the case if the day of the punch has no entry of its own and the punch is
+// within maxShift of the start of the previous shift.
+func ContinuesShift(day, previous *entity.WorkSchedule, punch entity.Punch, maxShift time.Duration) bool {
+	switch punch.Type {
+	case entity.PunchStartRest, entity.PunchEndRest, entity.PunchExit:
+	default:
+		return false
+	}
+
+	if previous == nil || !day.EntryHour.IsZero() || previous.EntryHour.IsZero() || !previous.ExitHour.IsZero() {
+		return false
+	}
+
+	return !punch.Time.Before(previous.EntryHour) && punch.Time.Sub(previous.EntryHour) <= maxShift
+}
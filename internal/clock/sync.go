@@ -0,0 +1,152 @@
+package clock
+
+import (
+	"time"
+
+	"github.com/alexanderbkl/vidre-back/internal/db"
+	"github.com/alexanderbkl/vidre-back/internal/entity"
+	"gorm.io/gorm"
+)
+
+// Sync statuses of punches that a device queued while offline.
+const (
+	SyncApplied   = "applied"
+	SyncDuplicate = "duplicate"
+	SyncFlagged   = "flagged"
+)
+
+// Sync records a punch that a device queued while offline. Punches are
+// identified by device and sequence, so a punch that has been synced before
+// is not recorded again and the recorded punch is returned instead.
+//
+// Punches are replayed with the same logic as Punch if they arrive in order.
+// A punch is flagged for review and left out of the work day if the device
+// has already synced a punch with a higher sequence, if it is earlier than
+// the times of its work day, or if the work day rejects it. Punches that are
+// already flagged for review are recorded with their message.
+func Sync(punch *entity.Punch, maxShift time.Duration) (string, Result, error) {
+	var status string
+	var result Result
+
+	err := db.Db().Transaction(func(tx *gorm.DB) error {
+		if err := lockDevice(tx, *punch.DeviceID); err != nil {
+			return err
+		}
+
+		if existing, err := entity.FindDevicePunch(tx, *punch.DeviceID, *punch.DeviceSequence); err != nil {
+			return err
+		} else if existing != nil {
+			*punch = *existing
+			status = SyncDuplicate
+			return nil
+		}
+
+		var last *int64
+		if err := tx.Model(&entity.Punch{}).Where("device_id = ?", *punch.DeviceID).Select("MAX(device_sequence)").Scan(&last).Error; err != nil {
+			return err
+		}
+
+		if err := lockWorker(tx, punch.WorkerID); err != nil {
+			return err
+		}
+
+		var day, previous entity.WorkSchedule
+		if err := projectShift(tx, &day, &previous, punch, maxShift); err != nil {
+			return err
+		}
+
+		var err error
+		if punch.Review != "" {
+			result, err = Result{Message: punch.Message, Rejected: true}, validate(*punch)
+		} else if last != nil && *punch.DeviceSequence < *last {
+			punch.Review, err = entity.PunchReviewOutOfOrder, validate(*punch)
+		} else {
+			result, punch.Review, err = ApplySynced(&day, &previous, *punch)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		status = SyncFlagged
+
+		if punch.Review == "" {
+			if err := day.TxSave(tx); err != nil {
+				return err
+			}
+
+			status = SyncApplied
+		}
+
+		punch.WorkScheduleID = day.ID
+		punch.Message = result.Message
+
+		return punch.TxCreate(tx)
+	})
+
+	return status, result, err
+}
+
+// ApplySynced applies a punch that a device queued while offline to the work
+// day. It returns the review flag of the punch if it cannot be applied in
+// order: PunchReviewOutOfOrder if it is earlier than the times of the day, or
+// PunchReviewConflict if the day rejects it. The day is only changed if the
+// punch has no review flag.
+func ApplySynced(day, previous *entity.WorkSchedule, punch entity.Punch) (Result, string, error) {
+	if err := validate(punch); err != nil {
+		return Result{}, "", err
+	}
+
+	if punch.Time.Before(latest(day)) {
+		return Result{}, entity.PunchReviewOutOfOrder, nil
+	}
+
+	result, err := Apply(day, previous, punch)
+	if err != nil {
+		return result, "", err
+	} else if result.Rejected {
+		return result, entity.PunchReviewConflict, nil
+	}
+
+	return result, "", nil
+}
+
+// validate checks the type and break type of a clock punch.
+func validate(punch entity.Punch) error {
+	switch punch.Type {
+	case entity.PunchEntry, entity.PunchEndRest, entity.PunchExit:
+		return nil
+	case entity.PunchStartRest:
+		if punch.BreakType != "" && !entity.ValidBreakType(punch.BreakType) {
+			return ErrInvalidBreakType
+		}
+		return nil
+	default:
+		return ErrInvalidType
+	}
+}
+
+// latest returns the latest time of the work day.
+func latest(day *entity.WorkSchedule) time.Time {
+	t := maxTime(day.EntryHour, day.ExitHour)
+
+	for _, b := range day.Breaks {
+		t = maxTime(t, maxTime(b.StartHour, b.EndHour))
+	}
+
+	return t
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+
+	return a
+}
+
+// lockDevice serializes the sync of punches of the same device until the
+// transaction ends. Device locks use another key space than worker locks.
+func lockDevice(tx *gorm.DB, deviceID uint) error {
+	return tx.Exec("SELECT pg_advisory_xact_lock(1, ?)", int32(deviceID)).Error
+}
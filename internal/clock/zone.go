@@ -0,0 +1,50 @@
+package clock
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrInvalidTime = errors.New("invalid time format")
+
+// localLayouts are accepted for times without an offset, which are read in
+// the business time zone.
+var localLayouts = []string{
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+}
+
+// ParseTime parses a timestamp. Timestamps with an offset or Z suffix, e.g.
+// "2024-03-31T01:30:00.000Z", are exact; timestamps without one are local
+// times in the location.
+func ParseTime(value string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t.In(loc), nil
+	}
+
+	for _, layout := range localLayouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, ErrInvalidTime
+}
+
+// Date returns the calendar day of t in the location, as stored in date columns.
+func Date(t time.Time, loc *time.Location) time.Time {
+	y, m, d := t.In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// AddDays moves t by a number of calendar days in the location, keeping its
+// wall clock time across daylight saving time changes.
+func AddDays(t time.Time, days int, loc *time.Location) time.Time {
+	if t.IsZero() {
+		return t
+	}
+
+	return t.In(loc).AddDate(0, 0, days)
+}
@@ -0,0 +1,56 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTime(t *testing.T) {
+	madrid, err := time.LoadLocation("Europe/Madrid")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name     string
+		value    string
+		expected time.Time
+		date     string
+	}{
+		{name: "UTC", value: "2024-03-04T07:00:00.000Z", expected: time.Date(2024, 3, 4, 7, 0, 0, 0, time.UTC), date: "2024-03-04"},
+		{name: "Offset", value: "2024-03-04T08:00:00+01:00", expected: time.Date(2024, 3, 4, 7, 0, 0, 0, time.UTC), date: "2024-03-04"},
+		{name: "Local", value: "2024-03-04T08:00", expected: time.Date(2024, 3, 4, 7, 0, 0, 0, time.UTC), date: "2024-03-04"},
+		{name: "LocalSummer", value: "2024-07-04 08:00:00", expected: time.Date(2024, 7, 4, 6, 0, 0, 0, time.UTC), date: "2024-07-04"},
+		{name: "AfterMidnightLocal", value: "2024-03-04T23:30:00.000Z", expected: time.Date(2024, 3, 4, 23, 30, 0, 0, time.UTC), date: "2024-03-05"},
+		{name: "SpringForward", value: "2024-03-31T03:30", expected: time.Date(2024, 3, 31, 1, 30, 0, 0, time.UTC), date: "2024-03-31"},
+		{name: "FallBack", value: "2024-10-27T01:30:00.000Z", expected: time.Date(2024, 10, 27, 1, 30, 0, 0, time.UTC), date: "2024-10-27"},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			parsed, err := ParseTime(tc.value, madrid)
+			require.NoError(t, err)
+			require.True(t, tc.expected.Equal(parsed), "%s != %s", tc.expected, parsed)
+			require.Equal(t, madrid, parsed.Location())
+			require.Equal(t, tc.date, Date(parsed, madrid).Format("2006-01-02"))
+		})
+	}
+
+	_, err = ParseTime("08:00", madrid)
+	require.ErrorIs(t, err, ErrInvalidTime)
+}
+
+func TestAddDays(t *testing.T) {
+	madrid, err := time.LoadLocation("Europe/Madrid")
+	require.NoError(t, err)
+
+	// Moving across the change to summer time keeps the wall clock time, which is 23 hours later.
+	entry := time.Date(2024, 3, 30, 8, 0, 0, 0, madrid)
+	moved := AddDays(entry, 1, madrid)
+	require.Equal(t, time.Date(2024, 3, 31, 8, 0, 0, 0, madrid), moved)
+	require.Equal(t, 23*time.Hour, moved.Sub(entry))
+
+	require.True(t, AddDays(time.Time{}, 1, madrid).IsZero())
+}
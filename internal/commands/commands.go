@@ -10,7 +10,40 @@ import (
 
 var log = event.Log
 
+// Run executes the command given on the command line.
+func Run(args []string) {
+	if len(args) == 0 {
+		Start()
+		return
+	}
+
+	switch args[0] {
+	case "start":
+		Start()
+	case "users":
+		Users(args[1:])
+	case "keys":
+		Keys(args[1:])
+	default:
+		log.Fatalf("unknown command %s", args[0])
+	}
+}
+
 func Start() {
+	connect()
+
+	// connect redis
+	// config.ConnectRedis()
+
+	// Pass this context down the chain.
+	cctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	server.Start(cctx)
+}
+
+// connect loads the config and opens and migrates the database.
+func connect() {
 	// init logger
 	config.InitLogger()
 
@@ -27,13 +60,4 @@ func Start() {
 	}
 
 	config.InitDb()
-
-	// connect redis
-	// config.ConnectRedis()
-
-	// Pass this context down the chain.
-	cctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
-	server.Start(cctx)
 }
@@ -0,0 +1,118 @@
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/alexanderbkl/vidre-back/internal/config"
+	"github.com/alexanderbkl/vidre-back/internal/entity"
+	"github.com/alexanderbkl/vidre-back/pkg/rnd"
+	"github.com/alexanderbkl/vidre-back/pkg/token"
+)
+
+// Keys manages the token signing keys.
+//
+//	keys list
+//	keys generate [-purpose local|public]
+//	keys promote -id ID
+//	keys retire -id ID
+//	keys rotate [-purpose local|public]
+//
+// A generated key is pending: running servers verify its tokens after their
+// next keyring refresh, but keep creating tokens with the current key. Once
+// promoted, the key creates new tokens while the previous key keeps verifying
+// older ones until it is retired. Rotate generates a key, waits for the
+// keyring refresh interval and promotes it.
+func Keys(args []string) {
+	if len(args) == 0 {
+		log.Fatal("usage: keys list|generate|promote|retire|rotate [flags]")
+	}
+
+	flags := flag.NewFlagSet("keys "+args[0], flag.ExitOnError)
+	id := flags.String("id", "", "key id")
+	purpose := flags.String("purpose", "", "key purpose (local, public), defaults to TOKEN_TYPE")
+
+	if err := flags.Parse(args[1:]); err != nil {
+		log.Fatal(err)
+	}
+
+	if (args[0] == "promote" || args[0] == "retire") && *id == "" {
+		flags.Usage()
+		log.Fatal("id is required")
+	}
+
+	connect()
+
+	if *purpose == "" {
+		*purpose = config.Env().TokenType
+	}
+
+	switch args[0] {
+	case "list":
+		listKeys()
+	case "generate":
+		generateKey(*purpose)
+	case "promote":
+		if err := entity.PromoteSigningKey(*id); err != nil {
+			log.Fatal("cannot promote key: ", err)
+		}
+		log.Infof("keys: %s is now the current key", *id)
+	case "retire":
+		if err := entity.RetireSigningKey(*id); err != nil {
+			log.Fatal("cannot retire key: ", err)
+		}
+		log.Infof("keys: retired %s, its tokens are no longer accepted", *id)
+	case "rotate":
+		key := generateKey(*purpose)
+		wait := config.Env().KeyringRefresh + 5*time.Second
+		log.Infof("keys: waiting %s for servers to load %s", wait, key.ID)
+		time.Sleep(wait)
+		if err := entity.PromoteSigningKey(key.ID); err != nil {
+			log.Fatal("cannot promote key: ", err)
+		}
+		log.Infof("keys: %s is now the current key", key.ID)
+	default:
+		log.Fatalf("unknown keys command %s", args[0])
+	}
+}
+
+// generateKey creates and stores a new pending key.
+func generateKey(purpose string) entity.SigningKey {
+	id := fmt.Sprintf("%s-%s", time.Now().UTC().Format("20060102"), rnd.GenerateRandomString(6))
+
+	key, err := token.GenerateKey(id, purpose)
+	if err != nil {
+		log.Fatal("cannot generate key: ", err)
+	}
+
+	kek, err := config.KeyEncryptionKey()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	signingKey, err := entity.NewSigningKey(key, entity.KeyStatePending, kek)
+	if err != nil {
+		log.Fatal("cannot encrypt key: ", err)
+	}
+
+	if err := signingKey.Create(); err != nil {
+		log.Fatal("cannot save key: ", err)
+	}
+
+	log.Infof("keys: generated pending %s key %s", purpose, id)
+
+	return signingKey
+}
+
+// listKeys prints all keys and their state.
+func listKeys() {
+	keys, err := entity.AllSigningKeys()
+	if err != nil {
+		log.Fatal("cannot list keys: ", err)
+	}
+
+	for _, key := range keys {
+		fmt.Printf("%-24s %-8s %-10s created %s\n", key.ID, key.Purpose, key.State, key.CreatedAt.Format(time.RFC3339))
+	}
+}
@@ -0,0 +1,114 @@
+package commands
+
+import (
+	"flag"
+
+	"github.com/alexanderbkl/vidre-back/internal/constant"
+	"github.com/alexanderbkl/vidre-back/internal/entity"
+	"github.com/alexanderbkl/vidre-back/pkg/ethsig"
+)
+
+// Users manages user accounts.
+//
+//	users add -name NAME -password PASSWORD [-role ROLE]
+//	users passwd -name NAME -password PASSWORD
+//	users role -name NAME -role ROLE
+//	users wallet -name NAME [-address ADDRESS]
+func Users(args []string) {
+	if len(args) == 0 {
+		log.Fatal("usage: users add|passwd|role|wallet [flags]")
+	}
+
+	flags := flag.NewFlagSet("users "+args[0], flag.ExitOnError)
+	name := flags.String("name", "", "user name")
+	password := flags.String("password", "", "user password")
+	role := flags.String("role", "", "user role (admin, manager, kiosk, worker), add defaults to worker")
+	address := flags.String("address", "", "wallet address, empty to unlink")
+
+	if err := flags.Parse(args[1:]); err != nil {
+		log.Fatal(err)
+	}
+
+	if *name == "" {
+		flags.Usage()
+		log.Fatal("name is required")
+	}
+
+	if (args[0] == "add" || args[0] == "passwd") && *password == "" {
+		flags.Usage()
+		log.Fatal("password is required")
+	}
+
+	if args[0] == "role" && *role == "" {
+		flags.Usage()
+		log.Fatal("role is required")
+	} else if *role == "" {
+		*role = constant.RoleWorker
+	}
+
+	if !constant.ValidRole(*role) {
+		log.Fatalf("invalid role %s", *role)
+	}
+
+	connect()
+
+	switch args[0] {
+	case "add":
+		user := entity.User{Name: *name, Role: *role}
+		if err := user.SetPassword(*password); err != nil {
+			log.Fatal("cannot set password: ", err)
+		}
+		if err := user.Create(); err != nil {
+			log.Fatal("cannot create user: ", err)
+		}
+		log.Infof("users: created %s (%s) with role %s", user.Name, user.UID, user.Role)
+	case "passwd":
+		user, err := entity.FindUserByName(*name)
+		if err != nil {
+			log.Fatal("cannot find user: ", err)
+		}
+		if err = user.SetPassword(*password); err != nil {
+			log.Fatal("cannot set password: ", err)
+		}
+		if err = user.Save(); err != nil {
+			log.Fatal("cannot save user: ", err)
+		}
+		if err = entity.BlockUserSessions(user.ID); err != nil {
+			log.Fatal("cannot revoke sessions: ", err)
+		}
+		log.Infof("users: changed password of %s", user.Name)
+	case "role":
+		user, err := entity.FindUserByName(*name)
+		if err != nil {
+			log.Fatal("cannot find user: ", err)
+		}
+		user.Role = *role
+		if err = user.Save(); err != nil {
+			log.Fatal("cannot save user: ", err)
+		}
+		// Tokens carry the role, so the user must log in again.
+		if err = entity.BlockUserSessions(user.ID); err != nil {
+			log.Fatal("cannot revoke sessions: ", err)
+		}
+		log.Infof("users: %s now has role %s", user.Name, user.Role)
+	case "wallet":
+		user, err := entity.FindUserByName(*name)
+		if err != nil {
+			log.Fatal("cannot find user: ", err)
+		}
+		user.WalletAddress = nil
+		if *address != "" {
+			wallet, err := ethsig.NormalizeAddress(*address)
+			if err != nil {
+				log.Fatal(err)
+			}
+			user.WalletAddress = &wallet
+		}
+		if err = user.Save(); err != nil {
+			log.Fatal("cannot save user: ", err)
+		}
+		log.Infof("users: linked wallet of %s", user.Name)
+	default:
+		log.Fatalf("unknown users command %s", args[0])
+	}
+}
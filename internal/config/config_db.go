@@ -57,7 +57,7 @@ func ConnectDB() error {
 // MigrateDb initializes the database and migrates the schema if needed.
 func MigrateDb(runFailed bool, ids []string) {
 
-	entity.InitDb(migrate.Opt(true, runFailed, ids))
+	entity.InitDb(migrate.Opt(true, runFailed, ids).In(env.TimeZone))
 
 	go entity.Error{}.LogEvents()
 
@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"os"
 	"reflect"
+	"strconv"
 	"time"
+	_ "time/tzdata"
 
 	"github.com/joho/godotenv"
 )
@@ -14,9 +16,37 @@ type EnvVar struct {
 	AppPort string
 	AppEnv  string
 	// token env
-	TokenSymmetricKey    string
+	TokenType            string
+	TokenSymmetricKey    string `optional:"true"`
+	TokenPrivateKey      string `optional:"true"`
+	TokenEncryptionKey   string // hex encoded 32 bytes, encrypts the signing keys
+	KeyringRefresh       time.Duration
 	AccessTokenDuration  time.Duration
 	RefreshTokenDuration time.Duration
+	DeviceTokenDuration  time.Duration
+	// worker pin env
+	PinMaxAttempts  int
+	PinLockDuration time.Duration
+	// longest shift that a punch on the next day may continue, e.g. a night shift
+	MaxShiftDuration time.Duration
+	// business time zone of the installation, all work days are computed in it
+	TimeZone string `optional:"true"`
+	// company name shown on the working time registers
+	CompanyName string `optional:"true"`
+	// how long responses of requests with an Idempotency-Key header are replayed
+	IdempotencyWindow time.Duration
+	// what happens to punches outside the geofences: off, flag or reject
+	GeofencePolicy string `optional:"true"`
+	// worst accuracy in meters of a punch location that is trusted
+	GeofenceMaxAccuracy int
+	// OpenID Connect env, disabled if OidcIssuer is empty
+	OidcIssuer       string `optional:"true"`
+	OidcClientID     string `optional:"true"`
+	OidcClientSecret string `optional:"true"`
+	OidcRedirectURL  string `optional:"true"`
+	OidcScopes       string `optional:"true"`
+	OidcRoleClaim    string `optional:"true"`
+	OidcRoles        string `optional:"true"`
 	// Postgres env
 	DBHost     string
 	DBName     string
@@ -25,8 +55,21 @@ type EnvVar struct {
 	DBPort     string
 }
 
+// Geofence policies, see EnvVar.GeofencePolicy.
+const (
+	GeofenceOff    = "off"
+	GeofenceFlag   = "flag"
+	GeofenceReject = "reject"
+)
+
+// DefaultTimeZone is the business time zone if TIME_ZONE is not set.
+const DefaultTimeZone = "Europe/Madrid"
+
 var env EnvVar
 
+// location is the loaded business time zone.
+var location = time.UTC
+
 func LoadEnv() (err error) {
 	// skip load env when docker
 	if os.Getenv("APP_PORT") == "" {
@@ -46,14 +89,74 @@ func LoadEnv() (err error) {
 		return err
 	}
 
+	krf, err := optionalDuration("KEYRING_REFRESH", time.Minute)
+	if err != nil {
+		return err
+	}
+
+	dtd, err := optionalDuration("DEVICE_TOKEN_DURATION", 365*24*time.Hour)
+	if err != nil {
+		return err
+	}
+
+	pma, err := optionalInt("PIN_MAX_ATTEMPTS", 5)
+	if err != nil {
+		return err
+	}
+
+	pld, err := optionalDuration("PIN_LOCK_DURATION", 15*time.Minute)
+	if err != nil {
+		return err
+	}
+
+	msd, err := optionalDuration("MAX_SHIFT_DURATION", 16*time.Hour)
+	if err != nil {
+		return err
+	}
+
+	idw, err := optionalDuration("IDEMPOTENCY_WINDOW", 24*time.Hour)
+	if err != nil {
+		return err
+	}
+
+	gma, err := optionalInt("GEOFENCE_MAX_ACCURACY", 100)
+	if err != nil {
+		return err
+	}
+
 	env = EnvVar{
 		// App env
 		AppPort: os.Getenv("APP_PORT"),
 		AppEnv:  os.Getenv("APP_ENV"),
 		// token env
+		TokenType:            os.Getenv("TOKEN_TYPE"),
 		TokenSymmetricKey:    os.Getenv("TOKEN_SYMMETRIC_KEY"),
+		TokenPrivateKey:      os.Getenv("TOKEN_PRIVATE_KEY"),
+		TokenEncryptionKey:   os.Getenv("TOKEN_KEY_ENCRYPTION_KEY"),
+		KeyringRefresh:       krf,
 		AccessTokenDuration:  atd,
 		RefreshTokenDuration: rtd,
+		DeviceTokenDuration:  dtd,
+		// worker pin env
+		PinMaxAttempts:  pma,
+		PinLockDuration: pld,
+		// shifts
+		MaxShiftDuration: msd,
+		TimeZone:         os.Getenv("TIME_ZONE"),
+		CompanyName:      os.Getenv("COMPANY_NAME"),
+		// retries
+		IdempotencyWindow: idw,
+		// geofences
+		GeofencePolicy:      os.Getenv("GEOFENCE_POLICY"),
+		GeofenceMaxAccuracy: gma,
+		// OpenID Connect
+		OidcIssuer:       os.Getenv("OIDC_ISSUER"),
+		OidcClientID:     os.Getenv("OIDC_CLIENT_ID"),
+		OidcClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+		OidcRedirectURL:  os.Getenv("OIDC_REDIRECT_URL"),
+		OidcScopes:       os.Getenv("OIDC_SCOPES"),
+		OidcRoleClaim:    os.Getenv("OIDC_ROLE_CLAIM"),
+		OidcRoles:        os.Getenv("OIDC_ROLES"),
 		// Postgres
 		DBHost:     os.Getenv("POSTGRES_HOST"),
 		DBName:     os.Getenv("POSTGRES_DB"),
@@ -63,14 +166,53 @@ func LoadEnv() (err error) {
 
 	}
 
+	if env.TimeZone == "" {
+		env.TimeZone = DefaultTimeZone
+	}
+
+	if location, err = time.LoadLocation(env.TimeZone); err != nil {
+		return fmt.Errorf("config: TIME_ZONE is invalid (%s)", err)
+	}
+
+	switch env.GeofencePolicy {
+	case "":
+		env.GeofencePolicy = GeofenceOff
+	case GeofenceOff, GeofenceFlag, GeofenceReject:
+	default:
+		return fmt.Errorf("config: unknown geofence policy %s", env.GeofencePolicy)
+	}
+
+	if env.TokenType == "" {
+		env.TokenType = TokenTypeLocal
+	}
+
 	values := reflect.ValueOf(env)
 	types := values.Type()
 	for i := 0; i < values.NumField(); i++ {
+		if types.Field(i).Tag.Get("optional") == "true" {
+			continue
+		}
 		if values.Field(i).String() == "" {
 			return fmt.Errorf("config: %s is missing", types.Field(i).Name)
 		}
 	}
 
+	if env.TokenType != TokenTypeLocal && env.TokenType != TokenTypePublic {
+		return fmt.Errorf("config: unknown token type %s", env.TokenType)
+	}
+
+	if env.OidcIssuer != "" && (env.OidcClientID == "" || env.OidcRedirectURL == "") {
+		return fmt.Errorf("config: OIDC_CLIENT_ID and OIDC_REDIRECT_URL are required with OIDC_ISSUER")
+	}
+
+	if env.OidcScopes == "" {
+		env.OidcScopes = "openid profile email"
+	}
+
+	if env.OidcRoleClaim == "" {
+		env.OidcRoleClaim = "groups"
+	}
+
 	if err != nil {
 		return
 	}
@@ -78,6 +220,41 @@ func LoadEnv() (err error) {
 	return
 }
 
+// optionalDuration parses the duration in the named variable, if set.
+func optionalDuration(name string, fallback time.Duration) (time.Duration, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("config: %s is invalid (%s)", name, err)
+	}
+
+	return d, nil
+}
+
+// optionalInt parses the integer in the named variable, if set.
+func optionalInt(name string, fallback int) (int, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback, nil
+	}
+
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("config: %s is invalid (%s)", name, err)
+	}
+
+	return i, nil
+}
+
 func Env() EnvVar {
 	return env
 }
+
+// Location returns the business time zone, in which work days start and end.
+func Location() *time.Location {
+	return location
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"strings"
+
+	"github.com/alexanderbkl/vidre-back/internal/constant"
+	"github.com/alexanderbkl/vidre-back/pkg/oidc"
+)
+
+// NewOidcProvider creates the OpenID Connect provider and role mapping.
+// It returns a nil provider if OIDC_ISSUER is not set.
+//
+// OIDC_ROLES maps values of the OIDC_ROLE_CLAIM claim to roles, e.g.
+// "time-admins=admin,office=manager". Users without a mapped role cannot log in.
+func NewOidcProvider() (*oidc.Provider, oidc.RoleMapping, error) {
+	if env.OidcIssuer == "" {
+		return nil, oidc.RoleMapping{}, nil
+	}
+
+	mapping, err := oidc.ParseRoleMapping(env.OidcRoleClaim, env.OidcRoles)
+	if err != nil {
+		return nil, oidc.RoleMapping{}, err
+	}
+
+	for _, role := range mapping.Roles {
+		if !constant.ValidRole(role) {
+			log.Warnf("config: OIDC_ROLES contains unknown role %s", role)
+		}
+	}
+
+	provider := oidc.NewProvider(oidc.Config{
+		Issuer:       env.OidcIssuer,
+		ClientID:     env.OidcClientID,
+		ClientSecret: env.OidcClientSecret,
+		RedirectURL:  env.OidcRedirectURL,
+		Scopes:       strings.Fields(env.OidcScopes),
+	}, nil)
+
+	return provider, mapping, nil
+}
@@ -0,0 +1,157 @@
+package config
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/alexanderbkl/vidre-back/internal/entity"
+	"github.com/alexanderbkl/vidre-back/pkg/token"
+)
+
+// Token types selected with TOKEN_TYPE.
+//
+// Keys are stored in the signing_keys table, encrypted with
+// TOKEN_KEY_ENCRYPTION_KEY. TOKEN_SYMMETRIC_KEY or TOKEN_PRIVATE_KEY is only
+// used to create the initial key, so that tokens issued before the keyring
+// existed keep working.
+const (
+	// TokenTypeLocal creates v2.local tokens encrypted with a symmetric key.
+	TokenTypeLocal = "local"
+	// TokenTypePublic creates v2.public tokens signed with an Ed25519 key.
+	// TOKEN_PRIVATE_KEY is a hex encoded 32 byte seed, e.g. from "openssl rand -hex 32".
+	TokenTypePublic = "public"
+)
+
+// NewTokenMaker creates a token maker that uses the keyring stored in the database.
+// The keyring is reloaded periodically so that new keys are picked up without a restart.
+func NewTokenMaker() (token.Maker, error) {
+	kek, err := KeyEncryptionKey()
+	if err != nil {
+		return nil, err
+	}
+
+	ring, err := LoadKeyring(kek)
+	if err != nil {
+		return nil, err
+	}
+
+	go refreshKeyring(ring, kek, env.KeyringRefresh)
+
+	return token.NewKeyringMaker(ring)
+}
+
+// KeyEncryptionKey returns the key that encrypts the signing keys in the database.
+func KeyEncryptionKey() (*[32]byte, error) {
+	b, err := hex.DecodeString(env.TokenEncryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("config: invalid token key encryption key (%s)", err)
+	} else if len(b) != 32 {
+		return nil, fmt.Errorf("config: token key encryption key must be 32 bytes")
+	}
+
+	var kek [32]byte
+	copy(kek[:], b)
+
+	return &kek, nil
+}
+
+// LoadKeyring loads the keyring from the database and creates the initial key
+// if needed. Keys stored before keys were encrypted are encrypted first.
+func LoadKeyring(kek *[32]byte) (*token.Keyring, error) {
+	if err := entity.SealSigningKeys(kek); err != nil {
+		return nil, err
+	}
+
+	keys, err := entity.AllSigningKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(keys) == 0 {
+		if err := createInitialKey(kek); err != nil {
+			return nil, err
+		}
+
+		if keys, err = entity.AllSigningKeys(); err != nil {
+			return nil, err
+		}
+	}
+
+	current, tokenKeys, err := keys.Keyring(kek)
+	if err != nil {
+		return nil, err
+	}
+
+	return token.NewKeyring(current, tokenKeys)
+}
+
+// refreshKeyring reloads the keyring at the given interval.
+func refreshKeyring(ring *token.Keyring, kek *[32]byte, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		keys, err := entity.AllSigningKeys()
+		if err != nil {
+			log.Errorf("config: cannot load signing keys (%s)", err)
+			continue
+		}
+
+		current, tokenKeys, err := keys.Keyring(kek)
+		if err == nil {
+			err = ring.Set(current, tokenKeys)
+		}
+
+		if err != nil {
+			log.Errorf("config: cannot refresh keyring (%s)", err)
+		}
+	}
+}
+
+// createInitialKey stores the configured key as the current key.
+func createInitialKey(kek *[32]byte) error {
+	key := token.Key{ID: token.LegacyKeyID, Purpose: env.TokenType}
+
+	switch env.TokenType {
+	case TokenTypePublic:
+		privateKey, err := parsePrivateKey(env.TokenPrivateKey)
+		if err != nil {
+			return err
+		}
+		key.Material = privateKey
+	default:
+		key.Material = []byte(env.TokenSymmetricKey)
+	}
+
+	if err := key.Validate(); err != nil {
+		return err
+	}
+
+	signingKey, err := entity.NewSigningKey(key, entity.KeyStateCurrent, kek)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("config: creating initial %s signing key", key.Purpose)
+
+	return signingKey.Create()
+}
+
+// parsePrivateKey decodes a hex encoded Ed25519 seed or private key.
+func parsePrivateKey(s string) (ed25519.PrivateKey, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("config: invalid token private key (%s)", err)
+	}
+
+	switch len(b) {
+	case ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(b), nil
+	case ed25519.PrivateKeySize:
+		return ed25519.PrivateKey(b), nil
+	default:
+		return nil, fmt.Errorf("config: token private key must be %d or %d bytes", ed25519.SeedSize, ed25519.PrivateKeySize)
+	}
+}
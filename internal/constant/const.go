@@ -7,9 +7,64 @@ import (
 const (
 	AuthorizationHeaderKey  = "authorization"
 	AuthorizationTypeBearer = "bearer"
+	AuthorizationTypeApiKey = "apikey"
 	AuthorizationPayloadKey = "authorization_payload"
+	AuthorizationApiKeyKey  = "authorization_api_key"
 )
 
+// User roles, see middlewares.RequireRole.
+const (
+	RoleAdmin   = "admin"
+	RoleManager = "manager"
+	RoleKiosk   = "kiosk"
+	RoleWorker  = "worker"
+)
+
+// Roles lists all valid user roles.
+var Roles = []string{RoleAdmin, RoleManager, RoleKiosk, RoleWorker}
+
+// ValidRole returns true if the role is one of Roles.
+func ValidRole(role string) bool {
+	for _, r := range Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// API key scopes, see middlewares.RequireScope.
+const (
+	ScopeWorkersRead   = "workers:read"
+	ScopeWorkersWrite  = "workers:write"
+	ScopeWorkDaysRead  = "work_days:read"
+	ScopeWorkDaysWrite = "work_days:write"
+	ScopeFestivosRead  = "festivos:read"
+	ScopeFestivosWrite = "festivos:write"
+	ScopeAuditRead     = "audit:read"
+)
+
+// Scopes lists all valid API key scopes.
+var Scopes = []string{
+	ScopeWorkersRead, ScopeWorkersWrite,
+	ScopeWorkDaysRead, ScopeWorkDaysWrite,
+	ScopeFestivosRead, ScopeFestivosWrite,
+	ScopeAuditRead,
+}
+
+// ValidScope returns true if the scope is one of Scopes.
+func ValidScope(scope string) bool {
+	for _, s := range Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// TotpIssuer is the account issuer shown by authenticator apps.
+const TotpIssuer = "Vidre"
+
 const LoginMessage = "Greetings from hello\nSign this message to log into hello\nnonce: "
 
 func BuildLoginMessage(nonce string) []byte {
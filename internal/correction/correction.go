@@ -0,0 +1,236 @@
+/*
+Package correction implements the workflow of correction requests.
+
+Workers file requests to correct a single time of a work day with a reason,
+e.g. a forgotten exit. Managers approve or reject pending requests; approved
+requests are applied to the work day as a correction punch, so the work day
+keeps its full punch history. Every change of status is kept in the history
+of the request.
+*/
+package correction
+
+import (
+	"errors"
+	"time"
+	"unicode/utf8"
+
+	"github.com/alexanderbkl/vidre-back/internal/audit"
+	"github.com/alexanderbkl/vidre-back/internal/clock"
+	"github.com/alexanderbkl/vidre-back/internal/db"
+	"github.com/alexanderbkl/vidre-back/internal/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MaxTextLength is the maximum number of characters of reasons and notes.
+const MaxTextLength = 500
+
+var (
+	ErrInvalidType    = errors.New("invalid correction type")
+	ErrInvalidBreak   = errors.New("invalid break")
+	ErrInvalidTime    = errors.New("time is not within the work day")
+	ErrFutureTime     = errors.New("time is in the future")
+	ErrReasonRequired = errors.New("reason is required")
+	ErrNoteRequired   = errors.New("note is required to reject a request")
+	ErrTextTooLong    = errors.New("text is too long")
+	ErrNotPending     = errors.New("correction request has already been reviewed")
+)
+
+// Types are the correction punch types that can be requested.
+var Types = []string{
+	entity.PunchSetEntry,
+	entity.PunchSetExit,
+	entity.PunchSetBreakStart,
+	entity.PunchSetBreakEnd,
+	entity.PunchDeleteBreak,
+}
+
+// Validate checks a correction request before it is filed. The time must be
+// on the date of the work day, or on the day after for shifts that end after
+// midnight, and not later than now. The time of break deletions is ignored.
+func Validate(request *entity.CorrectionRequest, now time.Time, loc *time.Location) error {
+	valid := false
+	for _, t := range Types {
+		valid = valid || request.Type == t
+	}
+
+	if !valid {
+		return ErrInvalidType
+	} else if request.BreakIndex < 0 || (request.BreakType != "" && !entity.ValidBreakType(request.BreakType)) {
+		return ErrInvalidBreak
+	}
+
+	if err := validateText(request.Reason); err != nil {
+		return err
+	} else if request.Reason == "" {
+		return ErrReasonRequired
+	}
+
+	if request.Type == entity.PunchDeleteBreak {
+		return nil
+	}
+
+	if date := clock.Date(request.Time, loc); !date.Equal(request.Date) && !date.Equal(request.Date.AddDate(0, 0, 1)) {
+		return ErrInvalidTime
+	} else if request.Time.After(now) {
+		return ErrFutureTime
+	}
+
+	return nil
+}
+
+// File stores a new pending correction request. The user or device of the
+// request is recorded as the author of the first status. Break corrections
+// must refer to a break of the work day, or to the next one.
+func File(request *entity.CorrectionRequest) error {
+	request.Status = entity.CorrectionPending
+	request.PunchID = nil
+	request.History = entity.CorrectionHistory{{
+		Status:   entity.CorrectionPending,
+		UserID:   request.UserID,
+		DeviceID: request.DeviceID,
+	}}
+
+	return db.Db().Transaction(func(tx *gorm.DB) error {
+		day, err := clock.TxDay(tx, request.WorkerID, request.Date)
+		if err != nil {
+			return err
+		} else if err := validateBreak(request, day); err != nil {
+			return err
+		}
+
+		return tx.Create(request).Error
+	})
+}
+
+// validateBreak checks the break index of a request against the breaks of
+// its work day, which is nil if there is none.
+func validateBreak(request *entity.CorrectionRequest, day *entity.WorkSchedule) error {
+	breaks := 0
+	if day != nil {
+		breaks = len(day.Breaks)
+	}
+
+	if clock.CheckBreakIndexes(breaks, entity.Punches{{Type: request.Type, BreakIndex: request.BreakIndex}}) != nil {
+		return ErrInvalidBreak
+	}
+
+	return nil
+}
+
+// Approve applies a pending correction request to its work day and marks it
+// as approved by the actor. The change of the work day is recorded in the
+// audit log with the reason of the request. It returns the corrected work
+// day, or nil if the correction removed it, and ErrInvalidBreak if the break
+// of the request no longer exists.
+func Approve(uid string, actor audit.Actor, note string) (request *entity.CorrectionRequest, day *entity.WorkSchedule, err error) {
+	if err := validateText(note); err != nil {
+		return nil, nil, err
+	}
+
+	err = db.Db().Transaction(func(tx *gorm.DB) error {
+		if request, err = review(tx, uid); err != nil {
+			return err
+		}
+
+		before, err := clock.TxDay(tx, request.WorkerID, request.Date)
+		if err != nil {
+			return err
+		}
+
+		punches := entity.Punches{{
+			Type:       request.Type,
+			Time:       request.Time,
+			BreakIndex: request.BreakIndex,
+			BreakType:  request.BreakType,
+			Source:     entity.PunchSourceManual,
+			UserID:     actor.UserID,
+		}}
+
+		if day, err = clock.TxCorrect(tx, request.WorkerID, request.Date, punches); errors.Is(err, clock.ErrInvalidBreakIndex) {
+			// The break has been removed since the request was filed.
+			return ErrInvalidBreak
+		} else if err != nil {
+			return err
+		}
+
+		if err := audit.WorkDay(tx, actor, request.Reason, before, day); err != nil {
+			return err
+		}
+
+		request.PunchID = &punches[0].ID
+
+		return setStatus(tx, request, entity.CorrectionApproved, actor.UserID, note)
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	request, err = entity.FindCorrectionRequest(uid)
+
+	return request, day, err
+}
+
+// Reject marks a pending correction request as rejected by the actor. A note
+// that explains the rejection to the worker is required.
+func Reject(uid string, actor audit.Actor, note string) (request *entity.CorrectionRequest, err error) {
+	if err := validateText(note); err != nil {
+		return nil, err
+	} else if note == "" {
+		return nil, ErrNoteRequired
+	}
+
+	err = db.Db().Transaction(func(tx *gorm.DB) error {
+		if request, err = review(tx, uid); err != nil {
+			return err
+		}
+
+		return setStatus(tx, request, entity.CorrectionRejected, actor.UserID, note)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return entity.FindCorrectionRequest(uid)
+}
+
+// review locks a correction request until the transaction ends and checks
+// that it is still pending.
+func review(tx *gorm.DB, uid string) (*entity.CorrectionRequest, error) {
+	var request entity.CorrectionRequest
+
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("uid = ?", uid).First(&request).Error; err != nil {
+		return nil, err
+	} else if !request.Pending() {
+		return nil, ErrNotPending
+	}
+
+	return &request, nil
+}
+
+// setStatus changes the status of the request and adds it to its history.
+func setStatus(tx *gorm.DB, request *entity.CorrectionRequest, status string, userID *uint, note string) error {
+	request.Status = status
+
+	if err := tx.Model(request).Select("status", "punch_id").Updates(request).Error; err != nil {
+		return err
+	}
+
+	return tx.Create(&entity.CorrectionStatus{
+		CorrectionRequestID: request.ID,
+		Status:              status,
+		Note:                note,
+		UserID:              userID,
+	}).Error
+}
+
+// validateText checks the length of a reason or note.
+func validateText(text string) error {
+	if utf8.RuneCountInString(text) > MaxTextLength {
+		return ErrTextTooLong
+	}
+
+	return nil
+}
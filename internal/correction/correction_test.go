@@ -0,0 +1,138 @@
+package correction
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexanderbkl/vidre-back/internal/entity"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Madrid")
+	require.NoError(t, err)
+
+	now := time.Date(2024, 3, 6, 9, 0, 0, 0, loc)
+
+	at := func(day, hour, min int) time.Time {
+		return time.Date(2024, 3, day, hour, min, 0, 0, loc)
+	}
+
+	testCases := []struct {
+		name    string
+		day     int
+		request entity.CorrectionRequest
+		err     error
+	}{
+		{
+			name:    "ForgottenExit",
+			request: entity.CorrectionRequest{Type: entity.PunchSetExit, Time: at(4, 17, 2), Reason: "Olvidé marcar la salida"},
+		},
+		{
+			name:    "NightShiftExit",
+			request: entity.CorrectionRequest{Type: entity.PunchSetExit, Time: at(5, 6, 0), Reason: "Turno de noche"},
+		},
+		{
+			name:    "PaidBreak",
+			request: entity.CorrectionRequest{Type: entity.PunchSetBreakStart, Time: at(4, 10, 0), BreakIndex: 1, BreakType: entity.BreakPaid, Reason: "Descanso"},
+		},
+		{
+			name:    "DeleteBreak",
+			request: entity.CorrectionRequest{Type: entity.PunchDeleteBreak, Reason: "Descanso duplicado"},
+		},
+		{
+			name:    "InvalidType",
+			request: entity.CorrectionRequest{Type: entity.PunchReset, Time: at(4, 17, 0), Reason: "Reiniciar"},
+			err:     ErrInvalidType,
+		},
+		{
+			name:    "ClockType",
+			request: entity.CorrectionRequest{Type: entity.PunchExit, Time: at(4, 17, 0), Reason: "Salida"},
+			err:     ErrInvalidType,
+		},
+		{
+			name:    "NegativeBreakIndex",
+			request: entity.CorrectionRequest{Type: entity.PunchSetBreakEnd, Time: at(4, 10, 0), BreakIndex: -1, Reason: "Descanso"},
+			err:     ErrInvalidBreak,
+		},
+		{
+			name:    "InvalidBreakType",
+			request: entity.CorrectionRequest{Type: entity.PunchSetBreakStart, Time: at(4, 10, 0), BreakType: "lunch", Reason: "Descanso"},
+			err:     ErrInvalidBreak,
+		},
+		{
+			name:    "NoReason",
+			request: entity.CorrectionRequest{Type: entity.PunchSetExit, Time: at(4, 17, 0)},
+			err:     ErrReasonRequired,
+		},
+		{
+			name:    "ReasonTooLong",
+			request: entity.CorrectionRequest{Type: entity.PunchSetExit, Time: at(4, 17, 0), Reason: strings.Repeat("ñ", MaxTextLength+1)},
+			err:     ErrTextTooLong,
+		},
+		{
+			name:    "DayBefore",
+			request: entity.CorrectionRequest{Type: entity.PunchSetEntry, Time: at(3, 23, 0), Reason: "Entrada"},
+			err:     ErrInvalidTime,
+		},
+		{
+			name:    "TwoDaysLater",
+			request: entity.CorrectionRequest{Type: entity.PunchSetExit, Time: at(6, 1, 0), Reason: "Salida"},
+			err:     ErrInvalidTime,
+		},
+		{
+			name:    "Future",
+			day:     6,
+			request: entity.CorrectionRequest{Type: entity.PunchSetExit, Time: at(6, 17, 0), Reason: "Salida"},
+			err:     ErrFutureTime,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			request := tc.request
+			request.Date = time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+			if tc.day != 0 {
+				request.Date = time.Date(2024, 3, tc.day, 0, 0, 0, 0, time.UTC)
+			}
+
+			err := Validate(&request, now, loc)
+			if tc.err == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, tc.err)
+			}
+		})
+	}
+}
+
+func TestValidateBreak(t *testing.T) {
+	day := &entity.WorkSchedule{Breaks: entity.WorkBreaks{{Type: entity.BreakUnpaid}, {Type: entity.BreakPaid}}}
+
+	testCases := []struct {
+		name    string
+		day     *entity.WorkSchedule
+		request entity.CorrectionRequest
+		err     error
+	}{
+		{name: "Exit", day: day, request: entity.CorrectionRequest{Type: entity.PunchSetExit}},
+		{name: "Existing", day: day, request: entity.CorrectionRequest{Type: entity.PunchSetBreakEnd, BreakIndex: 1}},
+		{name: "Next", day: day, request: entity.CorrectionRequest{Type: entity.PunchSetBreakStart, BreakIndex: 2}},
+		{name: "FirstOfNewDay", request: entity.CorrectionRequest{Type: entity.PunchSetBreakStart}},
+		{name: "Gap", day: day, request: entity.CorrectionRequest{Type: entity.PunchSetBreakStart, BreakIndex: 3}, err: ErrInvalidBreak},
+		{name: "Huge", day: day, request: entity.CorrectionRequest{Type: entity.PunchSetBreakEnd, BreakIndex: 2_000_000_000}, err: ErrInvalidBreak},
+		{name: "DeleteMissing", day: day, request: entity.CorrectionRequest{Type: entity.PunchDeleteBreak, BreakIndex: 2}, err: ErrInvalidBreak},
+		{name: "DeleteWithoutDay", request: entity.CorrectionRequest{Type: entity.PunchDeleteBreak}, err: ErrInvalidBreak},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.err, validateBreak(&tc.request, tc.day))
+		})
+	}
+}
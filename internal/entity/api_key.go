@@ -0,0 +1,163 @@
+package entity
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/alexanderbkl/vidre-back/internal/db"
+	"github.com/alexanderbkl/vidre-back/pkg/rnd"
+	"gorm.io/gorm"
+)
+
+const ApiKeyUID = byte('k')
+
+// API key format: "vk_" followed by the prefix, a dot and the secret.
+const (
+	ApiKeyPrefix       = "vk_"
+	ApiKeyPrefixLength = 8
+	ApiKeySecretLength = 32
+)
+
+var ErrApiKeyInvalid = errors.New("api key is invalid, expired or revoked")
+
+// ApiKey grants scoped access to the API for integrations without a user login.
+type ApiKey struct {
+	ID         uint           `gorm:"primary_key" json:"id"`
+	UID        string         `gorm:"type:varchar(42);uniqueIndex" json:"uid"`
+	Name       string         `gorm:"type:varchar(255);not null" json:"name"`
+	Prefix     string         `gorm:"type:varchar(16);uniqueIndex;not null" json:"prefix"`
+	SecretHash string         `gorm:"type:varchar(64);not null" json:"-"`
+	Scopes     string         `gorm:"type:varchar(512)" json:"scopes"`
+	ExpiresAt  *time.Time     `json:"expires_at"`
+	LastUsedAt *time.Time     `json:"last_used_at"`
+	RevokedAt  *time.Time     `json:"revoked_at"`
+	CreatedBy  uint           `gorm:"type:integer" json:"created_by"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+func (ApiKey) TableName() string {
+	return "api_keys"
+}
+
+type ApiKeys []ApiKey
+
+// BeforeCreate assigns a unique id to new api keys.
+func (key *ApiKey) BeforeCreate(tx *gorm.DB) error {
+	if rnd.IsUID(key.UID, ApiKeyUID) {
+		return nil
+	}
+
+	key.UID = rnd.GenerateUID(ApiKeyUID)
+
+	return nil
+}
+
+// NewSecret generates a new prefix and secret and returns the full key.
+// The key is returned once; only a hash of the secret is stored. Secrets
+// are random, so a fast hash is sufficient and keeps each request cheap.
+func (key *ApiKey) NewSecret() string {
+	secret := rnd.GenerateRandomString(ApiKeySecretLength)
+
+	key.Prefix = rnd.GenerateRandomString(ApiKeyPrefixLength)
+	key.SecretHash = hashApiKeySecret(secret)
+
+	return ApiKeyPrefix + key.Prefix + "." + secret
+}
+
+// SetScopes stores the scopes as a space separated list.
+func (key *ApiKey) SetScopes(scopes []string) {
+	key.Scopes = strings.Join(scopes, " ")
+}
+
+// HasScope returns true if the key has been granted the scope.
+func (key *ApiKey) HasScope(scope string) bool {
+	for _, s := range strings.Fields(key.Scopes) {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Active returns true if the key has neither expired nor been revoked.
+func (key *ApiKey) Active(now time.Time) bool {
+	return key.RevokedAt == nil && (key.ExpiresAt == nil || now.Before(*key.ExpiresAt))
+}
+
+// checkSecret returns true if the secret matches the stored hash.
+func (key *ApiKey) checkSecret(secret string) bool {
+	return subtle.ConstantTimeCompare([]byte(hashApiKeySecret(secret)), []byte(key.SecretHash)) == 1
+}
+
+// Used updates the last used timestamp.
+func (key *ApiKey) Used() error {
+	now := time.Now().UTC()
+	key.LastUsedAt = &now
+	return db.Db().Model(&ApiKey{}).Where("id = ?", key.ID).UpdateColumn("last_used_at", now).Error
+}
+
+// Revoke rejects the key from now on.
+func (key *ApiKey) Revoke() error {
+	now := time.Now().UTC()
+	key.RevokedAt = &now
+	return db.Db().Model(&ApiKey{}).Where("id = ?", key.ID).UpdateColumn("revoked_at", now).Error
+}
+
+func (key *ApiKey) Create() error {
+	return db.Db().Create(key).Error
+}
+
+// hashApiKeySecret returns the hex encoded SHA-256 hash of the secret.
+func hashApiKeySecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// parseApiKey splits a key into prefix and secret.
+func parseApiKey(s string) (prefix, secret string, ok bool) {
+	if !strings.HasPrefix(s, ApiKeyPrefix) {
+		return "", "", false
+	}
+
+	prefix, secret, ok = strings.Cut(strings.TrimPrefix(s, ApiKeyPrefix), ".")
+	if !ok || len(prefix) != ApiKeyPrefixLength || secret == "" {
+		return "", "", false
+	}
+
+	return prefix, secret, true
+}
+
+// AuthenticateApiKey returns the active api key matching the full key.
+func AuthenticateApiKey(s string) (*ApiKey, error) {
+	prefix, secret, ok := parseApiKey(s)
+	if !ok {
+		return nil, ErrApiKeyInvalid
+	}
+
+	var key ApiKey
+	if err := db.Db().Where("prefix = ?", prefix).First(&key).Error; err != nil {
+		return nil, ErrApiKeyInvalid
+	}
+
+	if !key.checkSecret(secret) || !key.Active(time.Now()) {
+		return nil, ErrApiKeyInvalid
+	}
+
+	return &key, nil
+}
+
+// FindApiKeyByUID returns the api key with the given unique id.
+func FindApiKeyByUID(uid string) (*ApiKey, error) {
+	var key ApiKey
+	if err := db.Db().Where("uid = ?", uid).First(&key).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
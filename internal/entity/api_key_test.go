@@ -0,0 +1,67 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alexanderbkl/vidre-back/internal/constant"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApiKeyNewSecret(t *testing.T) {
+	var key ApiKey
+
+	s := key.NewSecret()
+
+	prefix, secret, ok := parseApiKey(s)
+	require.True(t, ok)
+	require.Equal(t, key.Prefix, prefix)
+	require.Len(t, secret, ApiKeySecretLength)
+	require.True(t, key.checkSecret(secret))
+	require.False(t, key.checkSecret(secret+"x"))
+	require.NotContains(t, key.SecretHash, secret)
+}
+
+func TestParseApiKey(t *testing.T) {
+	testCases := []struct {
+		name string
+		key  string
+		ok   bool
+	}{
+		{name: "OK", key: "vk_abcdefgh.secret", ok: true},
+		{name: "NoPrefix", key: "abcdefgh.secret"},
+		{name: "NoSecret", key: "vk_abcdefgh."},
+		{name: "NoSeparator", key: "vk_abcdefghsecret"},
+		{name: "ShortPrefix", key: "vk_abc.secret"},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			_, _, ok := parseApiKey(tc.key)
+			require.Equal(t, tc.ok, ok)
+		})
+	}
+}
+
+func TestApiKeyScopes(t *testing.T) {
+	var key ApiKey
+	key.SetScopes([]string{constant.ScopeWorkersRead, constant.ScopeWorkDaysRead})
+
+	require.True(t, key.HasScope(constant.ScopeWorkersRead))
+	require.True(t, key.HasScope(constant.ScopeWorkDaysRead))
+	require.False(t, key.HasScope(constant.ScopeWorkersWrite))
+	require.False(t, key.HasScope("workers"))
+}
+
+func TestApiKeyActive(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Minute)
+	future := now.Add(time.Minute)
+
+	require.True(t, (&ApiKey{}).Active(now))
+	require.True(t, (&ApiKey{ExpiresAt: &future}).Active(now))
+	require.False(t, (&ApiKey{ExpiresAt: &past}).Active(now))
+	require.False(t, (&ApiKey{RevokedAt: &past}).Active(now))
+}
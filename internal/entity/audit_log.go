@@ -0,0 +1,100 @@
+package entity
+
+import (
+	"errors"
+	"time"
+
+	"github.com/alexanderbkl/vidre-back/internal/db"
+	"gorm.io/gorm"
+)
+
+// Audited entities.
+const (
+	AuditWorker       = "worker"
+	AuditWorkSchedule = "work_schedule"
+	AuditExtraHour    = "extra_hour"
+	AuditFestivo      = "festivo"
+)
+
+// AuditEntities lists all audited entities.
+var AuditEntities = []string{AuditWorker, AuditWorkSchedule, AuditExtraHour, AuditFestivo}
+
+// Audit actions.
+const (
+	AuditCreate = "create"
+	AuditUpdate = "update"
+	AuditDelete = "delete"
+)
+
+var ErrAuditLogImmutable = errors.New("audit logs cannot be changed or deleted")
+
+// AuditLog records a mutation of an entity: who made it and when, why, and
+// the values of the changed fields before and after. Audit logs are
+// append-only.
+type AuditLog struct {
+	ID        uint         `gorm:"primary_key" json:"id"`
+	Entity    string       `gorm:"type:varchar(32);not null;index:idx_audit_logs_entity" json:"entity"`
+	EntityID  string       `gorm:"type:varchar(64);not null;index:idx_audit_logs_entity" json:"entity_id"`
+	Action    string       `gorm:"type:varchar(16);not null" json:"action"`
+	Changes   AuditChanges `gorm:"type:jsonb;serializer:json" json:"changes"`
+	Reason    string       `gorm:"type:varchar(500);not null" json:"reason"`
+	UserID    *uint        `gorm:"type:integer" json:"user_id,omitempty"`
+	UserName  string       `gorm:"type:varchar(255)" json:"user_name,omitempty"`
+	DeviceID  *uint        `gorm:"type:integer" json:"device_id,omitempty"`
+	ApiKeyID  *uint        `gorm:"type:integer" json:"api_key_id,omitempty"`
+	CreatedAt time.Time    `gorm:"index" json:"created_at"`
+}
+
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
+
+type AuditLogs []AuditLog
+
+// BeforeUpdate prevents audit logs from being changed.
+func (entry *AuditLog) BeforeUpdate(tx *gorm.DB) error {
+	return ErrAuditLogImmutable
+}
+
+// BeforeDelete prevents audit logs from being deleted.
+func (entry *AuditLog) BeforeDelete(tx *gorm.DB) error {
+	return ErrAuditLogImmutable
+}
+
+// AuditChange is the value of a field before and after a mutation. Before is
+// nil for created entities and After for deleted ones.
+type AuditChange struct {
+	Field  string      `json:"field"`
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+type AuditChanges []AuditChange
+
+// ValidAuditEntity returns true if the entity is one of AuditEntities.
+func ValidAuditEntity(name string) bool {
+	for _, e := range AuditEntities {
+		if e == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// FindAuditLogs returns the audit log of an entity in chronological order,
+// or of all entities of its kind if the id is empty.
+func FindAuditLogs(name, id string) (AuditLogs, error) {
+	var logs AuditLogs
+
+	q := db.Db().Where("entity = ?", name)
+	if id != "" {
+		q = q.Where("entity_id = ?", id)
+	}
+
+	if err := q.Order("id").Find(&logs).Error; err != nil {
+		return nil, err
+	}
+
+	return logs, nil
+}
@@ -0,0 +1,115 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/alexanderbkl/vidre-back/internal/db"
+	"github.com/alexanderbkl/vidre-back/pkg/rnd"
+	"gorm.io/gorm"
+)
+
+const CorrectionRequestUID = byte('c')
+
+// Correction request statuses.
+const (
+	CorrectionPending  = "pending"
+	CorrectionApproved = "approved"
+	CorrectionRejected = "rejected"
+)
+
+// CorrectionRequest asks managers to correct a single time of a work day,
+// e.g. a forgotten exit. Workers file requests with a reason, and approved
+// requests are applied to the work day as a correction punch. Every change
+// of status is kept in the history of the request.
+type CorrectionRequest struct {
+	ID         uint              `gorm:"primary_key" json:"-"`
+	UID        string            `gorm:"type:varchar(42);uniqueIndex" json:"uid"`
+	WorkerID   uint              `gorm:"type:integer;index;not null" json:"worker_id"`
+	Date       time.Time         `gorm:"type:date;index" json:"date"`
+	Type       string            `gorm:"type:varchar(32);not null" json:"type"`
+	Time       time.Time         `gorm:"not null" json:"time"`
+	BreakIndex int               `gorm:"type:integer;default:0" json:"break_index"`
+	BreakType  string            `gorm:"type:varchar(16)" json:"break_type,omitempty"`
+	Reason     string            `gorm:"type:varchar(500);not null" json:"reason"`
+	Status     string            `gorm:"type:varchar(16);index;not null" json:"status"`
+	UserID     *uint             `gorm:"type:integer" json:"user_id,omitempty"`
+	DeviceID   *uint             `gorm:"type:integer" json:"device_id,omitempty"`
+	PunchID    *uint             `gorm:"type:integer" json:"punch_id,omitempty"`
+	History    CorrectionHistory `gorm:"foreignKey:CorrectionRequestID" json:"history"`
+	CreatedAt  time.Time         `json:"created_at"`
+	UpdatedAt  time.Time         `json:"updated_at"`
+}
+
+func (CorrectionRequest) TableName() string {
+	return "correction_requests"
+}
+
+type CorrectionRequests []CorrectionRequest
+
+// BeforeCreate assigns a unique id to new correction requests.
+func (request *CorrectionRequest) BeforeCreate(tx *gorm.DB) error {
+	if rnd.IsUID(request.UID, CorrectionRequestUID) {
+		return nil
+	}
+
+	request.UID = rnd.GenerateUID(CorrectionRequestUID)
+
+	return nil
+}
+
+// Pending tests if the request has not been approved or rejected yet.
+func (request *CorrectionRequest) Pending() bool {
+	return request.Status == CorrectionPending
+}
+
+// FiledBy tests if the request was filed by the user or device. Zero ids
+// never match.
+func (request *CorrectionRequest) FiledBy(userID, deviceID uint) bool {
+	if userID != 0 && request.UserID != nil && *request.UserID == userID {
+		return true
+	}
+
+	return deviceID != 0 && request.DeviceID != nil && *request.DeviceID == deviceID
+}
+
+// In converts the times of the request to the location.
+func (request *CorrectionRequest) In(loc *time.Location) *CorrectionRequest {
+	request.Time = request.Time.In(loc)
+
+	for i := range request.History {
+		request.History[i].CreatedAt = request.History[i].CreatedAt.In(loc)
+	}
+
+	return request
+}
+
+// CorrectionStatus records a change of status of a correction request, and
+// the user or device that made it.
+type CorrectionStatus struct {
+	ID                  uint      `gorm:"primary_key" json:"-"`
+	CorrectionRequestID uint      `gorm:"type:integer;index;not null" json:"-"`
+	Status              string    `gorm:"type:varchar(16);not null" json:"status"`
+	Note                string    `gorm:"type:varchar(500)" json:"note,omitempty"`
+	UserID              *uint     `gorm:"type:integer" json:"user_id,omitempty"`
+	DeviceID            *uint     `gorm:"type:integer" json:"device_id,omitempty"`
+	CreatedAt           time.Time `json:"created_at"`
+}
+
+func (CorrectionStatus) TableName() string {
+	return "correction_statuses"
+}
+
+type CorrectionHistory []CorrectionStatus
+
+// FindCorrectionRequest returns the correction request with its history.
+func FindCorrectionRequest(uid string) (*CorrectionRequest, error) {
+	request := CorrectionRequest{}
+
+	if err := db.Db().Preload("History", func(tx *gorm.DB) *gorm.DB {
+		return tx.Order("id")
+	}).Where("uid = ?", uid).First(&request).Error; err != nil {
+		return nil, err
+	}
+
+	return &request, nil
+}
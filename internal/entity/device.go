@@ -0,0 +1,119 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/alexanderbkl/vidre-back/internal/db"
+	"github.com/alexanderbkl/vidre-back/pkg/rnd"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const DeviceUID = byte('d')
+
+// DeviceSecretLength is the number of characters of generated device secrets.
+const DeviceSecretLength = 32
+
+// Device represents an enrolled clocking terminal. Device tokens issued
+// before RevokedAt are no longer accepted.
+type Device struct {
+	ID         uint           `gorm:"primary_key" json:"id"`
+	UID        string         `gorm:"type:varchar(42);uniqueIndex" json:"uid"`
+	Name       string         `gorm:"type:varchar(255);not null" json:"name"`
+	Site       string         `gorm:"type:varchar(255)" json:"site"`
+	SecretHash string         `gorm:"type:varchar(255)" json:"-"`
+	LastSeenAt *time.Time     `json:"last_seen_at"`
+	RevokedAt  *time.Time     `json:"revoked_at"`
+	Enabled    bool           `gorm:"type:boolean;not null;default:true" json:"enabled"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+}
+
+func (Device) TableName() string {
+	return "devices"
+}
+
+type Devices []Device
+
+// BeforeCreate assigns a unique id to new devices.
+func (device *Device) BeforeCreate(tx *gorm.DB) error {
+	if rnd.IsUID(device.UID, DeviceUID) {
+		return nil
+	}
+
+	device.UID = rnd.GenerateUID(DeviceUID)
+
+	return nil
+}
+
+// NewSecret generates a new enrollment secret and stores its hash.
+// The plain secret is returned once and never stored.
+func (device *Device) NewSecret() (string, error) {
+	secret := rnd.GenerateRandomString(DeviceSecretLength)
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+
+	device.SecretHash = string(hash)
+
+	return secret, nil
+}
+
+// RevokeTokens revokes all device tokens issued so far, e.g. when the secret
+// of a stolen device is reset.
+func (device *Device) RevokeTokens() {
+	// Round up to the precision of the database.
+	now := time.Now().UTC().Truncate(time.Microsecond).Add(time.Microsecond)
+	device.RevokedAt = &now
+}
+
+// Revoked returns true if a device token issued at the given time has been
+// revoked.
+func (device *Device) Revoked(issuedAt time.Time) bool {
+	return device.RevokedAt != nil && !issuedAt.After(*device.RevokedAt)
+}
+
+// CheckSecret returns true if the secret matches the stored hash.
+func (device *Device) CheckSecret(secret string) bool {
+	if device.SecretHash == "" {
+		return false
+	}
+
+	return bcrypt.CompareHashAndPassword([]byte(device.SecretHash), []byte(secret)) == nil
+}
+
+// Seen updates the last seen timestamp.
+func (device *Device) Seen() error {
+	now := time.Now().UTC()
+	device.LastSeenAt = &now
+	return db.Db().Model(&Device{}).Where("id = ?", device.ID).UpdateColumn("last_seen_at", now).Error
+}
+
+func (device *Device) Create() error {
+	return db.Db().Create(device).Error
+}
+
+func (device *Device) Save() error {
+	return db.Db().Save(device).Error
+}
+
+// FindDeviceByUID returns the device with the given unique id.
+func FindDeviceByUID(uid string) (*Device, error) {
+	var device Device
+	if err := db.Db().Where("uid = ?", uid).First(&device).Error; err != nil {
+		return nil, err
+	}
+	return &device, nil
+}
+
+// FindDeviceByID returns the device with the given id.
+func FindDeviceByID(id uint) (*Device, error) {
+	var device Device
+	if err := db.Db().First(&device, id).Error; err != nil {
+		return nil, err
+	}
+	return &device, nil
+}
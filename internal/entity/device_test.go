@@ -0,0 +1,20 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeviceRevokeTokens(t *testing.T) {
+	var device Device
+
+	issuedAt := time.Now()
+	require.False(t, device.Revoked(issuedAt))
+
+	device.RevokeTokens()
+	require.True(t, device.Revoked(issuedAt))
+	require.True(t, device.Revoked(device.RevokedAt.Add(-time.Nanosecond)))
+	require.False(t, device.Revoked(device.RevokedAt.Add(time.Second)))
+}
@@ -12,11 +12,28 @@ type Tables map[string]interface{}
 
 // Entities contains database entities and their table names.
 var Entities = Tables{
-	Error{}.TableName():        &Error{},
-	Worker{}.TableName():       &Worker{},
-	ExtraHour{}.TableName():    &ExtraHour{},
-	WorkSchedule{}.TableName(): &WorkSchedule{},
-	Festivo{}.TableName():      &Festivo{},
+	Error{}.TableName():             &Error{},
+	Worker{}.TableName():            &Worker{},
+	ExtraHour{}.TableName():         &ExtraHour{},
+	WorkSchedule{}.TableName():      &WorkSchedule{},
+	WorkBreak{}.TableName():         &WorkBreak{},
+	Festivo{}.TableName():           &Festivo{},
+	User{}.TableName():              &User{},
+	Session{}.TableName():           &Session{},
+	Device{}.TableName():            &Device{},
+	Punch{}.TableName():             &Punch{},
+	PinAttempt{}.TableName():        &PinAttempt{},
+	SigningKey{}.TableName():        &SigningKey{},
+	LoginNonce{}.TableName():        &LoginNonce{},
+	RecoveryCode{}.TableName():      &RecoveryCode{},
+	RolePolicy{}.TableName():        &RolePolicy{},
+	ApiKey{}.TableName():            &ApiKey{},
+	OidcState{}.TableName():         &OidcState{},
+	IdempotencyKey{}.TableName():    &IdempotencyKey{},
+	CorrectionRequest{}.TableName(): &CorrectionRequest{},
+	CorrectionStatus{}.TableName():  &CorrectionStatus{},
+	AuditLog{}.TableName():          &AuditLog{},
+	Geofence{}.TableName():          &Geofence{},
 }
 
 // WaitForMigration waits for the database migration to be successful.
@@ -85,9 +102,6 @@ func (list Tables) Migrate(db *gorm.DB, opt migrate.Options) {
 	// Run ORM auto migrations.
 	if opt.AutoMigrate {
 		for name, entity = range list {
-			if (name == "users" || name == "wallets" || name == "githubs") {
-				continue
-			}
 			log.Infof("migrate: migrating %s", name)
 			if err := db.AutoMigrate(entity); err != nil {
 				log.Debugf("migrate: %s (waiting 1s)", err)
@@ -0,0 +1,134 @@
+package entity
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/alexanderbkl/vidre-back/internal/db"
+	"github.com/alexanderbkl/vidre-back/pkg/geo"
+	"gorm.io/gorm"
+)
+
+// Geofence kinds.
+const (
+	GeofenceCircle  = "circle"
+	GeofencePolygon = "polygon"
+)
+
+var ErrInvalidGeofenceKind = errors.New("geofence kind must be circle or polygon")
+
+// Geofence is an area of a site from which workers may punch. Circles are
+// given by their center and radius in meters, polygons by their points.
+// Geofences without site apply to the devices of all sites.
+type Geofence struct {
+	ID        uint           `gorm:"primary_key" json:"id"`
+	Site      string         `gorm:"type:varchar(255);index" json:"site"`
+	Name      string         `gorm:"type:varchar(255);not null" json:"name"`
+	Kind      string         `gorm:"type:varchar(16);not null" json:"kind"`
+	Latitude  float64        `gorm:"type:double precision" json:"latitude,omitempty"`
+	Longitude float64        `gorm:"type:double precision" json:"longitude,omitempty"`
+	Radius    float64        `gorm:"type:double precision" json:"radius,omitempty"`
+	Points    geo.Polygon    `gorm:"type:jsonb;serializer:json" json:"points,omitempty"`
+	Enabled   bool           `gorm:"type:boolean;not null;default:true" json:"enabled"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+}
+
+func (Geofence) TableName() string {
+	return "geofences"
+}
+
+type Geofences []Geofence
+
+// Shape returns the area of the geofence, or nil if its kind is unknown.
+func (fence *Geofence) Shape() geo.Shape {
+	switch fence.Kind {
+	case GeofenceCircle:
+		return geo.Circle{Center: geo.Point{Lat: fence.Latitude, Lng: fence.Longitude}, Radius: fence.Radius}
+	case GeofencePolygon:
+		return fence.Points
+	default:
+		return nil
+	}
+}
+
+// Validate checks the kind and area of the geofence.
+func (fence *Geofence) Validate() error {
+	shape := fence.Shape()
+	if shape == nil {
+		return ErrInvalidGeofenceKind
+	}
+
+	return shape.Validate()
+}
+
+func (fence *Geofence) Create() error {
+	return db.Db().Create(fence).Error
+}
+
+func (fence *Geofence) Save() error {
+	return db.Db().Save(fence).Error
+}
+
+// Active tests if any of the geofences has an area, so that punches can be
+// checked against them.
+func (fences Geofences) Active() bool {
+	for i := range fences {
+		if fences[i].Shape() != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Locate returns the nearest geofence to the point and the distance to it in
+// meters. The point is inside the geofence if the distance is within the
+// accuracy of the location, unless the accuracy is worse than maxAccuracy.
+// The geofence is nil if there are none.
+func (fences Geofences) Locate(p geo.Point, accuracy, maxAccuracy float64) (fence *Geofence, distance float64, inside bool) {
+	distance = math.Inf(1)
+
+	for i := range fences {
+		shape := fences[i].Shape()
+		if shape == nil {
+			continue
+		}
+
+		if d := shape.Distance(p); d < distance {
+			fence, distance = &fences[i], d
+		}
+	}
+
+	if fence == nil {
+		return nil, 0, false
+	}
+
+	return fence, distance, distance <= accuracy && accuracy <= maxAccuracy
+}
+
+// FindGeofence returns the geofence with the given id.
+func FindGeofence(id uint) (*Geofence, error) {
+	var fence Geofence
+	if err := db.Db().First(&fence, id).Error; err != nil {
+		return nil, err
+	}
+	return &fence, nil
+}
+
+// FindGeofences returns the enabled geofences that apply to a site: its own
+// and those without site. All enabled geofences apply to an empty site.
+func FindGeofences(site string) (Geofences, error) {
+	var fences Geofences
+
+	q := db.Db().Where("enabled = ?", true)
+	if site != "" {
+		q = q.Where("site = ? OR site = ''", site)
+	}
+
+	err := q.Order("id").Find(&fences).Error
+
+	return fences, err
+}
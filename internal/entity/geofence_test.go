@@ -0,0 +1,69 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/alexanderbkl/vidre-back/pkg/geo"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeofenceValidate(t *testing.T) {
+	testCases := []struct {
+		name     string
+		fence    Geofence
+		expected error
+	}{
+		{name: "Circle", fence: Geofence{Kind: GeofenceCircle, Latitude: 40.4, Longitude: -3.7, Radius: 100}},
+		{name: "CircleWithoutRadius", fence: Geofence{Kind: GeofenceCircle, Latitude: 40.4, Longitude: -3.7}, expected: geo.ErrInvalidRadius},
+		{name: "Polygon", fence: Geofence{Kind: GeofencePolygon, Points: geo.Polygon{{Lat: 0, Lng: 0}, {Lat: 0, Lng: 1}, {Lat: 1, Lng: 1}}}},
+		{name: "PolygonWithoutPoints", fence: Geofence{Kind: GeofencePolygon}, expected: geo.ErrInvalidPolygon},
+		{name: "UnknownKind", fence: Geofence{Kind: "square"}, expected: ErrInvalidGeofenceKind},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, tc.fence.Validate())
+		})
+	}
+}
+
+func TestGeofencesLocate(t *testing.T) {
+	fences := Geofences{
+		{ID: 1, Kind: GeofenceCircle, Latitude: 0, Longitude: 0, Radius: 100},
+		{ID: 2, Kind: GeofencePolygon, Points: geo.Polygon{{Lat: 0.01, Lng: 0.01}, {Lat: 0.01, Lng: 0.02}, {Lat: 0.02, Lng: 0.02}, {Lat: 0.02, Lng: 0.01}}},
+	}
+
+	testCases := []struct {
+		name     string
+		point    geo.Point
+		accuracy float64
+		id       uint
+		distance float64
+		inside   bool
+	}{
+		{name: "InsideCircle", point: geo.Point{Lat: 0.0005}, id: 1, inside: true},
+		{name: "InsidePolygon", point: geo.Point{Lat: 0.015, Lng: 0.015}, accuracy: 10, id: 2, inside: true},
+		{name: "Outside", point: geo.Point{Lat: 0.0015}, id: 1, distance: 66.8},
+		{name: "WithinAccuracy", point: geo.Point{Lat: 0.0015}, accuracy: 70, id: 1, distance: 66.8, inside: true},
+		{name: "PoorAccuracy", point: geo.Point{Lat: 0.0005}, accuracy: 500, id: 1},
+		{name: "NearestPolygon", point: geo.Point{Lat: 0.015, Lng: 0.021}, id: 2, distance: 111.2},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			fence, distance, inside := fences.Locate(tc.point, tc.accuracy, 100)
+			require.NotNil(t, fence)
+			require.Equal(t, tc.id, fence.ID)
+			require.InDelta(t, tc.distance, distance, 0.5)
+			require.Equal(t, tc.inside, inside)
+		})
+	}
+
+	fence, _, inside := Geofences{}.Locate(geo.Point{}, 0, 100)
+	require.Nil(t, fence)
+	require.False(t, inside)
+}
@@ -0,0 +1,83 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/alexanderbkl/vidre-back/internal/db"
+	"gorm.io/gorm/clause"
+)
+
+// IdempotencyKeyMaxLength is the maximum length of an idempotency key.
+const IdempotencyKeyMaxLength = 255
+
+// IdempotencyKey stores the response of a request sent with an
+// Idempotency-Key header, so that it can be replayed when the client retries
+// the request. Keys are unique per client, i.e. device, user or api key.
+// A key without completion time belongs to a request that is in progress.
+type IdempotencyKey struct {
+	ID           uint       `gorm:"primary_key" json:"id"`
+	Client       string     `gorm:"type:varchar(64);not null;uniqueIndex:idx_idempotency_keys_client_key" json:"client"`
+	Key          string     `gorm:"type:varchar(255);not null;uniqueIndex:idx_idempotency_keys_client_key" json:"key"`
+	Method       string     `gorm:"type:varchar(16);not null" json:"method"`
+	Path         string     `gorm:"type:varchar(255);not null" json:"path"`
+	RequestHash  string     `gorm:"type:varchar(64);not null" json:"-"`
+	StatusCode   int        `gorm:"type:integer" json:"status_code"`
+	ContentType  string     `gorm:"type:varchar(255)" json:"content_type"`
+	ResponseBody []byte     `json:"-"`
+	CompletedAt  *time.Time `json:"completed_at"`
+	ExpiresAt    time.Time  `gorm:"index" json:"expires_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+func (IdempotencyKey) TableName() string {
+	return "idempotency_keys"
+}
+
+// Completed tests if the response of the request has been stored.
+func (k *IdempotencyKey) Completed() bool {
+	return k.CompletedAt != nil
+}
+
+// ReserveIdempotencyKey stores the key for a new request. It returns false
+// and the stored key if the client has already used the key before.
+// Expired keys are removed.
+func ReserveIdempotencyKey(key *IdempotencyKey, ttl time.Duration) (*IdempotencyKey, bool, error) {
+	now := time.Now().UTC()
+
+	if err := db.Db().Where("expires_at < ?", now).Delete(&IdempotencyKey{}).Error; err != nil {
+		log.Debugf("entity: cannot remove expired idempotency keys (%s)", err)
+	}
+
+	key.ExpiresAt = now.Add(ttl)
+
+	result := db.Db().Clauses(clause.OnConflict{DoNothing: true}).Create(key)
+	if result.Error != nil {
+		return nil, false, result.Error
+	} else if result.RowsAffected == 1 {
+		return key, true, nil
+	}
+
+	var existing IdempotencyKey
+	if err := db.Db().Where("client = ? AND key = ?", key.Client, key.Key).First(&existing).Error; err != nil {
+		return nil, false, err
+	}
+
+	return &existing, false, nil
+}
+
+// Complete stores the response of the request.
+func (k *IdempotencyKey) Complete(status int, contentType string, body []byte) error {
+	now := time.Now().UTC()
+
+	k.StatusCode = status
+	k.ContentType = contentType
+	k.ResponseBody = body
+	k.CompletedAt = &now
+
+	return db.Db().Model(k).Select("status_code", "content_type", "response_body", "completed_at").Updates(k).Error
+}
+
+// Release removes the key, so that the request can be sent again.
+func (k *IdempotencyKey) Release() error {
+	return db.Db().Delete(k).Error
+}
@@ -0,0 +1,62 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/alexanderbkl/vidre-back/internal/db"
+	"github.com/alexanderbkl/vidre-back/pkg/rnd"
+)
+
+// LoginNonceLength is the number of characters of a login nonce.
+const LoginNonceLength = 32
+
+// LoginNonceTTL is how long a login nonce can be used.
+const LoginNonceTTL = 5 * time.Minute
+
+// LoginNonce is a single-use value that a wallet signs to log in.
+type LoginNonce struct {
+	Nonce     string     `gorm:"type:varchar(64);primary_key" json:"nonce"`
+	ExpiresAt time.Time  `gorm:"index" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (LoginNonce) TableName() string {
+	return "login_nonces"
+}
+
+// NewLoginNonce creates and stores a new nonce. Expired nonces are removed.
+func NewLoginNonce() (*LoginNonce, error) {
+	now := time.Now().UTC()
+
+	if err := db.Db().Where("expires_at < ?", now.Add(-time.Hour)).Delete(&LoginNonce{}).Error; err != nil {
+		log.Debugf("entity: cannot remove expired login nonces (%s)", err)
+	}
+
+	nonce := &LoginNonce{
+		Nonce:     rnd.GenerateRandomString(LoginNonceLength),
+		ExpiresAt: now.Add(LoginNonceTTL),
+	}
+
+	if err := db.Db().Create(nonce).Error; err != nil {
+		return nil, err
+	}
+
+	return nonce, nil
+}
+
+// UseLoginNonce marks the nonce as used. It returns false if the nonce
+// does not exist, has expired or has already been used.
+func UseLoginNonce(nonce string) (bool, error) {
+	now := time.Now().UTC()
+
+	result := db.Db().Model(&LoginNonce{}).
+		Where("nonce = ? AND used_at IS NULL AND expires_at > ?", nonce, now).
+		Update("used_at", now)
+
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected == 1, nil
+}
@@ -0,0 +1,73 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/alexanderbkl/vidre-back/internal/db"
+	"github.com/alexanderbkl/vidre-back/pkg/oidc"
+	"github.com/alexanderbkl/vidre-back/pkg/rnd"
+)
+
+// OidcStateLength is the number of characters of the state and nonce.
+const OidcStateLength = 32
+
+// OidcStateTTL is how long a user can take to log in at the identity provider.
+const OidcStateTTL = 10 * time.Minute
+
+// OidcState is a pending OpenID Connect login.
+type OidcState struct {
+	State        string    `gorm:"type:varchar(64);primary_key" json:"state"`
+	Nonce        string    `gorm:"type:varchar(64);not null" json:"-"`
+	CodeVerifier string    `gorm:"type:varchar(128);not null" json:"-"`
+	ExpiresAt    time.Time `gorm:"index" json:"expires_at"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (OidcState) TableName() string {
+	return "oidc_states"
+}
+
+// NewOidcState creates and stores a new login state. Expired states are removed.
+func NewOidcState() (*OidcState, error) {
+	now := time.Now().UTC()
+
+	if err := db.Db().Where("expires_at < ?", now).Delete(&OidcState{}).Error; err != nil {
+		log.Debugf("entity: cannot remove expired oidc states (%s)", err)
+	}
+
+	state := &OidcState{
+		State:        rnd.GenerateRandomString(OidcStateLength),
+		Nonce:        rnd.GenerateRandomString(OidcStateLength),
+		CodeVerifier: oidc.NewCodeVerifier(),
+		ExpiresAt:    now.Add(OidcStateTTL),
+	}
+
+	if err := db.Db().Create(state).Error; err != nil {
+		return nil, err
+	}
+
+	return state, nil
+}
+
+// UseOidcState removes and returns the state. It returns false if the state
+// does not exist, has expired or has already been used.
+func UseOidcState(s string) (*OidcState, bool, error) {
+	var state OidcState
+
+	if err := db.Db().Where("state = ?", s).Limit(1).Find(&state).Error; err != nil {
+		return nil, false, err
+	} else if state.State == "" {
+		return nil, false, nil
+	}
+
+	result := db.Db().Where("state = ?", s).Delete(&OidcState{})
+	if result.Error != nil {
+		return nil, false, result.Error
+	}
+
+	if result.RowsAffected != 1 || time.Now().After(state.ExpiresAt) {
+		return nil, false, nil
+	}
+
+	return &state, true, nil
+}
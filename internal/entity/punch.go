@@ -0,0 +1,153 @@
+package entity
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Punch types submitted by workers at a clock, see clock.Apply.
+const (
+	PunchEntry     = "entry"
+	PunchStartRest = "startRest"
+	PunchEndRest   = "endRest"
+	PunchExit      = "exit"
+)
+
+// Punch types of corrections, which set a single time of the work day.
+// Break corrections refer to the break at BreakIndex.
+const (
+	PunchSetEntry      = "enterHour"
+	PunchSetBreakStart = "startBreakHour"
+	PunchSetBreakEnd   = "endBreakHour"
+	PunchDeleteBreak   = "deleteBreak"
+	PunchSetExit       = "exitHour"
+)
+
+// Punch types of corrections from before breaks were a collection. They
+// refer to the first (breakfast) and second (lunch) break of the day.
+const (
+	PunchSetBreakfastStart = "startBreakfastHour"
+	PunchSetBreakfastEnd   = "endBreakfastHour"
+	PunchSetLunchStart     = "startLunchHour"
+	PunchSetLunchEnd       = "endLunchHour"
+)
+
+// Punch types that clear all times of the work day. After PunchDelete the
+// work day is removed until a later punch is recorded for it.
+const (
+	PunchReset  = "reset"
+	PunchDelete = "delete"
+)
+
+// Punch sources.
+const (
+	PunchSourceClock     = "clock"
+	PunchSourceManual    = "manual"
+	PunchSourceMigration = "migration"
+	PunchSourceImport    = "import"
+)
+
+// Review flags of punches synced by offline devices.
+const (
+	// PunchReviewOutOfOrder flags punches that arrived after later punches.
+	PunchReviewOutOfOrder = "out_of_order"
+	// PunchReviewConflict flags punches that the work day rejected.
+	PunchReviewConflict = "conflict"
+	// PunchReviewOutsideGeofence flags punches rejected by the geofence policy.
+	PunchReviewOutsideGeofence = "outside_geofence"
+)
+
+// Geofence statuses of punches, see Geofences.Locate. Punches without a
+// location are outside if their site has geofences.
+const (
+	PunchInsideGeofence  = "inside"
+	PunchOutsideGeofence = "outside"
+)
+
+var ErrPunchImmutable = errors.New("punches cannot be changed or deleted")
+
+// Punch records a single clocking or correction for a worker. Punches are
+// append-only: work schedules are projections of the punches of their day.
+//
+// Punches that a device queued while offline are numbered with a device
+// sequence. Those that could not be replayed in order are flagged for review
+// and left out of the work day.
+//
+// Punches from phones may carry the location of the worker. If geofences are
+// defined for the site, the nearest one and the distance to it are recorded.
+type Punch struct {
+	ID               uint      `gorm:"primary_key" json:"id"`
+	WorkerID         uint      `gorm:"type:integer;index:idx_punches_worker_date;not null" json:"worker_id"`
+	Date             time.Time `gorm:"type:date;index:idx_punches_worker_date" json:"date"`
+	WorkScheduleID   uint      `gorm:"type:integer;index" json:"work_schedule_id"`
+	Type             string    `gorm:"type:varchar(32);not null" json:"type"`
+	Time             time.Time `gorm:"not null" json:"time"`
+	BreakType        string    `gorm:"type:varchar(16)" json:"break_type,omitempty"`
+	BreakIndex       int       `gorm:"type:integer;not null;default:0" json:"break_index"`
+	Source           string    `gorm:"type:varchar(32);not null;default:clock" json:"source"`
+	DeviceID         *uint     `gorm:"type:integer;index;uniqueIndex:idx_punches_device_sequence,priority:1" json:"device_id"`
+	Device           *Device   `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"device,omitempty"`
+	DeviceSequence   *int64    `gorm:"type:bigint;uniqueIndex:idx_punches_device_sequence,priority:2" json:"device_sequence,omitempty"`
+	UserID           *uint     `gorm:"type:integer" json:"user_id"`
+	Message          string    `gorm:"type:varchar(255)" json:"message"`
+	Review           string    `gorm:"type:varchar(32)" json:"review,omitempty"`
+	Latitude         *float64  `gorm:"type:double precision" json:"latitude,omitempty"`
+	Longitude        *float64  `gorm:"type:double precision" json:"longitude,omitempty"`
+	Accuracy         *float64  `gorm:"type:double precision" json:"accuracy,omitempty"`
+	GeofenceID       *uint     `gorm:"type:integer;index" json:"geofence_id,omitempty"`
+	Geofence         *Geofence `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"geofence,omitempty"`
+	GeofenceDistance *float64  `gorm:"type:double precision" json:"geofence_distance,omitempty"`
+	GeofenceStatus   string    `gorm:"type:varchar(16);index" json:"geofence_status,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+func (Punch) TableName() string {
+	return "punches"
+}
+
+type Punches []Punch
+
+// BeforeUpdate prevents punches from being changed.
+func (punch *Punch) BeforeUpdate(tx *gorm.DB) error {
+	return ErrPunchImmutable
+}
+
+// BeforeDelete prevents punches from being deleted.
+func (punch *Punch) BeforeDelete(tx *gorm.DB) error {
+	return ErrPunchImmutable
+}
+
+func (punch *Punch) Create(db *gorm.DB) error {
+	return db.Create(punch).Error
+}
+
+func (punch *Punch) TxCreate(tx *gorm.DB) error {
+	return tx.Create(punch).Error
+}
+
+// In converts the time of the punch to the location.
+func (punch *Punch) In(loc *time.Location) *Punch {
+	punch.Time = inLocation(punch.Time, loc)
+	return punch
+}
+
+// FindDayPunches returns the punches of a worker on a date in the order they were recorded.
+func FindDayPunches(db *gorm.DB, workerID uint, date time.Time) (Punches, error) {
+	var punches Punches
+	err := db.Where("worker_id = ? AND date = ?", workerID, date).Order("id").Find(&punches).Error
+	return punches, err
+}
+
+// FindDevicePunch returns the punch that a device synced with a sequence, or
+// nil if the device has not synced it yet.
+func FindDevicePunch(db *gorm.DB, deviceID uint, sequence int64) (*Punch, error) {
+	var punch Punch
+	if err := db.Where("device_id = ? AND device_sequence = ?", deviceID, sequence).Limit(1).Find(&punch).Error; err != nil {
+		return nil, err
+	} else if punch.ID == 0 {
+		return nil, nil
+	}
+	return &punch, nil
+}
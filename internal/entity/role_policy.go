@@ -0,0 +1,52 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/alexanderbkl/vidre-back/internal/constant"
+	"github.com/alexanderbkl/vidre-back/internal/db"
+)
+
+// RolePolicy contains the security requirements of a user role.
+type RolePolicy struct {
+	Role       string    `gorm:"type:varchar(32);primary_key" json:"role"`
+	RequireMFA bool      `gorm:"type:boolean;not null;default:false" json:"require_mfa"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func (RolePolicy) TableName() string {
+	return "role_policies"
+}
+
+type RolePolicies []RolePolicy
+
+func (policy *RolePolicy) Save() error {
+	return db.Db().Save(policy).Error
+}
+
+// FindRolePolicy returns the policy of the role, or the default policy if none is stored.
+func FindRolePolicy(role string) (*RolePolicy, error) {
+	policy := RolePolicy{Role: role}
+
+	if err := db.Db().Where("role = ?", role).Limit(1).Find(&policy).Error; err != nil {
+		return nil, err
+	}
+
+	return &policy, nil
+}
+
+// AllRolePolicies returns the policies of all roles.
+func AllRolePolicies() (RolePolicies, error) {
+	policies := make(RolePolicies, 0, len(constant.Roles))
+
+	for _, role := range constant.Roles {
+		policy, err := FindRolePolicy(role)
+		if err != nil {
+			return nil, err
+		}
+
+		policies = append(policies, *policy)
+	}
+
+	return policies, nil
+}
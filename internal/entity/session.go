@@ -0,0 +1,98 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/alexanderbkl/vidre-back/internal/db"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Session represents a login session and the refresh token currently issued for it.
+//
+// Refresh tokens are rotated on every renewal. Presenting a refresh token that
+// is not the current one means it was reused, and the session gets blocked.
+type Session struct {
+	ID             uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
+	UserID         uint      `gorm:"type:integer;index;not null" json:"user_id"`
+	User           User      `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	RefreshTokenID uuid.UUID `gorm:"type:uuid;not null" json:"-"`
+	UserAgent      string    `gorm:"type:varchar(512)" json:"user_agent"`
+	ClientIP       string    `gorm:"type:varchar(64)" json:"client_ip"`
+	IsBlocked      bool      `gorm:"type:boolean;not null;default:false" json:"is_blocked"`
+	ExpiresAt      time.Time `json:"expires_at"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+func (Session) TableName() string {
+	return "sessions"
+}
+
+type Sessions []Session
+
+func (session *Session) Create() error {
+	return db.Db().Create(session).Error
+}
+
+func (session *Session) TxCreate(tx *gorm.DB) error {
+	return tx.Create(session).Error
+}
+
+// Active returns true if the session is neither blocked nor expired.
+func (session *Session) Active() bool {
+	return !session.IsBlocked && time.Now().Before(session.ExpiresAt)
+}
+
+// Rotate replaces the current refresh token id if it still matches the given one.
+// It returns false if the token was already rotated or the session is blocked.
+func (session *Session) Rotate(currentID, nextID uuid.UUID, expiresAt time.Time) (bool, error) {
+	result := db.Db().Model(&Session{}).
+		Where("id = ? AND refresh_token_id = ? AND is_blocked = ?", session.ID, currentID, false).
+		Updates(map[string]interface{}{"refresh_token_id": nextID, "expires_at": expiresAt})
+
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+
+	session.RefreshTokenID = nextID
+	session.ExpiresAt = expiresAt
+
+	return true, nil
+}
+
+// Block revokes the session so that its refresh token can no longer be used.
+func (session *Session) Block() error {
+	session.IsBlocked = true
+	return db.Db().Model(&Session{}).Where("id = ?", session.ID).Update("is_blocked", true).Error
+}
+
+// FindSession returns the session with the given id.
+func FindSession(id uuid.UUID) (*Session, error) {
+	var session Session
+	if err := db.Db().Where("id = ?", id).First(&session).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// ActiveUserSessions returns the sessions of a user that are neither blocked nor expired.
+func ActiveUserSessions(userID uint) (Sessions, error) {
+	var sessions Sessions
+	err := db.Db().
+		Where("user_id = ? AND is_blocked = ? AND expires_at > ?", userID, false, time.Now()).
+		Order("created_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// BlockUserSessions revokes all sessions of a user, e.g. after a password change.
+func BlockUserSessions(userID uint) error {
+	return db.Db().Model(&Session{}).
+		Where("user_id = ? AND is_blocked = ?", userID, false).
+		Update("is_blocked", true).Error
+}
@@ -0,0 +1,224 @@
+package entity
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/alexanderbkl/vidre-back/internal/db"
+	"github.com/alexanderbkl/vidre-back/pkg/token"
+	"golang.org/x/crypto/nacl/secretbox"
+	"gorm.io/gorm"
+)
+
+// Signing key states.
+//
+// New keys start as pending so that all instances can verify their tokens
+// before the key is promoted to current and starts creating tokens.
+const (
+	KeyStatePending  = "pending"
+	KeyStateCurrent  = "current"
+	KeyStatePrevious = "previous"
+	KeyStateRetired  = "retired"
+)
+
+// sealedKeyPrefix marks key material encrypted with secretbox. Material
+// without prefix is hex encoded plaintext from before keys were encrypted.
+const sealedKeyPrefix = "sb1:"
+
+var ErrSigningKeyDecryption = errors.New("cannot decrypt signing key, check the key encryption key")
+
+// SigningKey represents a token key of the keyring, see token.Keyring.
+// The key material is encrypted with the key encryption key, so that the
+// database alone does not allow to create tokens.
+type SigningKey struct {
+	ID         string     `gorm:"type:varchar(64);primary_key" json:"id"`
+	Purpose    string     `gorm:"type:varchar(16);not null" json:"purpose"`
+	Material   string     `gorm:"type:varchar(255);not null" json:"-"`
+	State      string     `gorm:"type:varchar(16);index;not null" json:"state"`
+	PromotedAt *time.Time `json:"promoted_at"`
+	RetiredAt  *time.Time `json:"retired_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+func (SigningKey) TableName() string {
+	return "signing_keys"
+}
+
+type SigningKeys []SigningKey
+
+// NewSigningKey creates a signing key from a token key, encrypted with the
+// key encryption key.
+func NewSigningKey(key token.Key, state string, kek *[32]byte) (SigningKey, error) {
+	material, err := sealKey(key.Material, kek)
+	if err != nil {
+		return SigningKey{}, err
+	}
+
+	return SigningKey{
+		ID:       key.ID,
+		Purpose:  key.Purpose,
+		Material: material,
+		State:    state,
+	}, nil
+}
+
+// Sealed returns true if the key material is encrypted.
+func (key *SigningKey) Sealed() bool {
+	return strings.HasPrefix(key.Material, sealedKeyPrefix)
+}
+
+// TokenKey returns the decrypted token key.
+func (key *SigningKey) TokenKey(kek *[32]byte) (token.Key, error) {
+	material, err := openKey(key.Material, kek)
+	if err != nil {
+		return token.Key{}, fmt.Errorf("signing key %s: %w", key.ID, err)
+	}
+
+	return token.Key{ID: key.ID, Purpose: key.Purpose, Material: material}, nil
+}
+
+func (key *SigningKey) Create() error {
+	return db.Db().Create(key).Error
+}
+
+// AllSigningKeys returns all signing keys, oldest first.
+func AllSigningKeys() (SigningKeys, error) {
+	var keys SigningKeys
+	err := db.Db().Order("created_at").Find(&keys).Error
+	return keys, err
+}
+
+// Keyring returns the current key id and all keys that have not been retired.
+func (keys SigningKeys) Keyring(kek *[32]byte) (current string, result []token.Key, err error) {
+	for _, key := range keys {
+		if key.State == KeyStateRetired {
+			continue
+		}
+
+		k, err := key.TokenKey(kek)
+		if err != nil {
+			return "", nil, err
+		}
+
+		if key.State == KeyStateCurrent {
+			current = key.ID
+		}
+
+		result = append(result, k)
+	}
+
+	return current, result, nil
+}
+
+// SealSigningKeys encrypts the material of keys stored before keys were
+// encrypted.
+func SealSigningKeys(kek *[32]byte) error {
+	keys, err := AllSigningKeys()
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		if key.Sealed() {
+			continue
+		}
+
+		k, err := key.TokenKey(kek)
+		if err != nil {
+			return err
+		}
+
+		material, err := sealKey(k.Material, kek)
+		if err != nil {
+			return err
+		}
+
+		if err := db.Db().Model(&SigningKey{}).Where("id = ? AND material = ?", key.ID, key.Material).
+			Update("material", material).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// PromoteSigningKey makes the key the current key. The previous current key
+// keeps verifying tokens until it is retired.
+func PromoteSigningKey(id string) error {
+	return db.Db().Transaction(func(tx *gorm.DB) error {
+		var key SigningKey
+		if err := tx.Where("id = ?", id).First(&key).Error; err != nil {
+			return err
+		}
+
+		if key.State != KeyStatePending && key.State != KeyStatePrevious {
+			return fmt.Errorf("signing key %s is %s", key.ID, key.State)
+		}
+
+		if err := tx.Model(&SigningKey{}).Where("state = ?", KeyStateCurrent).
+			Update("state", KeyStatePrevious).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&key).Updates(map[string]interface{}{
+			"state":       KeyStateCurrent,
+			"promoted_at": time.Now().UTC(),
+		}).Error
+	})
+}
+
+// RetireSigningKey stops the key from verifying tokens.
+func RetireSigningKey(id string) error {
+	var key SigningKey
+	if err := db.Db().Where("id = ?", id).First(&key).Error; err != nil {
+		return err
+	}
+
+	if key.State == KeyStateCurrent {
+		return fmt.Errorf("signing key %s is current, promote another key first", key.ID)
+	}
+
+	return db.Db().Model(&key).Updates(map[string]interface{}{
+		"state":      KeyStateRetired,
+		"retired_at": time.Now().UTC(),
+	}).Error
+}
+
+// sealKey encrypts key material with a random nonce.
+func sealKey(material []byte, kek *[32]byte) (string, error) {
+	var nonce [24]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return "", err
+	}
+
+	return sealedKeyPrefix + hex.EncodeToString(secretbox.Seal(nonce[:], material, &nonce, kek)), nil
+}
+
+// openKey decrypts key material, or decodes it if it is not encrypted.
+func openKey(s string, kek *[32]byte) ([]byte, error) {
+	if !strings.HasPrefix(s, sealedKeyPrefix) {
+		return hex.DecodeString(s)
+	}
+
+	box, err := hex.DecodeString(strings.TrimPrefix(s, sealedKeyPrefix))
+	if err != nil {
+		return nil, err
+	} else if len(box) < 24 {
+		return nil, ErrSigningKeyDecryption
+	}
+
+	var nonce [24]byte
+	copy(nonce[:], box)
+
+	material, ok := secretbox.Open(nil, box[24:], &nonce, kek)
+	if !ok {
+		return nil, ErrSigningKeyDecryption
+	}
+
+	return material, nil
+}
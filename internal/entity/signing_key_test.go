@@ -0,0 +1,49 @@
+package entity
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/alexanderbkl/vidre-back/pkg/rnd"
+	"github.com/alexanderbkl/vidre-back/pkg/token"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSigningKeyEncryption(t *testing.T) {
+	var kek, other [32]byte
+	copy(kek[:], rnd.GenerateRandomString(32))
+	copy(other[:], rnd.GenerateRandomString(32))
+
+	material := []byte(rnd.GenerateRandomString(32))
+
+	key, err := NewSigningKey(token.Key{ID: "k1", Purpose: token.PurposeLocal, Material: material}, KeyStatePending, &kek)
+	require.NoError(t, err)
+	require.True(t, key.Sealed())
+	require.NotContains(t, key.Material, hex.EncodeToString(material))
+	require.LessOrEqual(t, len(key.Material), 255)
+
+	k, err := key.TokenKey(&kek)
+	require.NoError(t, err)
+	require.Equal(t, material, k.Material)
+
+	_, err = key.TokenKey(&other)
+	require.ErrorIs(t, err, ErrSigningKeyDecryption)
+
+	// Keys stored before keys were encrypted are still readable.
+	legacy := SigningKey{ID: "k0", Purpose: token.PurposeLocal, Material: hex.EncodeToString(material)}
+	require.False(t, legacy.Sealed())
+
+	k, err = legacy.TokenKey(&kek)
+	require.NoError(t, err)
+	require.Equal(t, material, k.Material)
+
+	// The largest keys fit into the column.
+	public, err := token.GenerateKey("k2", token.PurposePublic)
+	require.NoError(t, err)
+
+	key, err = NewSigningKey(public, KeyStatePending, &kek)
+	require.NoError(t, err)
+	require.LessOrEqual(t, len(key.Material), 255)
+	require.True(t, strings.HasPrefix(key.Material, sealedKeyPrefix))
+}
@@ -0,0 +1,142 @@
+package entity
+
+import (
+	"errors"
+	"time"
+
+	"github.com/alexanderbkl/vidre-back/internal/constant"
+	"github.com/alexanderbkl/vidre-back/internal/db"
+	"github.com/alexanderbkl/vidre-back/pkg/rnd"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const UserUID = byte('u')
+
+// PasswordMinLength is the minimum number of characters of a user password.
+const PasswordMinLength = 8
+
+var ErrPasswordTooShort = errors.New("password is too short")
+
+// User represents an account that can log in to the API.
+type User struct {
+	ID           uint   `gorm:"primary_key" json:"id"`
+	UID          string `gorm:"type:varchar(42);uniqueIndex" json:"uid"`
+	Name         string `gorm:"type:varchar(255);uniqueIndex;not null" json:"name"`
+	PasswordHash string `gorm:"type:varchar(255)" json:"-"`
+	Role         string `gorm:"type:varchar(32);not null;default:worker" json:"role"`
+	// WalletAddress is the lower case Ethereum address linked to the user, if any.
+	WalletAddress *string `gorm:"type:varchar(42);uniqueIndex" json:"wallet_address"`
+	// OidcSubject is the subject of the identity provider account linked to the user, if any.
+	OidcSubject *string `gorm:"type:varchar(255);uniqueIndex" json:"-"`
+	// TotpSecret is the base32 TOTP secret, set during enrollment before TotpEnabled.
+	TotpSecret      string         `gorm:"type:varchar(64)" json:"-"`
+	TotpEnabled     bool           `gorm:"type:boolean;default:false" json:"totp_enabled"`
+	TotpLastCounter int64          `gorm:"type:bigint;default:0" json:"-"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+}
+
+func (User) TableName() string {
+	return "users"
+}
+
+type Users []User
+
+// BeforeCreate assigns a unique id and the default role to new users.
+func (user *User) BeforeCreate(tx *gorm.DB) error {
+	if user.Role == "" {
+		user.Role = constant.RoleWorker
+	}
+
+	if rnd.IsUID(user.UID, UserUID) {
+		return nil
+	}
+
+	user.UID = rnd.GenerateUID(UserUID)
+
+	return nil
+}
+
+// SetPassword stores the bcrypt hash of the given password.
+func (user *User) SetPassword(password string) error {
+	if len(password) < PasswordMinLength {
+		return ErrPasswordTooShort
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	user.PasswordHash = string(hash)
+
+	return nil
+}
+
+// CheckPassword returns true if the password matches the stored hash.
+func (user *User) CheckPassword(password string) bool {
+	if user.PasswordHash == "" {
+		return false
+	}
+
+	return bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) == nil
+}
+
+func (user *User) Create() error {
+	return db.Db().Create(user).Error
+}
+
+func (user *User) TxCreate(tx *gorm.DB) error {
+	return tx.Create(user).Error
+}
+
+func (user *User) Save() error {
+	return db.Db().Session(&gorm.Session{FullSaveAssociations: true}).Save(user).Error
+}
+
+// FindUserByName returns the user with the given name.
+func FindUserByName(name string) (*User, error) {
+	var user User
+	if err := db.Db().Where("name = ?", name).First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// FindUserByID returns the user with the given id.
+func FindUserByID(id uint) (*User, error) {
+	var user User
+	if err := db.Db().First(&user, id).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// FindUserByUID returns the user with the given unique id.
+func FindUserByUID(uid string) (*User, error) {
+	var user User
+	if err := db.Db().Where("uid = ?", uid).First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// FindUserByWallet returns the user linked to the given wallet address.
+func FindUserByWallet(address string) (*User, error) {
+	var user User
+	if err := db.Db().Where("wallet_address = ?", address).First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// FindUserByOidcSubject returns the user linked to the given identity provider subject.
+func FindUserByOidcSubject(subject string) (*User, error) {
+	var user User
+	if err := db.Db().Where("oidc_subject = ?", subject).First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
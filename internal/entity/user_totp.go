@@ -0,0 +1,181 @@
+package entity
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/alexanderbkl/vidre-back/internal/db"
+	"github.com/alexanderbkl/vidre-back/pkg/rnd"
+	"github.com/alexanderbkl/vidre-back/pkg/totp"
+	"gorm.io/gorm"
+)
+
+// TotpSkew is the number of 30 second periods a code may be early or late.
+const TotpSkew = 1
+
+// Recovery code settings.
+const (
+	RecoveryCodeCount  = 10
+	RecoveryCodeLength = 10
+)
+
+var (
+	ErrTotpEnabled    = errors.New("two-factor authentication is already enabled")
+	ErrTotpNotSetup   = errors.New("two-factor authentication has not been set up")
+	ErrTotpInvalid    = errors.New("invalid two-factor code")
+	ErrTotpRequired   = errors.New("two-factor code required")
+	ErrTotpEnrollment = errors.New("two-factor authentication must be enabled for this role")
+)
+
+// SetupTotp creates a new TOTP secret. The secret is only used for login once
+// EnableTotp has been called after verifying a code.
+func (user *User) SetupTotp() error {
+	if user.TotpEnabled {
+		return ErrTotpEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return err
+	}
+
+	user.TotpSecret = secret
+	user.TotpLastCounter = 0
+
+	return nil
+}
+
+// TotpURI returns the provisioning URI for authenticator apps.
+func (user *User) TotpURI(issuer string) string {
+	return totp.URI(issuer, user.Name, user.TotpSecret)
+}
+
+// checkTotp verifies the code and returns its counter. Codes that are not
+// newer than the last accepted code are rejected so they cannot be replayed.
+func (user *User) checkTotp(code string, now time.Time) (int64, bool) {
+	if user.TotpSecret == "" {
+		return 0, false
+	}
+
+	counter, ok := totp.Verify(user.TotpSecret, code, now, TotpSkew)
+	if !ok || int64(counter) <= user.TotpLastCounter {
+		return 0, false
+	}
+
+	return int64(counter), true
+}
+
+// VerifyTotp checks the code and stores its counter as the last accepted one.
+func (user *User) VerifyTotp(code string) (bool, error) {
+	counter, ok := user.checkTotp(code, time.Now())
+	if !ok {
+		return false, nil
+	}
+
+	result := db.Db().Model(&User{}).
+		Where("id = ? AND totp_last_counter < ?", user.ID, counter).
+		Update("totp_last_counter", counter)
+
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	user.TotpLastCounter = counter
+
+	return result.RowsAffected == 1, nil
+}
+
+// EnableTotp requires a valid code for future logins.
+func (user *User) EnableTotp() error {
+	if user.TotpSecret == "" {
+		return ErrTotpNotSetup
+	}
+
+	user.TotpEnabled = true
+
+	return user.Save()
+}
+
+// DisableTotp removes the TOTP secret and all recovery codes.
+func (user *User) DisableTotp() error {
+	return db.Db().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", user.ID).Delete(&RecoveryCode{}).Error; err != nil {
+			return err
+		}
+
+		user.TotpSecret = ""
+		user.TotpEnabled = false
+		user.TotpLastCounter = 0
+
+		return tx.Model(&User{}).Where("id = ?", user.ID).Updates(map[string]interface{}{
+			"totp_secret":       "",
+			"totp_enabled":      false,
+			"totp_last_counter": 0,
+		}).Error
+	})
+}
+
+// RecoveryCode is a single-use code that replaces a TOTP code at login.
+type RecoveryCode struct {
+	ID        uint       `gorm:"primary_key" json:"id"`
+	UserID    uint       `gorm:"type:integer;index;not null" json:"user_id"`
+	User      User       `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	CodeHash  string     `gorm:"type:varchar(64);index;not null" json:"-"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (RecoveryCode) TableName() string {
+	return "recovery_codes"
+}
+
+// hashRecoveryCode returns the hex encoded SHA-256 hash of the normalized code.
+// Recovery codes are random, so a fast hash is sufficient.
+func hashRecoveryCode(code string) string {
+	code = strings.NewReplacer("-", "", " ", "").Replace(code)
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+// NewRecoveryCodes replaces the recovery codes of the user and returns the new codes.
+func NewRecoveryCodes(userID uint) ([]string, error) {
+	codes := make([]string, RecoveryCodeCount)
+	rows := make([]RecoveryCode, RecoveryCodeCount)
+
+	for i := range codes {
+		code := rnd.GenerateRandomString(RecoveryCodeLength)
+		codes[i] = code[:RecoveryCodeLength/2] + "-" + code[RecoveryCodeLength/2:]
+		rows[i] = RecoveryCode{UserID: userID, CodeHash: hashRecoveryCode(code)}
+	}
+
+	err := db.Db().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&RecoveryCode{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&rows).Error
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// UseRecoveryCode marks the code as used. It returns false if the code
+// does not belong to the user or has already been used.
+func UseRecoveryCode(userID uint, code string) (bool, error) {
+	result := db.Db().Model(&RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hashRecoveryCode(code)).
+		Update("used_at", time.Now().UTC())
+
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected == 1, nil
+}
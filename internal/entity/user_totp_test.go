@@ -0,0 +1,42 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alexanderbkl/vidre-back/pkg/totp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserCheckTotp(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	user := User{Name: "alice"}
+	require.NoError(t, user.SetupTotp())
+	require.NotEmpty(t, user.TotpSecret)
+
+	code, err := totp.Code(user.TotpSecret, now)
+	require.NoError(t, err)
+
+	counter, ok := user.checkTotp(code, now)
+	require.True(t, ok)
+	require.Equal(t, int64(totp.Counter(now)), counter)
+
+	_, ok = user.checkTotp("000000", now.Add(-time.Hour))
+	require.False(t, ok)
+
+	// A code that has already been accepted cannot be reused.
+	user.TotpLastCounter = counter
+	_, ok = user.checkTotp(code, now)
+	require.False(t, ok)
+
+	user.TotpEnabled = true
+	require.ErrorIs(t, user.SetupTotp(), ErrTotpEnabled)
+}
+
+func TestHashRecoveryCode(t *testing.T) {
+	require.Equal(t, hashRecoveryCode("abcde-FGHIJ"), hashRecoveryCode("abcdeFGHIJ"))
+	require.Equal(t, hashRecoveryCode("abcde-FGHIJ"), hashRecoveryCode(" abcde FGHIJ "))
+	require.NotEqual(t, hashRecoveryCode("abcde-FGHIJ"), hashRecoveryCode("abcde-FGHIK"))
+	require.Len(t, hashRecoveryCode("abcde-FGHIJ"), 64)
+}
@@ -0,0 +1,55 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Break types.
+const (
+	BreakPaid   = "paid"
+	BreakUnpaid = "unpaid"
+)
+
+// ValidBreakType returns true if the type is BreakPaid or BreakUnpaid.
+func ValidBreakType(breakType string) bool {
+	return breakType == BreakPaid || breakType == BreakUnpaid
+}
+
+// WorkBreak is a break within a work day. EndHour is zero while the break is open.
+type WorkBreak struct {
+	ID             uint      `gorm:"primary_key" json:"id"`
+	WorkScheduleID uint      `gorm:"type:integer;index;not null" json:"work_schedule_id"`
+	Position       int       `gorm:"type:integer;not null" json:"position"`
+	Type           string    `gorm:"type:varchar(16);not null;default:unpaid" json:"type"`
+	StartHour      time.Time `gorm:"type:timestamptz" json:"start_hour"`
+	EndHour        time.Time `gorm:"type:timestamptz" json:"end_hour"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+func (WorkBreak) TableName() string {
+	return "work_breaks"
+}
+
+type WorkBreaks []WorkBreak
+
+// ReplaceBreaks stores the breaks of a work schedule in place of its current ones.
+func ReplaceBreaks(tx *gorm.DB, schedule *WorkSchedule) error {
+	if err := tx.Where("work_schedule_id = ?", schedule.ID).Delete(&WorkBreak{}).Error; err != nil {
+		return err
+	}
+
+	if len(schedule.Breaks) == 0 {
+		return nil
+	}
+
+	for i := range schedule.Breaks {
+		schedule.Breaks[i].ID = 0
+		schedule.Breaks[i].WorkScheduleID = schedule.ID
+		schedule.Breaks[i].Position = i
+	}
+
+	return tx.Create(&schedule.Breaks).Error
+}
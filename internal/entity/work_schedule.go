@@ -6,19 +6,17 @@ import (
 	"gorm.io/gorm"
 )
 
+// WorkSchedule is the work day of a worker with its entry, exit and breaks.
 type WorkSchedule struct {
-	ID           uint           `gorm:"primary_key" json:"id"`
-	WorkerID     uint           `gorm:"type:integer;not null" json:"worker_id"`
-	Date         time.Time      `gorm:"type:date;not null" json:"date"`
-	EntryHour    time.Time      `gorm:"type:time;not null" json:"entry_hour"`
-	ExitHour     time.Time      `gorm:"type:time;not null" json:"exit_hour"`
-	BreakfastStartHour time.Time     `gorm:"type:time" json:"breakfast_start_hour"`
-	BreakfastEndHour   time.Time     `gorm:"type:time" json:"breakfast_end_hour"`
-	LunchStartHour time.Time     `gorm:"type:time" json:"lunch_start_hour"`
-	LunchEndHour   time.Time     `gorm:"type:time" json:"lunch_end_hour"`
-	CreatedAt    time.Time      `json:"created_at"`
-	UpdatedAt    time.Time      `json:"updated_at"`
-	DeletedAt    gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+	ID        uint           `gorm:"primary_key" json:"id"`
+	WorkerID  uint           `gorm:"type:integer;not null" json:"worker_id"`
+	Date      time.Time      `gorm:"type:date;not null" json:"date"`
+	EntryHour time.Time      `gorm:"type:timestamptz;not null" json:"entry_hour"`
+	ExitHour  time.Time      `gorm:"type:timestamptz;not null" json:"exit_hour"`
+	Breaks    WorkBreaks     `gorm:"foreignKey:WorkScheduleID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"breaks"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
 }
 
 func (WorkSchedule) TableName() string {
@@ -36,11 +34,25 @@ func (schedule *WorkSchedule) TxCreate(tx *gorm.DB) error {
 }
 
 func (schedule *WorkSchedule) Save(db *gorm.DB) error {
-	return db.Session(&gorm.Session{FullSaveAssociations: true}).Save(schedule).Error
+	return db.Transaction(schedule.TxSave)
 }
 
+// TxSave saves the work schedule and replaces its breaks.
 func (schedule *WorkSchedule) TxSave(tx *gorm.DB) error {
-	return tx.Session(&gorm.Session{FullSaveAssociations: true}).Save(schedule).Error
+	if err := tx.Omit("Breaks").Save(schedule).Error; err != nil {
+		return err
+	}
+
+	return ReplaceBreaks(tx, schedule)
+}
+
+// TxDelete deletes the work schedule together with its breaks.
+func (schedule *WorkSchedule) TxDelete(tx *gorm.DB) error {
+	if err := tx.Where("work_schedule_id = ?", schedule.ID).Delete(&WorkBreak{}).Error; err != nil {
+		return err
+	}
+
+	return tx.Delete(schedule).Error
 }
 
 func (schedule *WorkSchedule) Count(db *gorm.DB) (int64, error) {
@@ -49,3 +61,60 @@ func (schedule *WorkSchedule) Count(db *gorm.DB) (int64, error) {
 	return count, err
 }
 
+// FindWorkSchedules returns the work schedules of a worker between two dates,
+// including both, ordered by date and with their breaks.
+func FindWorkSchedules(db *gorm.DB, workerID uint, startDate, endDate time.Time) (WorkSchedules, error) {
+	var schedules WorkSchedules
+
+	err := db.Preload("Breaks", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position")
+	}).Where("worker_id = ? AND date >= ? AND date <= ?", workerID, startDate, endDate).Order("date").Find(&schedules).Error
+
+	return schedules, err
+}
+
+// FindWorkersSchedules returns the work schedules of several workers between
+// two dates, including both, ordered by worker and date and with their breaks.
+func FindWorkersSchedules(db *gorm.DB, workerIDs []uint, startDate, endDate time.Time) (WorkSchedules, error) {
+	var schedules WorkSchedules
+
+	err := db.Preload("Breaks", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position")
+	}).Where("worker_id IN ? AND date >= ? AND date <= ?", workerIDs, startDate, endDate).Order("worker_id, date").Find(&schedules).Error
+
+	return schedules, err
+}
+
+// In converts the times of the work schedule to the location, e.g. the
+// business time zone for responses. Empty times stay empty.
+func (schedule *WorkSchedule) In(loc *time.Location) *WorkSchedule {
+	schedule.EntryHour = inLocation(schedule.EntryHour, loc)
+	schedule.ExitHour = inLocation(schedule.ExitHour, loc)
+
+	for i := range schedule.Breaks {
+		schedule.Breaks[i].StartHour = inLocation(schedule.Breaks[i].StartHour, loc)
+		schedule.Breaks[i].EndHour = inLocation(schedule.Breaks[i].EndHour, loc)
+	}
+
+	return schedule
+}
+
+// inLocation returns t in the location unless it is the zero time.
+func inLocation(t time.Time, loc *time.Location) time.Time {
+	if t.IsZero() {
+		return time.Time{}
+	}
+
+	return t.In(loc)
+}
+
+// OpenBreak returns the break that has been started but not ended yet, if any.
+func (schedule *WorkSchedule) OpenBreak() *WorkBreak {
+	for i := range schedule.Breaks {
+		if schedule.Breaks[i].EndHour.IsZero() {
+			return &schedule.Breaks[i]
+		}
+	}
+
+	return nil
+}
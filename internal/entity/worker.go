@@ -11,6 +11,10 @@ type Worker struct {
 	ID        uint           `gorm:"primary_key" json:"id"`
 	Name      string         `gorm:"type:varchar(255)" json:"name"`
 	Code      string         `gorm:"type:varchar(255);unique" json:"code"`
+	// PinHash is the bcrypt hash of the optional punch PIN.
+	PinHash           string     `gorm:"type:varchar(255)" json:"-"`
+	FailedPinAttempts int        `gorm:"type:integer;not null;default:0" json:"failed_pin_attempts"`
+	PinLockedUntil    *time.Time `json:"pin_locked_until"`
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
@@ -34,6 +38,10 @@ func (worker *Worker) Save() error {
 	return db.Db().Session(&gorm.Session{FullSaveAssociations: true}).Save(worker).Error
 }
 
+func (worker *Worker) TxSave(tx *gorm.DB) error {
+	return tx.Session(&gorm.Session{FullSaveAssociations: true}).Save(worker).Error
+}
+
 func (worker *Worker) Count() (int64, error) {
 	var count int64
 	err := db.Db().Model(&Worker{}).Count(&count).Error
@@ -0,0 +1,158 @@
+package entity
+
+import (
+	"errors"
+	"time"
+
+	"github.com/alexanderbkl/vidre-back/internal/db"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Worker PIN length limits.
+const (
+	PinMinLength = 4
+	PinMaxLength = 8
+)
+
+var (
+	ErrPinFormat = errors.New("pin must have between 4 and 8 digits")
+	ErrPinLocked = errors.New("pin is locked")
+	ErrPinWrong  = errors.New("pin is wrong")
+)
+
+// HasPin returns true if punches of the worker require a PIN.
+func (worker *Worker) HasPin() bool {
+	return worker.PinHash != ""
+}
+
+// SetPin stores the bcrypt hash of the PIN and unlocks the worker.
+func (worker *Worker) SetPin(pin string) error {
+	if len(pin) < PinMinLength || len(pin) > PinMaxLength {
+		return ErrPinFormat
+	}
+
+	for _, r := range pin {
+		if r < '0' || r > '9' {
+			return ErrPinFormat
+		}
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(pin), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	worker.PinHash = string(hash)
+	worker.FailedPinAttempts = 0
+	worker.PinLockedUntil = nil
+
+	return nil
+}
+
+// ResetPin removes the PIN and unlocks the worker.
+func (worker *Worker) ResetPin() {
+	worker.PinHash = ""
+	worker.FailedPinAttempts = 0
+	worker.PinLockedUntil = nil
+}
+
+// PinLocked returns true if the worker is locked out after too many failed attempts.
+func (worker *Worker) PinLocked(now time.Time) bool {
+	return worker.PinLockedUntil != nil && now.Before(*worker.PinLockedUntil)
+}
+
+// VerifyPin checks the PIN and counts failed attempts. After maxAttempts
+// consecutive failures the worker is locked out for lockDuration.
+//
+// The attempt is counted on the locked database row of the worker, so that
+// parallel attempts cannot skip the lockout.
+func (worker *Worker) VerifyPin(pin string, maxAttempts int, lockDuration time.Duration) error {
+	now := time.Now().UTC()
+
+	if worker.PinLocked(now) {
+		return ErrPinLocked
+	}
+
+	valid := bcrypt.CompareHashAndPassword([]byte(worker.PinHash), []byte(pin)) == nil
+
+	var result error
+
+	err := db.Db().Transaction(func(tx *gorm.DB) error {
+		var current Worker
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "failed_pin_attempts", "pin_locked_until").
+			First(&current, worker.ID).Error; err != nil {
+			return err
+		}
+
+		worker.FailedPinAttempts = current.FailedPinAttempts
+		worker.PinLockedUntil = current.PinLockedUntil
+
+		var updates map[string]interface{}
+		if updates, result = worker.countPinAttempt(valid, now, maxAttempts, lockDuration); len(updates) == 0 {
+			return nil
+		}
+
+		return tx.Model(&Worker{}).Where("id = ?", worker.ID).Updates(updates).Error
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return result
+}
+
+// countPinAttempt counts a PIN attempt on the current state of the worker.
+// It returns the changed columns, if any, and the result of the attempt.
+func (worker *Worker) countPinAttempt(valid bool, now time.Time, maxAttempts int, lockDuration time.Duration) (map[string]interface{}, error) {
+	if worker.PinLocked(now) {
+		return nil, ErrPinLocked
+	}
+
+	if valid {
+		if worker.FailedPinAttempts == 0 && worker.PinLockedUntil == nil {
+			return nil, nil
+		}
+
+		worker.FailedPinAttempts = 0
+		worker.PinLockedUntil = nil
+
+		return map[string]interface{}{"failed_pin_attempts": 0, "pin_locked_until": nil}, nil
+	}
+
+	worker.FailedPinAttempts++
+
+	if maxAttempts > 0 && worker.FailedPinAttempts >= maxAttempts {
+		lockedUntil := now.Add(lockDuration)
+		worker.FailedPinAttempts = 0
+		worker.PinLockedUntil = &lockedUntil
+
+		return map[string]interface{}{"failed_pin_attempts": 0, "pin_locked_until": lockedUntil}, ErrPinWrong
+	}
+
+	return map[string]interface{}{"failed_pin_attempts": worker.FailedPinAttempts}, ErrPinWrong
+}
+
+// PinAttempt records a failed worker PIN verification.
+type PinAttempt struct {
+	ID        uint      `gorm:"primary_key" json:"id"`
+	WorkerID  uint      `gorm:"type:integer;index;not null" json:"worker_id"`
+	Worker    Worker    `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	DeviceID  *uint     `gorm:"type:integer" json:"device_id"`
+	ClientIP  string    `gorm:"type:varchar(64)" json:"client_ip"`
+	Locked    bool      `gorm:"type:boolean" json:"locked"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (PinAttempt) TableName() string {
+	return "pin_attempts"
+}
+
+type PinAttempts []PinAttempt
+
+func (attempt *PinAttempt) Create() error {
+	return db.Db().Create(attempt).Error
+}
@@ -0,0 +1,105 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestWorkerSetPin(t *testing.T) {
+	testCases := []struct {
+		name string
+		pin  string
+		err  error
+	}{
+		{name: "FourDigits", pin: "1234"},
+		{name: "EightDigits", pin: "12345678"},
+		{name: "TooShort", pin: "123", err: ErrPinFormat},
+		{name: "TooLong", pin: "123456789", err: ErrPinFormat},
+		{name: "NotNumeric", pin: "12a4", err: ErrPinFormat},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			lockedUntil := time.Now().Add(time.Hour)
+			worker := Worker{FailedPinAttempts: 3, PinLockedUntil: &lockedUntil}
+
+			err := worker.SetPin(tc.pin)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				require.False(t, worker.HasPin())
+				return
+			}
+
+			require.NoError(t, err)
+			require.True(t, worker.HasPin())
+			require.NoError(t, bcrypt.CompareHashAndPassword([]byte(worker.PinHash), []byte(tc.pin)))
+			require.Zero(t, worker.FailedPinAttempts)
+			require.False(t, worker.PinLocked(time.Now()))
+		})
+	}
+}
+
+func TestWorkerPinLocked(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Minute)
+	future := now.Add(time.Minute)
+
+	require.False(t, (&Worker{}).PinLocked(now))
+	require.False(t, (&Worker{PinLockedUntil: &past}).PinLocked(now))
+	require.True(t, (&Worker{PinLockedUntil: &future}).PinLocked(now))
+}
+
+func TestWorkerCountPinAttempt(t *testing.T) {
+	now := time.Now()
+	future := now.Add(time.Minute)
+
+	testCases := []struct {
+		name     string
+		worker   Worker
+		valid    bool
+		updates  map[string]interface{}
+		attempts int
+		locked   bool
+		err      error
+	}{
+		{name: "Valid", valid: true},
+		{name: "ValidAfterFailure", worker: Worker{FailedPinAttempts: 2}, valid: true, updates: map[string]interface{}{"failed_pin_attempts": 0, "pin_locked_until": nil}},
+		{name: "Wrong", worker: Worker{FailedPinAttempts: 1}, updates: map[string]interface{}{"failed_pin_attempts": 2}, attempts: 2, err: ErrPinWrong},
+		{name: "LastAttempt", worker: Worker{FailedPinAttempts: 2}, updates: map[string]interface{}{"failed_pin_attempts": 0, "pin_locked_until": now.Add(time.Hour)}, locked: true, err: ErrPinWrong},
+		{name: "Locked", worker: Worker{PinLockedUntil: &future}, valid: true, locked: true, err: ErrPinLocked},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			updates, err := tc.worker.countPinAttempt(tc.valid, now, 3, time.Hour)
+			require.Equal(t, tc.err, err)
+			require.Equal(t, tc.updates, updates)
+			require.Equal(t, tc.attempts, tc.worker.FailedPinAttempts)
+			require.Equal(t, tc.locked, tc.worker.PinLocked(now))
+		})
+	}
+}
+
+func TestWorkerCountPinAttemptsLockout(t *testing.T) {
+	// Each attempt counts on the state left by the previous one, as they do
+	// on the locked row, so the third wrong PIN locks the worker.
+	now := time.Now()
+	worker := Worker{}
+
+	for i := 0; i < 3; i++ {
+		_, err := worker.countPinAttempt(false, now, 3, time.Hour)
+		require.Equal(t, ErrPinWrong, err)
+	}
+
+	require.True(t, worker.PinLocked(now))
+
+	_, err := worker.countPinAttempt(true, now, 3, time.Hour)
+	require.Equal(t, ErrPinLocked, err)
+}
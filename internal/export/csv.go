@@ -0,0 +1,64 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+
+	"github.com/alexanderbkl/vidre-back/internal/register"
+	"github.com/alexanderbkl/vidre-back/internal/worktime"
+)
+
+// csvHeader contains the column titles of the CSV export.
+var csvHeader = []string{"Código", "Trabajador", "Fecha", "Entrada", "Descansos", "Salida", "Horas brutas", "Descansos retribuidos", "Descansos no retribuidos", "Horas netas", "Observaciones"}
+
+// WriteCSV writes all work days as CSV, with a total row after the days of
+// each worker. It uses semicolons and starts with a byte order mark, as
+// Excel expects for Spanish settings.
+func (e *Export) WriteCSV(w io.Writer) error {
+	if _, err := io.WriteString(w, "\ufeff"); err != nil {
+		return err
+	}
+
+	out := csv.NewWriter(w)
+	out.Comma = ';'
+	out.UseCRLF = true
+
+	if err := out.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, worker := range e.Workers {
+		for _, day := range worker.Days {
+			row := []string{cellText(worker.Code), cellText(worker.Name), day.Date.Format("02/01/2006"), e.Entry(day), e.Breaks(day), e.Exit(day)}
+			row = append(row, csvTimes(day.Times)...)
+			row = append(row, e.Remarks(day))
+
+			if err := out.Write(row); err != nil {
+				return err
+			}
+		}
+
+		var remarks string
+		if worker.Incomplete > 0 {
+			remarks = fmt.Sprintf("%d días incompletos", worker.Incomplete)
+		}
+
+		row := []string{cellText(worker.Code), cellText(worker.Name), "Total", "", "", ""}
+		row = append(row, csvTimes(worker.Total)...)
+		row = append(row, remarks)
+
+		if err := out.Write(row); err != nil {
+			return err
+		}
+	}
+
+	out.Flush()
+
+	return out.Error()
+}
+
+// csvTimes returns the worked time columns.
+func csvTimes(t worktime.Times) []string {
+	return []string{register.Hours(t.Gross), register.Hours(t.PaidBreaks), register.Hours(t.UnpaidBreaks), register.Hours(t.Net)}
+}
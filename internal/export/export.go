@@ -0,0 +1,160 @@
+/*
+Package export writes the work schedules of several workers with their
+computed worked time as CSV or XLSX, so that they can be processed in a
+spreadsheet.
+
+Times are shown as wall clock times in the business time zone. Times on a
+later day than the work day, e.g. the exit of a night shift, are marked
+with the day offset as in the working time register.
+*/
+package export
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/alexanderbkl/vidre-back/internal/entity"
+	"github.com/alexanderbkl/vidre-back/internal/register"
+	"github.com/alexanderbkl/vidre-back/internal/worktime"
+)
+
+// Formats contains the supported export formats.
+var Formats = map[string]string{
+	"csv":  "text/csv; charset=utf-8",
+	"xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// MaxDays is the longest date frame that can be exported at once.
+const MaxDays = 366
+
+var (
+	ErrInvalidRange = errors.New("from must not be after to")
+	ErrRangeTooLong = errors.New("date frame is too long, at most 366 days can be exported")
+)
+
+// ValidateRange checks that the date frame from and to, both inclusive, is
+// not inverted and not longer than MaxDays.
+func ValidateRange(from, to time.Time) error {
+	if to.Before(from) {
+		return ErrInvalidRange
+	} else if to.Sub(from) >= MaxDays*24*time.Hour {
+		return ErrRangeTooLong
+	}
+
+	return nil
+}
+
+// Codes splits a comma separated list of worker codes. Blank and duplicate
+// codes are ignored.
+func Codes(list string) []string {
+	var codes []string
+	seen := make(map[string]bool)
+
+	for _, code := range strings.Split(list, ",") {
+		if code = strings.TrimSpace(code); code != "" && !seen[code] {
+			seen[code] = true
+			codes = append(codes, code)
+		}
+	}
+
+	return codes
+}
+
+// cellText returns a text that a spreadsheet does not evaluate as a formula.
+// Texts starting with a formula character are prefixed with a quote, so that
+// e.g. a worker name like "=HYPERLINK(...)" is shown as is.
+func cellText(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+
+	return s
+}
+
+// Day is an exported work day.
+type Day struct {
+	Schedule *entity.WorkSchedule
+	worktime.Day
+}
+
+// Worker contains the exported work days of a worker.
+type Worker struct {
+	entity.Worker
+	Days       []Day
+	Total      worktime.Times
+	Incomplete int
+}
+
+// Export contains the work days of the selected workers in a date frame.
+type Export struct {
+	From     time.Time
+	To       time.Time
+	Location *time.Location
+	Workers  []Worker
+}
+
+// New returns the export of the workers in the given order with their work
+// schedules. Schedules of other workers are ignored.
+func New(from, to time.Time, loc *time.Location, workers entity.Workers, schedules entity.WorkSchedules) *Export {
+	e := &Export{From: from, To: to, Location: loc, Workers: make([]Worker, len(workers))}
+
+	index := make(map[uint]int, len(workers))
+	for i, worker := range workers {
+		e.Workers[i].Worker = worker
+		index[worker.ID] = i
+	}
+
+	for i := range schedules {
+		w, ok := index[schedules[i].WorkerID]
+		if !ok {
+			continue
+		}
+
+		day := Day{Schedule: &schedules[i], Day: worktime.Compute(&schedules[i])}
+
+		e.Workers[w].Days = append(e.Workers[w].Days, day)
+		e.Workers[w].Total.Add(day.Times)
+
+		if !day.Complete {
+			e.Workers[w].Incomplete++
+		}
+	}
+
+	return e
+}
+
+// Entry returns the entry time of a day.
+func (e *Export) Entry(day Day) string {
+	return register.Clock(day.Date, day.Schedule.EntryHour, e.Location)
+}
+
+// Exit returns the exit time of a day.
+func (e *Export) Exit(day Day) string {
+	return register.Clock(day.Date, day.Schedule.ExitHour, e.Location)
+}
+
+// Breaks returns the breaks of a day, e.g. "10:00-10:15 R, 14:00-15:00",
+// where paid breaks are marked with "R".
+func (e *Export) Breaks(day Day) string {
+	breaks := make([]string, 0, len(day.Schedule.Breaks))
+
+	for _, b := range day.Schedule.Breaks {
+		text := register.Clock(day.Date, b.StartHour, e.Location) + "-" + register.Clock(day.Date, b.EndHour, e.Location)
+		if b.Type == entity.BreakPaid {
+			text += " R"
+		}
+		breaks = append(breaks, text)
+	}
+
+	return strings.Join(breaks, ", ")
+}
+
+// Remarks returns the remarks of a day.
+func (e *Export) Remarks(day Day) string {
+	if !day.Complete {
+		return "Incompleto"
+	}
+
+	return ""
+}
@@ -0,0 +1,244 @@
+package export
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexanderbkl/vidre-back/internal/entity"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+)
+
+func testExport(t *testing.T) *Export {
+	madrid, err := time.LoadLocation("Europe/Madrid")
+	require.NoError(t, err)
+
+	local := func(day, hour, min int) time.Time {
+		return time.Date(2024, 3, day, hour, min, 0, 0, madrid)
+	}
+
+	date := func(day int) time.Time {
+		return time.Date(2024, 3, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	workers := entity.Workers{
+		{ID: 2, Code: "0042", Name: "Begoña Núñez"},
+		{ID: 1, Code: "0007", Name: "Jordi Puig"},
+	}
+
+	schedules := entity.WorkSchedules{
+		{WorkerID: 1, Date: date(4), EntryHour: local(4, 7, 0), ExitHour: local(4, 15, 0)},
+		{WorkerID: 2, Date: date(4), EntryHour: local(4, 8, 0), ExitHour: local(4, 17, 0), Breaks: entity.WorkBreaks{
+			{Type: entity.BreakPaid, StartHour: local(4, 10, 0), EndHour: local(4, 10, 15)},
+			{Type: entity.BreakUnpaid, StartHour: local(4, 14, 0), EndHour: local(4, 15, 0)},
+		}},
+		{WorkerID: 2, Date: date(5), EntryHour: local(5, 22, 0), ExitHour: local(6, 6, 0)},
+		{WorkerID: 2, Date: date(7), EntryHour: local(7, 8, 0)},
+		{WorkerID: 3, Date: date(4), EntryHour: local(4, 8, 0), ExitHour: local(4, 16, 0)},
+	}
+
+	return New(date(1), date(31), madrid, workers, schedules)
+}
+
+func TestNew(t *testing.T) {
+	e := testExport(t)
+
+	require.Len(t, e.Workers, 2)
+
+	begona := e.Workers[0]
+	require.Equal(t, "0042", begona.Code)
+	require.Len(t, begona.Days, 3)
+	require.Equal(t, 1, begona.Incomplete)
+	require.Equal(t, 17*time.Hour, begona.Total.Gross)
+	require.Equal(t, 16*time.Hour, begona.Total.Net)
+
+	jordi := e.Workers[1]
+	require.Equal(t, "0007", jordi.Code)
+	require.Len(t, jordi.Days, 1)
+	require.Equal(t, 8*time.Hour, jordi.Total.Net)
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, testExport(t).WriteCSV(&buf))
+
+	expected := []string{
+		"\ufeffCódigo;Trabajador;Fecha;Entrada;Descansos;Salida;Horas brutas;Descansos retribuidos;Descansos no retribuidos;Horas netas;Observaciones",
+		"0042;Begoña Núñez;04/03/2024;08:00;10:00-10:15 R, 14:00-15:00;17:00;9:00;0:15;1:00;8:00;",
+		"0042;Begoña Núñez;05/03/2024;22:00;;06:00 (+1);8:00;0:00;0:00;8:00;",
+		"0042;Begoña Núñez;07/03/2024;08:00;;;0:00;0:00;0:00;0:00;Incompleto",
+		"0042;Begoña Núñez;Total;;;;17:00;0:15;1:00;16:00;1 días incompletos",
+		"0007;Jordi Puig;04/03/2024;07:00;;15:00;8:00;0:00;0:00;8:00;",
+		"0007;Jordi Puig;Total;;;;8:00;0:00;0:00;8:00;",
+		"",
+	}
+
+	require.Equal(t, strings.Join(expected, "\r\n"), buf.String())
+}
+
+func TestWriteXLSX(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, testExport(t).WriteXLSX(&buf))
+
+	f, err := excelize.OpenReader(&buf)
+	require.NoError(t, err)
+	defer f.Close()
+
+	require.Equal(t, []string{"Resumen", "0042 Begoña Núñez", "0007 Jordi Puig"}, f.GetSheetList())
+
+	// Zero durations are formatted as "0" by excelize, Excel shows "0:00".
+	summary, err := f.GetRows("Resumen")
+	require.NoError(t, err)
+	require.Equal(t, "Periodo: 01/03/2024 - 31/03/2024", summary[0][0])
+	require.Equal(t, []string{"0042", "Begoña Núñez", "3", "1", "17:00", "0:15", "1:00", "16:00"}, summary[3])
+	require.Equal(t, []string{"0007", "Jordi Puig", "1", "0", "8:00", "0", "0", "8:00"}, summary[4])
+	require.Equal(t, []string{"Total", "", "4", "1", "25:00", "0:15", "1:00", "24:00"}, summary[5])
+
+	rows, err := f.GetRows("0042 Begoña Núñez")
+	require.NoError(t, err)
+	require.Len(t, rows, 7)
+	require.Equal(t, []string{"04/03/2024", "08:00", "10:00-10:15 R, 14:00-15:00", "17:00", "9:00", "0:15", "1:00", "8:00"}, rows[3])
+	require.Equal(t, []string{"05/03/2024", "22:00", "", "06:00 (+1)", "8:00", "0", "0", "8:00"}, rows[4])
+	require.Equal(t, "Incompleto", rows[5][8])
+	require.Equal(t, []string{"Total", "", "", "", "17:00", "0:15", "1:00", "16:00", "1 días incompletos"}, rows[6])
+
+	net, err := f.GetCellValue("0042 Begoña Núñez", "H4", excelize.Options{RawCellValue: true})
+	require.NoError(t, err)
+	require.Equal(t, "0.333333333333333", net[:17])
+}
+
+func TestSheetNames(t *testing.T) {
+	testCases := []struct {
+		name     string
+		workers  []Worker
+		expected []string
+	}{
+		{
+			name:     "CodeAndName",
+			workers:  []Worker{{Worker: entity.Worker{Code: "0042", Name: "Begoña Núñez"}}},
+			expected: []string{"0042 Begoña Núñez"},
+		},
+		{
+			name:     "InvalidCharacters",
+			workers:  []Worker{{Worker: entity.Worker{Code: "01/02", Name: "[Ana]: *test?"}}},
+			expected: []string{"0102 Ana test"},
+		},
+		{
+			name:     "Truncated",
+			workers:  []Worker{{Worker: entity.Worker{Code: "0001", Name: "María de los Ángeles Fernández"}}},
+			expected: []string{"0001 María de los Ángeles Ferná"},
+		},
+		{
+			name: "Duplicates",
+			workers: []Worker{
+				{Worker: entity.Worker{Code: "0001", Name: "María de los Ángeles Fernández"}},
+				{Worker: entity.Worker{Code: "0001", Name: "María de los Ángeles Fernández García"}},
+				{Worker: entity.Worker{Name: "Resumen"}},
+			},
+			expected: []string{"0001 María de los Ángeles Ferná", "0001 María de los Ángeles F (2)", "Resumen (2)"},
+		},
+		{
+			name:     "Empty",
+			workers:  []Worker{{}},
+			expected: []string{"Trabajador"},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, SheetNames(tc.workers))
+		})
+	}
+}
+
+func TestValidateRange(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	testCases := []struct {
+		name     string
+		from, to time.Time
+		expected error
+	}{
+		{"SameDay", date(2024, 3, 4), date(2024, 3, 4), nil},
+		{"Month", date(2024, 3, 1), date(2024, 3, 31), nil},
+		{"LeapYear", date(2024, 1, 1), date(2024, 12, 31), nil},
+		{"Inverted", date(2024, 3, 5), date(2024, 3, 4), ErrInvalidRange},
+		{"TooLong", date(2024, 1, 1), date(2025, 1, 1), ErrRangeTooLong},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, ValidateRange(tc.from, tc.to))
+		})
+	}
+}
+
+func TestCodes(t *testing.T) {
+	testCases := []struct {
+		name     string
+		list     string
+		expected []string
+	}{
+		{"Empty", "", nil},
+		{"Blank", " , ,", nil},
+		{"Single", "A", []string{"A"}},
+		{"Duplicates", "A, B,A ,B", []string{"A", "B"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, Codes(tc.list))
+		})
+	}
+}
+
+func TestCellText(t *testing.T) {
+	testCases := []struct {
+		text     string
+		expected string
+	}{
+		{"", ""},
+		{"Begoña Núñez", "Begoña Núñez"},
+		{"0042", "0042"},
+		{`=HYPERLINK("http://example.com","x")`, `'=HYPERLINK("http://example.com","x")`},
+		{"+34 600", "'+34 600"},
+		{"-1+1", "'-1+1"},
+		{"@SUM(A1)", "'@SUM(A1)"},
+		{"\t=1", "'\t=1"},
+		{"Ana=1", "Ana=1"},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.expected, cellText(tc.text))
+	}
+}
+
+func TestWriteFormulaNames(t *testing.T) {
+	e := testExport(t)
+	e.Workers[1].Name = "=HYPERLINK(\"http://example.com\")"
+
+	var buf bytes.Buffer
+	require.NoError(t, e.WriteCSV(&buf))
+	require.Contains(t, buf.String(), "\r\n0007;\"'=HYPERLINK(\"\"http://example.com\"\")\";Total;")
+
+	buf.Reset()
+	require.NoError(t, e.WriteXLSX(&buf))
+
+	f, err := excelize.OpenReader(&buf)
+	require.NoError(t, err)
+	defer f.Close()
+
+	name, err := f.GetCellValue("Resumen", "B5")
+	require.NoError(t, err)
+	require.Equal(t, "'=HYPERLINK(\"http://example.com\")", name)
+
+	formula, err := f.GetCellFormula("Resumen", "B5")
+	require.NoError(t, err)
+	require.Empty(t, formula)
+}
@@ -0,0 +1,309 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/alexanderbkl/vidre-back/internal/worktime"
+	"github.com/xuri/excelize/v2"
+)
+
+// SummarySheet is the name of the sheet with the totals of all workers.
+const SummarySheet = "Resumen"
+
+// maxSheetName is the maximum length of a sheet name in Excel.
+const maxSheetName = 31
+
+// summaryHeader contains the column titles of the summary sheet.
+var summaryHeader = []interface{}{"Código", "Trabajador", "Días", "Días incompletos", "Horas brutas", "Descansos retribuidos", "Descansos no retribuidos", "Horas netas"}
+
+// workerHeader contains the column titles of a worker sheet.
+var workerHeader = []interface{}{"Fecha", "Entrada", "Descansos", "Salida", "Horas brutas", "Descansos retribuidos", "Descansos no retribuidos", "Horas netas", "Observaciones"}
+
+// xlsxStyles contains the cell styles of the workbook.
+type xlsxStyles struct {
+	header    int
+	date      int
+	hours     int
+	total     int
+	totalHour int
+}
+
+// WriteXLSX writes a workbook with a summary sheet and one sheet with the
+// work days of each worker. Worked times are durations, so that they can be
+// added up in Excel.
+func (e *Export) WriteXLSX(w io.Writer) error {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	styles, err := newXLSXStyles(f)
+	if err != nil {
+		return err
+	}
+
+	if err := f.SetSheetName(f.GetSheetName(0), SummarySheet); err != nil {
+		return err
+	}
+
+	if err := e.writeSummary(f, styles); err != nil {
+		return err
+	}
+
+	names := SheetNames(e.Workers)
+
+	for i, worker := range e.Workers {
+		if _, err := f.NewSheet(names[i]); err != nil {
+			return err
+		}
+
+		if err := e.writeWorker(f, styles, names[i], worker); err != nil {
+			return err
+		}
+	}
+
+	f.SetActiveSheet(0)
+
+	return f.Write(w)
+}
+
+// writeSummary writes the totals of each worker and of all workers.
+func (e *Export) writeSummary(f *excelize.File, styles xlsxStyles) error {
+	sheet := SummarySheet
+
+	period := fmt.Sprintf("Periodo: %s - %s", e.From.Format("02/01/2006"), e.To.Format("02/01/2006"))
+	if err := f.SetCellValue(sheet, "A1", period); err != nil {
+		return err
+	}
+
+	if err := writeHeader(f, styles, sheet, 3, summaryHeader); err != nil {
+		return err
+	}
+
+	row := 4
+
+	var total worktime.Times
+	var days, incomplete int
+
+	for _, worker := range e.Workers {
+		values := []interface{}{cellText(worker.Code), cellText(worker.Name), len(worker.Days), worker.Incomplete}
+		if err := writeRow(f, sheet, row, append(values, xlsxTimes(worker.Total)...)); err != nil {
+			return err
+		}
+
+		if err := setStyle(f, sheet, "E", "H", row, styles.hours); err != nil {
+			return err
+		}
+
+		total.Add(worker.Total)
+		days += len(worker.Days)
+		incomplete += worker.Incomplete
+		row++
+	}
+
+	values := []interface{}{"Total", "", days, incomplete}
+	if err := writeRow(f, sheet, row, append(values, xlsxTimes(total)...)); err != nil {
+		return err
+	}
+
+	if err := setStyle(f, sheet, "A", "D", row, styles.total); err != nil {
+		return err
+	}
+
+	if err := setStyle(f, sheet, "E", "H", row, styles.totalHour); err != nil {
+		return err
+	}
+
+	if err := f.SetColWidth(sheet, "B", "B", 30); err != nil {
+		return err
+	}
+
+	return f.SetColWidth(sheet, "C", "H", 14)
+}
+
+// writeWorker writes the work days and totals of a worker.
+func (e *Export) writeWorker(f *excelize.File, styles xlsxStyles, sheet string, worker Worker) error {
+	if err := f.SetCellValue(sheet, "A1", cellText(worker.Code+" "+worker.Name)); err != nil {
+		return err
+	}
+
+	if err := writeHeader(f, styles, sheet, 3, workerHeader); err != nil {
+		return err
+	}
+
+	row := 4
+
+	for _, day := range worker.Days {
+		values := []interface{}{day.Date, e.Entry(day), e.Breaks(day), e.Exit(day)}
+		values = append(values, xlsxTimes(day.Times)...)
+		if err := writeRow(f, sheet, row, append(values, e.Remarks(day))); err != nil {
+			return err
+		}
+
+		if err := setStyle(f, sheet, "A", "A", row, styles.date); err != nil {
+			return err
+		}
+
+		if err := setStyle(f, sheet, "E", "H", row, styles.hours); err != nil {
+			return err
+		}
+
+		row++
+	}
+
+	var remarks string
+	if worker.Incomplete > 0 {
+		remarks = fmt.Sprintf("%d días incompletos", worker.Incomplete)
+	}
+
+	values := []interface{}{"Total", "", "", ""}
+	values = append(values, xlsxTimes(worker.Total)...)
+	if err := writeRow(f, sheet, row, append(values, remarks)); err != nil {
+		return err
+	}
+
+	if err := setStyle(f, sheet, "A", "D", row, styles.total); err != nil {
+		return err
+	}
+
+	if err := setStyle(f, sheet, "E", "H", row, styles.totalHour); err != nil {
+		return err
+	}
+
+	if err := f.SetColWidth(sheet, "A", "B", 12); err != nil {
+		return err
+	}
+
+	if err := f.SetColWidth(sheet, "C", "C", 30); err != nil {
+		return err
+	}
+
+	if err := f.SetColWidth(sheet, "D", "H", 14); err != nil {
+		return err
+	}
+
+	return f.SetColWidth(sheet, "I", "I", 24)
+}
+
+// SheetNames returns unique sheet names for the workers, made of their code
+// and name without the characters Excel does not allow.
+func SheetNames(workers []Worker) []string {
+	names := make([]string, len(workers))
+	used := map[string]bool{strings.ToLower(SummarySheet): true}
+
+	for i, worker := range workers {
+		base := sheetName(strings.TrimSpace(worker.Code + " " + worker.Name))
+		if base == "" {
+			base = "Trabajador"
+		}
+
+		name := base
+		for n := 2; used[strings.ToLower(name)]; n++ {
+			suffix := fmt.Sprintf(" (%d)", n)
+			name = truncate(base, maxSheetName-utf8.RuneCountInString(suffix)) + suffix
+		}
+
+		used[strings.ToLower(name)] = true
+		names[i] = name
+	}
+
+	return names
+}
+
+// sheetName removes the characters Excel does not allow in sheet names and
+// limits the length.
+func sheetName(s string) string {
+	s = strings.Map(func(r rune) rune {
+		switch r {
+		case ':', '\\', '/', '?', '*', '[', ']':
+			return -1
+		}
+		return r
+	}, s)
+
+	return strings.Trim(truncate(s, maxSheetName), "' ")
+}
+
+// truncate limits a string to n characters.
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+
+	return string([]rune(s)[:n])
+}
+
+// xlsxTimes returns the worked time columns as fractions of a day, which is
+// how Excel stores durations.
+func xlsxTimes(t worktime.Times) []interface{} {
+	return []interface{}{days(t.Gross), days(t.PaidBreaks), days(t.UnpaidBreaks), days(t.Net)}
+}
+
+// days returns the duration in days, rounded to minutes.
+func days(d time.Duration) float64 {
+	return d.Round(time.Minute).Minutes() / (24 * 60)
+}
+
+func newXLSXStyles(f *excelize.File) (xlsxStyles, error) {
+	var styles xlsxStyles
+	var err error
+
+	dateFormat, hoursFormat := "dd/mm/yyyy", "[h]:mm"
+	bold := &excelize.Font{Bold: true}
+
+	if styles.header, err = f.NewStyle(&excelize.Style{
+		Font: bold,
+		Fill: excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"D9D9D9"}},
+	}); err != nil {
+		return styles, err
+	}
+
+	if styles.date, err = f.NewStyle(&excelize.Style{CustomNumFmt: &dateFormat}); err != nil {
+		return styles, err
+	}
+
+	if styles.hours, err = f.NewStyle(&excelize.Style{CustomNumFmt: &hoursFormat}); err != nil {
+		return styles, err
+	}
+
+	if styles.total, err = f.NewStyle(&excelize.Style{Font: bold}); err != nil {
+		return styles, err
+	}
+
+	styles.totalHour, err = f.NewStyle(&excelize.Style{Font: bold, CustomNumFmt: &hoursFormat})
+
+	return styles, err
+}
+
+// writeHeader writes the column titles to a row and freezes the rows above.
+func writeHeader(f *excelize.File, styles xlsxStyles, sheet string, row int, titles []interface{}) error {
+	if err := writeRow(f, sheet, row, titles); err != nil {
+		return err
+	}
+
+	last, err := excelize.ColumnNumberToName(len(titles))
+	if err != nil {
+		return err
+	}
+
+	if err := setStyle(f, sheet, "A", last, row, styles.header); err != nil {
+		return err
+	}
+
+	return f.SetPanes(sheet, &excelize.Panes{
+		Freeze:      true,
+		YSplit:      row,
+		TopLeftCell: fmt.Sprintf("A%d", row+1),
+		ActivePane:  "bottomLeft",
+	})
+}
+
+func writeRow(f *excelize.File, sheet string, row int, values []interface{}) error {
+	return f.SetSheetRow(sheet, fmt.Sprintf("A%d", row), &values)
+}
+
+func setStyle(f *excelize.File, sheet, from, to string, row, style int) error {
+	return f.SetCellStyle(sheet, fmt.Sprintf("%s%d", from, row), fmt.Sprintf("%s%d", to, row), style)
+}
@@ -15,13 +15,43 @@ type RegisterUserRequest struct {
 	Name string `json:"name"`
 }
 
+type LoginPasswordRequest struct {
+	Name         string `json:"name"     binding:"required"`
+	Password     string `json:"password" binding:"required"`
+	Otp          string `json:"otp"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+type TotpSetupResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type RolePolicyRequest struct {
+	Role       string `json:"role" binding:"required"`
+	RequireMFA bool   `json:"require_mfa"`
+}
+
 type LoginUserRequest struct {
 	Name          string `json:"name"`
 	WalletAddress string `json:"wallet_address" binding:"required"`
 	Signature     string `json:"signature"      binding:"required"`
+	Nonce         string `json:"nonce"          binding:"required"`
+	Otp           string `json:"otp"`
+	RecoveryCode  string `json:"recovery_code"`
 	Referral      string `json:"referral"`
 }
 
+type LoginNonceResponse struct {
+	Nonce     string    `json:"nonce"`
+	Message   string    `json:"message"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
 type LoginUserResponse struct {
 	SessionID             uuid.UUID `json:"session_id"`
 	AccessToken           string    `json:"access_token"`
@@ -31,3 +61,14 @@ type LoginUserResponse struct {
 	WalletAddress         string    `json:"wallet_address"`
 	WalletPrivateKey      string    `json:"wallet_private_key"`
 }
+
+type CreateApiKeyRequest struct {
+	Name      string     `json:"name"   binding:"required"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type OidcCallbackRequest struct {
+	Code  string `json:"code"  binding:"required"`
+	State string `json:"state" binding:"required"`
+}
@@ -0,0 +1,18 @@
+package form
+
+// CreateCorrectionRequest files a correction of a single time of a work day.
+// The time is not required to delete a break.
+type CreateCorrectionRequest struct {
+	WorkerCode string `json:"worker_code" binding:"required"`
+	Pin        string `json:"pin"`
+	Date       string `json:"date"        binding:"required"`
+	Type       string `json:"type"        binding:"required"`
+	Time       string `json:"time"`
+	BreakIndex int    `json:"break_index"`
+	BreakType  string `json:"break_type"`
+	Reason     string `json:"reason"      binding:"required"`
+}
+
+type ReviewCorrectionRequest struct {
+	Note string `json:"note"`
+}
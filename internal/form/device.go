@@ -0,0 +1,24 @@
+package form
+
+import "time"
+
+type CreateDeviceRequest struct {
+	Name string `json:"name" binding:"required"`
+	Site string `json:"site"`
+}
+
+type UpdateDeviceRequest struct {
+	Name    string `json:"name"`
+	Site    string `json:"site"`
+	Enabled *bool  `json:"enabled"`
+}
+
+type EnrollDeviceRequest struct {
+	UID    string `json:"uid"    binding:"required"`
+	Secret string `json:"secret" binding:"required"`
+}
+
+type EnrollDeviceResponse struct {
+	AccessToken          string    `json:"access_token"`
+	AccessTokenExpiresAt time.Time `json:"access_token_expires_at"`
+}
@@ -0,0 +1,24 @@
+package form
+
+import "github.com/alexanderbkl/vidre-back/pkg/geo"
+
+// GeofenceRequest defines a geofence. Circles need the latitude, longitude
+// and radius in meters, polygons at least three points.
+type GeofenceRequest struct {
+	Site      string      `json:"site"`
+	Name      string      `json:"name"      binding:"required"`
+	Kind      string      `json:"kind"      binding:"required"`
+	Latitude  float64     `json:"latitude"`
+	Longitude float64     `json:"longitude"`
+	Radius    float64     `json:"radius"`
+	Points    geo.Polygon `json:"points"`
+	Enabled   *bool       `json:"enabled"`
+}
+
+// Location is the optional location of a punch from a phone. Latitude and
+// longitude are given in degrees, the accuracy in meters.
+type Location struct {
+	Latitude  *float64 `json:"latitude"`
+	Longitude *float64 `json:"longitude"`
+	Accuracy  *float64 `json:"accuracy"`
+}
@@ -0,0 +1,33 @@
+package form
+
+// SyncPunch is a punch that a kiosk queued while offline.
+type SyncPunch struct {
+	Sequence   *int64 `json:"sequence"    binding:"required"`
+	WorkerCode string `json:"worker_code" binding:"required"`
+	Type       string `json:"type"        binding:"required"`
+	Time       string `json:"time"        binding:"required"`
+	BreakType  string `json:"break_type"`
+	Pin        string `json:"pin"`
+	Location
+}
+
+type SyncPunchesRequest struct {
+	Punches []SyncPunch `json:"punches" binding:"required,dive"`
+}
+
+// SyncPunchResult is the result of a synced punch. Status is "applied",
+// "duplicate", "flagged" or "invalid".
+type SyncPunchResult struct {
+	Sequence int64  `json:"sequence"`
+	Status   string `json:"status"`
+	PunchID  uint   `json:"punch_id,omitempty"`
+	Date     string `json:"date,omitempty"`
+	Review   string `json:"review,omitempty"`
+	Geofence string `json:"geofence,omitempty"`
+	Message  string `json:"message,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+type SyncPunchesResponse struct {
+	Results []SyncPunchResult `json:"results"`
+}
@@ -0,0 +1,53 @@
+package form
+
+// WorkTimes are worked times in seconds, see package worktime.
+type WorkTimes struct {
+	Gross        int64 `json:"gross_seconds"`
+	Breaks       int64 `json:"breaks_seconds"`
+	PaidBreaks   int64 `json:"paid_breaks_seconds"`
+	UnpaidBreaks int64 `json:"unpaid_breaks_seconds"`
+	Net          int64 `json:"net_seconds"`
+}
+
+type WorkDaySummary struct {
+	Date     string `json:"date"`
+	Complete bool   `json:"complete"`
+	WorkTimes
+}
+
+type WorkDaysSummaryResponse struct {
+	WorkerCode     string           `json:"worker_code"`
+	StartDate      string           `json:"start_date"`
+	EndDate        string           `json:"end_date"`
+	Days           []WorkDaySummary `json:"days"`
+	Total          WorkTimes        `json:"total"`
+	IncompleteDays int              `json:"incomplete_days"`
+}
+
+// OvertimeTimes are regular and overtime worked times in seconds.
+type OvertimeTimes struct {
+	Regular  int64 `json:"regular_seconds"`
+	Overtime int64 `json:"overtime_seconds"`
+	Window   int64 `json:"window_seconds"`
+	Weekend  int64 `json:"weekend_seconds"`
+	Festivo  int64 `json:"festivo_seconds"`
+}
+
+type OvertimeDay struct {
+	Date string `json:"date"`
+	OvertimeTimes
+}
+
+type OvertimeMonth struct {
+	Month string `json:"month"`
+	OvertimeTimes
+}
+
+type OvertimeResponse struct {
+	WorkerCode string          `json:"worker_code"`
+	StartDate  string          `json:"start_date"`
+	EndDate    string          `json:"end_date"`
+	Days       []OvertimeDay   `json:"days"`
+	Months     []OvertimeMonth `json:"months"`
+	Total      OvertimeTimes   `json:"total"`
+}
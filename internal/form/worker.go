@@ -1,11 +1,19 @@
 package form
 
 type CreateWorkerRequest struct {
-	Name string `json:"name" binding:"required"`
-	Code string `json:"code" binding:"required"`
+	Name   string `json:"name"   binding:"required"`
+	Code   string `json:"code"   binding:"required"`
+	Reason string `json:"reason"`
 }
 
 type ModifyWorkerRequest struct {
-	Name string `json:"name" binding:"required"`
-	Code string `json:"code" binding:"required"`
-}
\ No newline at end of file
+	Name   string `json:"name"   binding:"required"`
+	Code   string `json:"code"   binding:"required"`
+	Reason string `json:"reason"`
+}
+
+type SetWorkerPinRequest struct {
+	Code   string `json:"code"   binding:"required"`
+	Pin    string `json:"pin"    binding:"required"`
+	Reason string `json:"reason"`
+}
@@ -0,0 +1,228 @@
+/*
+Package importer imports workers and historical work days from CSV files.
+
+Columns are matched by their header, so that their order does not matter and
+Spanish titles are accepted too. Fields may be separated by commas or
+semicolons, as Excel writes them with Spanish settings.
+
+Every row is validated before anything is written, and the errors of all
+rows are reported together with their line numbers. An import either writes
+all rows in a single transaction or nothing at all. In a dry run the rows are
+imported and the transaction is rolled back, so that the report previews the
+changes.
+*/
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Import actions.
+const (
+	ActionCreate  = "create"
+	ActionReplace = "replace"
+)
+
+var (
+	ErrEmptyFile     = errors.New("import: file is empty")
+	ErrMissingColumn = errors.New("import: missing column")
+)
+
+// errRollback rolls back the transaction of a dry run or of an import with
+// invalid rows.
+var errRollback = errors.New("import: rollback")
+
+// RowError is a validation error of a row.
+type RowError struct {
+	Row     int    `json:"row"`
+	Column  string `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+// Change is a change of an imported row.
+type Change struct {
+	Row        int    `json:"row"`
+	Action     string `json:"action"`
+	WorkerCode string `json:"worker_code"`
+	Name       string `json:"name,omitempty"`
+	Date       string `json:"date,omitempty"`
+}
+
+// Report is the result of an import. The changes are previewed unless the
+// import has been committed.
+type Report struct {
+	DryRun    bool       `json:"dry_run"`
+	Committed bool       `json:"committed"`
+	Rows      int        `json:"rows"`
+	Created   int        `json:"created"`
+	Replaced  int        `json:"replaced"`
+	Changes   []Change   `json:"changes"`
+	Errors    []RowError `json:"errors"`
+}
+
+// Valid tests if all rows are valid.
+func (r *Report) Valid() bool {
+	return len(r.Errors) == 0
+}
+
+// Fail adds a validation error of a row.
+func (r *Report) Fail(row int, column, format string, args ...interface{}) {
+	r.Errors = append(r.Errors, RowError{Row: row, Column: column, Message: fmt.Sprintf(format, args...)})
+}
+
+// record adds a change.
+func (r *Report) record(change Change) {
+	if change.Action == ActionReplace {
+		r.Replaced++
+	} else {
+		r.Created++
+	}
+
+	r.Changes = append(r.Changes, change)
+}
+
+// column is a column of an import file with the header titles it accepts.
+type column struct {
+	name     string
+	titles   []string
+	required bool
+}
+
+// table reads the rows of a CSV file by column name.
+type table struct {
+	reader *csv.Reader
+	index  map[string]int
+}
+
+// newTable reads the header of a CSV file and looks up the columns.
+func newTable(r io.Reader, columns []column) (*table, error) {
+	in := bufio.NewReader(r)
+
+	first, err := in.Peek(4096)
+	if len(first) == 0 {
+		if err == nil || err == io.EOF {
+			return nil, ErrEmptyFile
+		}
+		return nil, err
+	}
+
+	t := &table{reader: csv.NewReader(in)}
+
+	if line := bytes.SplitN(first, []byte("\n"), 2)[0]; bytes.Count(line, []byte(";")) > bytes.Count(line, []byte(",")) {
+		t.reader.Comma = ';'
+	}
+
+	if err := t.readHeader(columns); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func (t *table) readHeader(columns []column) error {
+	t.reader.FieldsPerRecord = -1
+	t.reader.TrimLeadingSpace = true
+
+	header, err := t.reader.Read()
+	if err == io.EOF {
+		return ErrEmptyFile
+	} else if err != nil {
+		return err
+	}
+
+	titles := make(map[string]int, len(header))
+	for i, title := range header {
+		titles[normalize(title)] = i
+	}
+
+	t.index = make(map[string]int, len(columns))
+
+	for _, c := range columns {
+		for _, title := range c.titles {
+			if i, ok := titles[title]; ok {
+				t.index[c.name] = i
+				break
+			}
+		}
+
+		if _, ok := t.index[c.name]; !ok && c.required {
+			return fmt.Errorf("%w %s", ErrMissingColumn, c.name)
+		}
+	}
+
+	return nil
+}
+
+// row is a row of a CSV file.
+type row struct {
+	line   int
+	fields []string
+	index  map[string]int
+}
+
+// get returns the trimmed value of a column, or an empty string if the file
+// has no such column.
+func (r row) get(name string) string {
+	i, ok := r.index[name]
+	if !ok || i >= len(r.fields) {
+		return ""
+	}
+
+	return strings.TrimSpace(r.fields[i])
+}
+
+// next returns the next row that is not empty. It returns io.EOF at the end
+// of the file.
+func (t *table) next() (row, error) {
+	for {
+		fields, err := t.reader.Read()
+		if err != nil {
+			return row{}, err
+		}
+
+		line, _ := t.reader.FieldPos(0)
+
+		if strings.TrimSpace(strings.Join(fields, "")) == "" {
+			continue
+		}
+
+		return row{line: line, fields: fields, index: t.index}, nil
+	}
+}
+
+// normalize returns the header title in lower case without byte order mark,
+// surrounding spaces and accents, e.g. "código" becomes "codigo".
+func normalize(title string) string {
+	title = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(title, "\ufeff")))
+
+	return strings.NewReplacer("á", "a", "é", "e", "í", "i", "ó", "o", "ú", "u", " ", "_").Replace(title)
+}
+
+// each calls fn for each row and counts the rows. A malformed row is reported
+// and ends the file, as the rows after it cannot be read reliably.
+func (t *table) each(report *Report, fn func(row)) error {
+	for {
+		r, err := t.next()
+
+		var parseErr *csv.ParseError
+
+		switch {
+		case err == io.EOF:
+			return nil
+		case errors.As(err, &parseErr):
+			report.Fail(parseErr.Line, "", "%s", parseErr.Err)
+			return nil
+		case err != nil:
+			return err
+		}
+
+		report.Rows++
+		fn(r)
+	}
+}
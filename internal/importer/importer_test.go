@@ -0,0 +1,207 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexanderbkl/vidre-back/internal/entity"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseWorkers(t *testing.T) {
+	csv := "Código;Nombre\n" +
+		"0042;Begoña Núñez\n" +
+		"\n" +
+		"0007;\n" +
+		";Sin código\n" +
+		"0042;Otra persona\n" +
+		"0008;Jordi Puig\n"
+
+	rows, report, err := ParseWorkers(strings.NewReader(csv))
+	require.NoError(t, err)
+
+	require.Equal(t, []WorkerRow{
+		{Row: 2, Code: "0042", Name: "Begoña Núñez"},
+		{Row: 7, Code: "0008", Name: "Jordi Puig"},
+	}, rows)
+
+	require.Equal(t, 5, report.Rows)
+	require.Equal(t, []RowError{
+		{Row: 4, Column: "name", Message: "name is required"},
+		{Row: 5, Column: "code", Message: "code is required"},
+		{Row: 6, Column: "code", Message: "code 0042 is already used in row 2"},
+	}, report.Errors)
+}
+
+func TestParseWorkersHeader(t *testing.T) {
+	_, _, err := ParseWorkers(strings.NewReader(""))
+	require.ErrorIs(t, err, ErrEmptyFile)
+
+	_, _, err = ParseWorkers(strings.NewReader("code,surname\n0042,Núñez\n"))
+	require.ErrorIs(t, err, ErrMissingColumn)
+
+	rows, report, err := ParseWorkers(strings.NewReader("\ufeffName,Code\r\nBegoña Núñez,0042\r\n"))
+	require.NoError(t, err)
+	require.True(t, report.Valid())
+	require.Equal(t, []WorkerRow{{Row: 2, Code: "0042", Name: "Begoña Núñez"}}, rows)
+
+	_, report, err = ParseWorkers(strings.NewReader("code,name\n\"0042,Begoña\n"))
+	require.NoError(t, err)
+	require.Len(t, report.Errors, 1)
+	require.Equal(t, 2, report.Errors[0].Row)
+}
+
+func TestParseWorkDays(t *testing.T) {
+	madrid, err := time.LoadLocation("Europe/Madrid")
+	require.NoError(t, err)
+
+	local := func(day, hour, min int) time.Time {
+		return time.Date(2024, 3, day, hour, min, 0, 0, madrid)
+	}
+
+	testCases := []struct {
+		name     string
+		row      string
+		expected *entity.WorkSchedule
+		err      RowError
+	}{
+		{
+			name: "Breaks",
+			row:  "0042,2024-03-04,08:00,17:00,\"10:00-10:15 R, 14:00 - 15:00\"",
+			expected: &entity.WorkSchedule{
+				EntryHour: local(4, 8, 0),
+				ExitHour:  local(4, 17, 0),
+				Breaks: entity.WorkBreaks{
+					{Position: 0, Type: entity.BreakPaid, StartHour: local(4, 10, 0), EndHour: local(4, 10, 15)},
+					{Position: 1, Type: entity.BreakUnpaid, StartHour: local(4, 14, 0), EndHour: local(4, 15, 0)},
+				},
+			},
+		},
+		{
+			name: "NightShift",
+			row:  "0042,04/03/2024,22:00,06:00,02:00-02:30",
+			expected: &entity.WorkSchedule{
+				EntryHour: local(4, 22, 0),
+				ExitHour:  local(5, 6, 0),
+				Breaks: entity.WorkBreaks{
+					{Type: entity.BreakUnpaid, StartHour: local(5, 2, 0), EndHour: local(5, 2, 30)},
+				},
+			},
+		},
+		{
+			name: "Seconds",
+			row:  "0042,2024-03-04,08:00:30,16:00:00,",
+			expected: &entity.WorkSchedule{
+				EntryHour: time.Date(2024, 3, 4, 8, 0, 30, 0, madrid),
+				ExitHour:  local(4, 16, 0),
+			},
+		},
+		{
+			name: "MissingWorkerCode",
+			row:  ",2024-03-04,08:00,16:00,",
+			err:  RowError{Row: 2, Column: "worker_code", Message: "worker code is required"},
+		},
+		{
+			name: "InvalidDate",
+			row:  "0042,2024-02-30,08:00,16:00,",
+			err:  RowError{Row: 2, Column: "date", Message: `invalid date "2024-02-30"`},
+		},
+		{
+			name: "InvalidEntry",
+			row:  "0042,2024-03-04,8h,16:00,",
+			err:  RowError{Row: 2, Column: "entry_hour", Message: `invalid time "8h"`},
+		},
+		{
+			name: "MissingExit",
+			row:  "0042,2024-03-04,08:00,,",
+			err:  RowError{Row: 2, Column: "exit_hour", Message: "time is required"},
+		},
+		{
+			name: "EmptyWorkDay",
+			row:  "0042,2024-03-04,08:00,08:00,",
+			err:  RowError{Row: 2, Column: "exit_hour", Message: "exit must be after entry"},
+		},
+		{
+			name: "TooLong",
+			row:  "0042,2024-03-04,08:00,07:00,",
+			err:  RowError{Row: 2, Column: "exit_hour", Message: "work day is longer than 16h0m0s"},
+		},
+		{
+			name: "InvalidBreak",
+			row:  "0042,2024-03-04,08:00,16:00,10:00",
+			err:  RowError{Row: 2, Column: "breaks", Message: "break 1 must be given as start-end"},
+		},
+		{
+			name: "InvalidBreakTime",
+			row:  "0042,2024-03-04,08:00,16:00,\"10:00-10:15, 12:00-1pm\"",
+			err:  RowError{Row: 2, Column: "breaks", Message: `break 2: invalid time "1pm"`},
+		},
+		{
+			name: "BreakOutside",
+			row:  "0042,2024-03-04,08:00,16:00,15:30-16:30",
+			err:  RowError{Row: 2, Column: "breaks", Message: "break 1 is outside of the work day"},
+		},
+		{
+			name: "BreakBeforeEntry",
+			row:  "0042,2024-03-04,08:00,16:00,07:30-07:45",
+			err:  RowError{Row: 2, Column: "breaks", Message: "break 1 is outside of the work day"},
+		},
+		{
+			name: "OverlappingBreaks",
+			row:  "0042,2024-03-04,08:00,16:00,\"13:00-14:00, 10:00-10:30, 13:30-14:30\"",
+			err:  RowError{Row: 2, Column: "breaks", Message: "break 3 overlaps break 1"},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			csv := "worker_code,date,entry_hour,exit_hour,breaks\n" + tc.row + "\n"
+
+			rows, report, err := ParseWorkDays(strings.NewReader(csv), madrid, 16*time.Hour)
+			require.NoError(t, err)
+			require.Equal(t, 1, report.Rows)
+
+			if tc.expected == nil {
+				require.Empty(t, rows)
+				require.Equal(t, []RowError{tc.err}, report.Errors)
+				return
+			}
+
+			require.True(t, report.Valid(), report.Errors)
+			require.Len(t, rows, 1)
+
+			s := rows[0].Schedule
+			require.Equal(t, "0042", rows[0].WorkerCode)
+			require.Equal(t, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), s.Date)
+			require.True(t, tc.expected.EntryHour.Equal(s.EntryHour), s.EntryHour)
+			require.True(t, tc.expected.ExitHour.Equal(s.ExitHour), s.ExitHour)
+			require.Len(t, s.Breaks, len(tc.expected.Breaks))
+
+			for i, b := range tc.expected.Breaks {
+				require.Equal(t, b.Type, s.Breaks[i].Type)
+				require.True(t, b.StartHour.Equal(s.Breaks[i].StartHour), s.Breaks[i].StartHour)
+				require.True(t, b.EndHour.Equal(s.Breaks[i].EndHour), s.Breaks[i].EndHour)
+			}
+		})
+	}
+}
+
+func TestParseWorkDaysFile(t *testing.T) {
+	csv := "Código;Fecha;Entrada;Salida\n" +
+		"0042;04/03/2024;08:00;16:00\n" +
+		"0042;2024-03-04;09:00;17:00\n" +
+		"0007;2024-03-04;09:00;17:00\n"
+
+	rows, report, err := ParseWorkDays(strings.NewReader(csv), time.UTC, 0)
+	require.NoError(t, err)
+	require.Equal(t, 3, report.Rows)
+	require.Equal(t, []RowError{{Row: 3, Column: "date", Message: "work day is already given in row 2"}}, report.Errors)
+	require.Len(t, rows, 2)
+	require.Equal(t, "0007", rows[1].WorkerCode)
+
+	_, _, err = ParseWorkDays(strings.NewReader("worker_code,date,entry_hour\n"), time.UTC, 0)
+	require.ErrorIs(t, err, ErrMissingColumn)
+}
@@ -0,0 +1,323 @@
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alexanderbkl/vidre-back/internal/audit"
+	"github.com/alexanderbkl/vidre-back/internal/clock"
+	"github.com/alexanderbkl/vidre-back/internal/entity"
+	"gorm.io/gorm"
+)
+
+// workDayColumns contains the columns of a work day import file.
+var workDayColumns = []column{
+	{name: "worker_code", titles: []string{"worker_code", "code", "codigo"}, required: true},
+	{name: "date", titles: []string{"date", "fecha"}, required: true},
+	{name: "entry_hour", titles: []string{"entry_hour", "enter_hour", "enterhour", "entry", "entrada"}, required: true},
+	{name: "exit_hour", titles: []string{"exit_hour", "exithour", "exit", "salida"}, required: true},
+	{name: "breaks", titles: []string{"breaks", "descansos"}},
+}
+
+// dateLayouts contains the accepted date formats.
+var dateLayouts = []string{"2006-01-02", "02/01/2006"}
+
+// clockLayouts contains the accepted time formats.
+var clockLayouts = []string{"15:04", "15:04:05"}
+
+// WorkDayRow is a row of a work day import file.
+type WorkDayRow struct {
+	Row        int
+	WorkerCode string
+	Date       time.Time
+	Schedule   entity.WorkSchedule
+}
+
+// ParseWorkDays reads and validates the rows of a work day import file.
+//
+// Times are wall clock times in the location. A time before the entry is on
+// the next day, e.g. the exit of a night shift. Breaks are given as a comma
+// separated list of intervals, where paid breaks are marked with "R", e.g.
+// "10:00-10:15 R, 14:00-15:00". Breaks must lie within the work day and must
+// not overlap, and a work day must not be longer than maxShift. Each worker
+// may have one row per date.
+func ParseWorkDays(r io.Reader, loc *time.Location, maxShift time.Duration) ([]WorkDayRow, *Report, error) {
+	t, err := newTable(r, workDayColumns)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	report := &Report{}
+
+	var rows []WorkDayRow
+	seen := make(map[string]int)
+
+	err = t.each(report, func(r row) {
+		errs := len(report.Errors)
+
+		day := WorkDayRow{Row: r.line, WorkerCode: r.get("worker_code")}
+		if day.WorkerCode == "" {
+			report.Fail(day.Row, "worker_code", "worker code is required")
+		}
+
+		date, err := parseDate(r.get("date"))
+		if err != nil {
+			report.Fail(day.Row, "date", "%s", err)
+			return
+		}
+
+		day.Date = date
+
+		key := day.WorkerCode + " " + day.Date.Format("2006-01-02")
+		if first, ok := seen[key]; ok {
+			report.Fail(day.Row, "date", "work day is already given in row %d", first)
+		} else {
+			seen[key] = day.Row
+		}
+
+		if parseSchedule(report, r, &day, loc, maxShift) && len(report.Errors) == errs {
+			rows = append(rows, day)
+		}
+	})
+
+	return rows, report, err
+}
+
+// parseSchedule parses the times and breaks of a row and reports whether
+// they are valid.
+func parseSchedule(report *Report, r row, day *WorkDayRow, loc *time.Location, maxShift time.Duration) bool {
+	s := &day.Schedule
+	s.Date = day.Date
+
+	entry, err := at(day.Date, r.get("entry_hour"), loc, time.Time{})
+	if err != nil {
+		report.Fail(day.Row, "entry_hour", "%s", err)
+		return false
+	}
+
+	exit, err := at(day.Date, r.get("exit_hour"), loc, entry)
+	if err != nil {
+		report.Fail(day.Row, "exit_hour", "%s", err)
+		return false
+	}
+
+	s.EntryHour, s.ExitHour = entry, exit
+
+	if !exit.After(entry) {
+		report.Fail(day.Row, "exit_hour", "exit must be after entry")
+		return false
+	} else if maxShift > 0 && exit.Sub(entry) > maxShift {
+		report.Fail(day.Row, "exit_hour", "work day is longer than %s", maxShift)
+		return false
+	}
+
+	breaks, err := parseBreaks(day.Date, r.get("breaks"), loc, entry)
+	if err != nil {
+		report.Fail(day.Row, "breaks", "%s", err)
+		return false
+	}
+
+	for i, b := range breaks {
+		if b.StartHour.Before(entry) || b.EndHour.After(exit) {
+			report.Fail(day.Row, "breaks", "break %d is outside of the work day", i+1)
+			return false
+		}
+	}
+
+	if i, j, ok := Overlap(breaks); ok {
+		report.Fail(day.Row, "breaks", "break %d overlaps break %d", j+1, i+1)
+		return false
+	}
+
+	s.Breaks = breaks
+
+	return true
+}
+
+// parseBreaks parses a list of breaks, e.g. "10:00-10:15 R, 14:00-15:00".
+func parseBreaks(date time.Time, value string, loc *time.Location, entry time.Time) (entity.WorkBreaks, error) {
+	var breaks entity.WorkBreaks
+
+	for i, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		b := entity.WorkBreak{Position: len(breaks), Type: entity.BreakUnpaid}
+
+		if strings.HasSuffix(strings.ToUpper(item), " R") {
+			b.Type = entity.BreakPaid
+			item = strings.TrimSpace(item[:len(item)-2])
+		}
+
+		start, end, ok := strings.Cut(item, "-")
+		if !ok {
+			return nil, fmt.Errorf("break %d must be given as start-end", i+1)
+		}
+
+		var err error
+		if b.StartHour, err = at(date, strings.TrimSpace(start), loc, entry); err != nil {
+			return nil, fmt.Errorf("break %d: %s", i+1, err)
+		}
+
+		if b.EndHour, err = at(date, strings.TrimSpace(end), loc, b.StartHour); err != nil {
+			return nil, fmt.Errorf("break %d: %s", i+1, err)
+		}
+
+		if !b.EndHour.After(b.StartHour) {
+			return nil, fmt.Errorf("break %d must end after it starts", i+1)
+		}
+
+		breaks = append(breaks, b)
+	}
+
+	return breaks, nil
+}
+
+// Overlap returns the indexes of two overlapping breaks.
+func Overlap(breaks entity.WorkBreaks) (int, int, bool) {
+	order := make([]int, len(breaks))
+	for i := range order {
+		order[i] = i
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		return breaks[order[i]].StartHour.Before(breaks[order[j]].StartHour)
+	})
+
+	for k := 1; k < len(order); k++ {
+		prev, cur := order[k-1], order[k]
+		if breaks[cur].StartHour.Before(breaks[prev].EndHour) {
+			if prev > cur {
+				prev, cur = cur, prev
+			}
+			return prev, cur, true
+		}
+	}
+
+	return 0, 0, false
+}
+
+// parseDate parses a date, e.g. "2024-03-04" or "04/03/2024".
+func parseDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, errors.New("date is required")
+	}
+
+	for _, layout := range dateLayouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid date %q", value)
+}
+
+// at returns the wall clock time on the date in the location. A time before
+// notBefore is on the next day.
+func at(date time.Time, value string, loc *time.Location, notBefore time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, errors.New("time is required")
+	}
+
+	var c time.Time
+	var err error
+
+	for _, layout := range clockLayouts {
+		if c, err = time.Parse(layout, value); err == nil {
+			break
+		}
+	}
+
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q", value)
+	}
+
+	t := time.Date(date.Year(), date.Month(), date.Day(), c.Hour(), c.Minute(), c.Second(), 0, loc)
+	if t.Before(notBefore) {
+		t = time.Date(date.Year(), date.Month(), date.Day()+1, c.Hour(), c.Minute(), c.Second(), 0, loc)
+	}
+
+	return t, nil
+}
+
+// ImportWorkDays replaces the work days of the rows in a single transaction
+// by appending correction punches based on the given punch, and records the
+// changes in the audit log with the reason. Nothing is written if a worker
+// does not exist, if the report contains errors or in a dry run.
+func ImportWorkDays(db *gorm.DB, rows []WorkDayRow, base entity.Punch, actor audit.Actor, reason string, report *Report) error {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		codes := make([]string, 0, len(rows))
+		for _, day := range rows {
+			codes = append(codes, day.WorkerCode)
+		}
+
+		var workers entity.Workers
+		if len(codes) > 0 {
+			if err := tx.Where("code IN ?", codes).Find(&workers).Error; err != nil {
+				return err
+			}
+		}
+
+		ids := make(map[string]uint, len(workers))
+		for _, w := range workers {
+			ids[w.Code] = w.ID
+		}
+
+		for i := range rows {
+			day := &rows[i]
+
+			workerID, ok := ids[day.WorkerCode]
+			if !ok {
+				report.Fail(day.Row, "worker_code", "worker %s does not exist", day.WorkerCode)
+				continue
+			}
+
+			existing, err := clock.TxDay(tx, workerID, day.Date)
+			if err != nil {
+				return err
+			}
+
+			reset := base
+			reset.Type = entity.PunchReset
+			reset.Time = time.Now().UTC()
+
+			punches := append(entity.Punches{reset}, clock.SetPunches(&day.Schedule, base)...)
+
+			schedule, err := clock.TxCorrect(tx, workerID, day.Date, punches)
+			if err != nil {
+				return err
+			}
+
+			if err := audit.WorkDay(tx, actor, reason, existing, schedule); err != nil {
+				return err
+			}
+
+			change := Change{Row: day.Row, Action: ActionCreate, WorkerCode: day.WorkerCode, Date: day.Date.Format("2006-01-02")}
+			if existing != nil {
+				change.Action = ActionReplace
+			}
+
+			report.record(change)
+		}
+
+		if !report.Valid() || report.DryRun {
+			return errRollback
+		}
+
+		return nil
+	})
+
+	if errors.Is(err, errRollback) {
+		return nil
+	}
+
+	report.Committed = err == nil
+
+	return err
+}
@@ -0,0 +1,119 @@
+package importer
+
+import (
+	"errors"
+	"io"
+
+	"github.com/alexanderbkl/vidre-back/internal/audit"
+	"github.com/alexanderbkl/vidre-back/internal/entity"
+	"gorm.io/gorm"
+)
+
+// workerColumns contains the columns of a worker import file.
+var workerColumns = []column{
+	{name: "code", titles: []string{"code", "worker_code", "codigo"}, required: true},
+	{name: "name", titles: []string{"name", "nombre", "trabajador"}, required: true},
+}
+
+// WorkerRow is a row of a worker import file.
+type WorkerRow struct {
+	Row  int
+	Code string
+	Name string
+}
+
+// ParseWorkers reads and validates the rows of a worker import file with
+// the columns code and name. Codes must be unique within the file.
+func ParseWorkers(r io.Reader) ([]WorkerRow, *Report, error) {
+	t, err := newTable(r, workerColumns)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	report := &Report{}
+
+	var rows []WorkerRow
+	seen := make(map[string]int)
+
+	err = t.each(report, func(r row) {
+		w := WorkerRow{Row: r.line, Code: r.get("code"), Name: r.get("name")}
+		valid := true
+
+		if w.Code == "" {
+			report.Fail(w.Row, "code", "code is required")
+			valid = false
+		} else if first, ok := seen[w.Code]; ok {
+			report.Fail(w.Row, "code", "code %s is already used in row %d", w.Code, first)
+			valid = false
+		} else {
+			seen[w.Code] = w.Row
+		}
+
+		if w.Name == "" {
+			report.Fail(w.Row, "name", "name is required")
+			valid = false
+		}
+
+		if valid {
+			rows = append(rows, w)
+		}
+	})
+
+	return rows, report, err
+}
+
+// ImportWorkers creates the workers in a single transaction and records them
+// in the audit log with the reason. Nothing is written if a code is already
+// taken by an existing worker, if the report contains errors or in a dry run.
+func ImportWorkers(db *gorm.DB, rows []WorkerRow, actor audit.Actor, reason string, report *Report) error {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		codes := make([]string, len(rows))
+		for i, w := range rows {
+			codes[i] = w.Code
+		}
+
+		var existing entity.Workers
+		if len(codes) > 0 {
+			if err := tx.Unscoped().Where("code IN ?", codes).Find(&existing).Error; err != nil {
+				return err
+			}
+		}
+
+		taken := make(map[string]bool, len(existing))
+		for _, w := range existing {
+			taken[w.Code] = true
+		}
+
+		for _, w := range rows {
+			if taken[w.Code] {
+				report.Fail(w.Row, "code", "worker %s already exists", w.Code)
+				continue
+			}
+
+			worker := entity.Worker{Code: w.Code, Name: w.Name}
+			if err := worker.TxCreate(tx); err != nil {
+				return err
+			}
+
+			if err := audit.Record(tx, actor, reason, entity.AuditWorker, worker.ID, nil, &worker); err != nil {
+				return err
+			}
+
+			report.record(Change{Row: w.Row, Action: ActionCreate, WorkerCode: w.Code, Name: w.Name})
+		}
+
+		if !report.Valid() || report.DryRun {
+			return errRollback
+		}
+
+		return nil
+	})
+
+	if errors.Is(err, errRollback) {
+		return nil
+	}
+
+	report.Committed = err == nil
+
+	return err
+}
@@ -9,14 +9,20 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/alexanderbkl/vidre-back/internal/api"
 	"github.com/alexanderbkl/vidre-back/internal/constant"
+	"github.com/alexanderbkl/vidre-back/internal/entity"
 	"github.com/alexanderbkl/vidre-back/pkg/token"
 	"github.com/gin-gonic/gin"
 )
 
-// AuthMiddleware creates a gin middleware for authorization
+// apiKeyUsedInterval limits how often the last used timestamp of an api key is written.
+const apiKeyUsedInterval = time.Minute
+
+// AuthMiddleware creates a gin middleware for authorization with a bearer
+// token or an api key. Api keys are only admitted by RequireScope.
 func AuthMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		authorizationHeader := ctx.GetHeader(constant.AuthorizationHeaderKey)
@@ -35,20 +41,48 @@ func AuthMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
 		}
 
 		authorizationType := strings.ToLower(fields[0])
-		if authorizationType != constant.AuthorizationTypeBearer {
-			err := fmt.Errorf("unsupported authorization type %s", authorizationType)
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, api.ErrorResponse(err))
-			return
-		}
+		switch authorizationType {
+		case constant.AuthorizationTypeBearer:
+			accessToken := fields[1]
+			payload, err := tokenMaker.VerifyToken(accessToken)
+			if err != nil {
+				ctx.AbortWithStatusJSON(http.StatusUnauthorized, api.ErrorResponse(err))
+				return
+			}
 
-		accessToken := fields[1]
-		payload, err := tokenMaker.VerifyToken(accessToken)
-		if err != nil {
+			// Refresh tokens can only be exchanged for new tokens.
+			if payload.Purpose != token.PurposeAccess {
+				ctx.AbortWithStatusJSON(http.StatusUnauthorized, api.ErrorResponse(token.ErrWrongPurpose))
+				return
+			}
+
+			// Logouts and revoked sessions also cut off their access tokens.
+			if err := api.VerifySession(payload); err != nil {
+				ctx.AbortWithStatusJSON(http.StatusUnauthorized, api.ErrorResponse(err))
+				return
+			}
+
+			ctx.Set(constant.AuthorizationPayloadKey, payload)
+		case constant.AuthorizationTypeApiKey:
+			key, err := entity.AuthenticateApiKey(fields[1])
+			if err != nil {
+				ctx.AbortWithStatusJSON(http.StatusUnauthorized, api.ErrorResponse(err))
+				return
+			}
+
+			if key.LastUsedAt == nil || time.Since(*key.LastUsedAt) > apiKeyUsedInterval {
+				if err := key.Used(); err != nil {
+					log.Errorf("api key: cannot update last used (%s)", err)
+				}
+			}
+
+			ctx.Set(constant.AuthorizationApiKeyKey, key)
+		default:
+			err := fmt.Errorf("unsupported authorization type %s", authorizationType)
 			ctx.AbortWithStatusJSON(http.StatusUnauthorized, api.ErrorResponse(err))
 			return
 		}
 
-		ctx.Set(constant.AuthorizationPayloadKey, payload)
 		ctx.Next()
 	}
 }
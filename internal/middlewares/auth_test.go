@@ -22,8 +22,9 @@ func addAuthorization(
 	authorizationType string,
 	username string,
 	duration time.Duration,
+	opts ...token.PayloadOption,
 ) {
-	token, payload, err := tokenMaker.CreateToken(1, username, username, duration)
+	token, payload, err := tokenMaker.CreateToken(1, username, username, duration, opts...)
 	require.NoError(t, err)
 	require.NotEmpty(t, payload)
 
@@ -72,6 +73,15 @@ func TestAuthMiddleware(t *testing.T) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
 			},
 		},
+		{
+			name: "RefreshToken",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, constant.AuthorizationTypeBearer, "user", time.Minute, token.WithPurpose(token.PurposeRefresh))
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
 		{
 			name: "ExpiredToken",
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
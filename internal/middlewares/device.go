@@ -0,0 +1,47 @@
+package middlewares
+
+import (
+	"time"
+
+	"github.com/alexanderbkl/vidre-back/internal/api"
+	"github.com/alexanderbkl/vidre-back/internal/constant"
+	"github.com/alexanderbkl/vidre-back/internal/entity"
+	"github.com/alexanderbkl/vidre-back/pkg/token"
+	"github.com/gin-gonic/gin"
+)
+
+// deviceSeenInterval limits how often the last seen timestamp of a device is written.
+const deviceSeenInterval = time.Minute
+
+// DeviceMiddleware creates a gin middleware that rejects device tokens of
+// devices that have been disabled or deleted, and tokens that have been
+// revoked. It must be used after AuthMiddleware.
+func DeviceMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		value, ok := ctx.Get(constant.AuthorizationPayloadKey)
+		if !ok {
+			ctx.Next()
+			return
+		}
+
+		payload, ok := value.(*token.Payload)
+		if !ok || payload.DeviceID == 0 {
+			ctx.Next()
+			return
+		}
+
+		device, err := entity.FindDeviceByID(payload.DeviceID)
+		if err != nil || !device.Enabled || device.Revoked(payload.IssuedAt) {
+			api.AbortUnauthorized(ctx)
+			return
+		}
+
+		if device.LastSeenAt == nil || time.Since(*device.LastSeenAt) > deviceSeenInterval {
+			if err := device.Seen(); err != nil {
+				log.Errorf("device: cannot update last seen (%s)", err)
+			}
+		}
+
+		ctx.Next()
+	}
+}
@@ -0,0 +1,195 @@
+package middlewares
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/alexanderbkl/vidre-back/internal/api"
+	"github.com/alexanderbkl/vidre-back/internal/constant"
+	"github.com/alexanderbkl/vidre-back/internal/entity"
+	"github.com/alexanderbkl/vidre-back/pkg/token"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// IdempotencyKeyHeader is the request header with the client idempotency key.
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader marks responses that have been replayed.
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+// Idempotency keys are stored in the database, tests replace the functions.
+var (
+	reserveIdempotencyKey  = entity.ReserveIdempotencyKey
+	completeIdempotencyKey = (*entity.IdempotencyKey).Complete
+	releaseIdempotencyKey  = (*entity.IdempotencyKey).Release
+)
+
+// maxIdempotentBody limits the size of requests with an idempotency key.
+const maxIdempotentBody = 1 << 20
+
+// Idempotency creates a gin middleware that makes requests with an
+// Idempotency-Key header safe to retry. The response to the first request
+// with a key is stored for the window and replayed to retries of the same
+// client, without running the handler again. Responses with server errors
+// are not stored, so that the request can be retried. It must be used after
+// AuthMiddleware.
+func Idempotency(window time.Duration) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		key := ctx.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			ctx.Next()
+			return
+		}
+
+		if !validIdempotencyKey(key) {
+			api.Abort(ctx, http.StatusBadRequest, "Invalid idempotency key")
+			return
+		}
+
+		client := idempotencyClient(ctx)
+		if client == "" {
+			ctx.Next()
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(ctx.Request.Body, maxIdempotentBody+1))
+		if err != nil {
+			api.Abort(ctx, http.StatusBadRequest, "Invalid request body")
+			return
+		} else if len(body) > maxIdempotentBody {
+			api.Abort(ctx, http.StatusRequestEntityTooLarge, "Request body too large")
+			return
+		}
+
+		ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		stored, created, err := reserveIdempotencyKey(&entity.IdempotencyKey{
+			Client:      client,
+			Key:         key,
+			Method:      ctx.Request.Method,
+			Path:        ctx.Request.URL.Path,
+			RequestHash: requestHash(ctx.Request.Method, ctx.Request.URL.Path, body),
+		}, window)
+
+		if err != nil {
+			log.Errorf("idempotency: cannot reserve key (%s)", err)
+			api.Abort(ctx, http.StatusInternalServerError, "Internal server error")
+			return
+		}
+
+		if !created {
+			replay(ctx, stored, requestHash(ctx.Request.Method, ctx.Request.URL.Path, body))
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: ctx.Writer}
+		ctx.Writer = recorder
+
+		// Release the key if the handler does not complete, e.g. on panic.
+		completed := false
+		defer func() {
+			if !completed {
+				if err := releaseIdempotencyKey(stored); err != nil {
+					log.Errorf("idempotency: cannot release key (%s)", err)
+				}
+			}
+		}()
+
+		ctx.Next()
+
+		if status := recorder.Status(); status >= http.StatusInternalServerError {
+			return
+		}
+
+		if err := completeIdempotencyKey(stored, recorder.Status(), recorder.Header().Get("Content-Type"), recorder.body.Bytes()); err != nil {
+			log.Errorf("idempotency: cannot store response (%s)", err)
+			return
+		}
+
+		completed = true
+	}
+}
+
+// replay responds with the stored response of the key. Requests that differ
+// from the first request with the key, or that arrive while it is still in
+// progress, are rejected.
+func replay(ctx *gin.Context, stored *entity.IdempotencyKey, hash string) {
+	switch {
+	case stored.RequestHash != hash:
+		api.Abort(ctx, http.StatusUnprocessableEntity, "Idempotency key has already been used for another request")
+	case !stored.Completed():
+		api.Abort(ctx, http.StatusConflict, "Request with this idempotency key is still in progress")
+	default:
+		ctx.Header(IdempotentReplayedHeader, "true")
+		ctx.Data(stored.StatusCode, stored.ContentType, stored.ResponseBody)
+		ctx.Abort()
+	}
+}
+
+// idempotencyClient returns the client that keys are unique for, i.e. the
+// device, user or api key of the request.
+func idempotencyClient(ctx *gin.Context) string {
+	if value, ok := ctx.Get(constant.AuthorizationPayloadKey); ok {
+		if payload, ok := value.(*token.Payload); ok {
+			if payload.DeviceID != 0 {
+				return fmt.Sprintf("device:%d", payload.DeviceID)
+			}
+			return fmt.Sprintf("user:%d", payload.UserID)
+		}
+	}
+
+	if value, ok := ctx.Get(constant.AuthorizationApiKeyKey); ok {
+		if key, ok := value.(*entity.ApiKey); ok {
+			return fmt.Sprintf("api_key:%d", key.ID)
+		}
+	}
+
+	return ""
+}
+
+// validIdempotencyKey tests if the key is printable ASCII and not too long.
+func validIdempotencyKey(key string) bool {
+	if len(key) > entity.IdempotencyKeyMaxLength {
+		return false
+	}
+
+	for i := 0; i < len(key); i++ {
+		if key[i] < ' ' || key[i] > '~' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// requestHash returns the fingerprint of a request, so that a key cannot be
+// reused for another request.
+func requestHash(method, path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method + " " + path + "\n"))
+	h.Write(body)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// responseRecorder keeps a copy of the response body.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *responseRecorder) WriteString(s string) (int, error) {
+	r.body.WriteString(s)
+	return r.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,209 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexanderbkl/vidre-back/internal/constant"
+	"github.com/alexanderbkl/vidre-back/internal/entity"
+	"github.com/alexanderbkl/vidre-back/pkg/token"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdempotency(t *testing.T) {
+	testCases := []struct {
+		name  string
+		key   string
+		calls int
+		code  int
+	}{
+		{name: "NoKey", calls: 1, code: http.StatusOK},
+		{name: "NoClient", key: "a3f1c2e4-punch-1", calls: 1, code: http.StatusOK},
+		{name: "InvalidKey", key: "punch\x01", code: http.StatusBadRequest},
+		{name: "TooLong", key: strings.Repeat("k", 256), code: http.StatusBadRequest},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			calls := 0
+
+			router := gin.New()
+			router.POST("/worker/work_day", Idempotency(time.Hour), func(ctx *gin.Context) {
+				calls++
+				ctx.JSON(http.StatusOK, gin.H{"message": "ok"})
+			})
+
+			request := httptest.NewRequest(http.MethodPost, "/worker/work_day", strings.NewReader(`{"type":"entry"}`))
+			if tc.key != "" {
+				request.Header.Set(IdempotencyKeyHeader, tc.key)
+			}
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+
+			require.Equal(t, tc.code, recorder.Code)
+			require.Equal(t, tc.calls, calls)
+		})
+	}
+}
+
+// stubIdempotencyKeys replaces the stored idempotency keys with a map.
+func stubIdempotencyKeys(t *testing.T) map[string]*entity.IdempotencyKey {
+	keys := make(map[string]*entity.IdempotencyKey)
+
+	reserve, complete, release := reserveIdempotencyKey, completeIdempotencyKey, releaseIdempotencyKey
+	t.Cleanup(func() {
+		reserveIdempotencyKey, completeIdempotencyKey, releaseIdempotencyKey = reserve, complete, release
+	})
+
+	reserveIdempotencyKey = func(key *entity.IdempotencyKey, ttl time.Duration) (*entity.IdempotencyKey, bool, error) {
+		if existing, ok := keys[key.Client+" "+key.Key]; ok {
+			stored := *existing
+			return &stored, false, nil
+		}
+
+		keys[key.Client+" "+key.Key] = key
+		return key, true, nil
+	}
+
+	completeIdempotencyKey = func(key *entity.IdempotencyKey, status int, contentType string, body []byte) error {
+		now := time.Now()
+		key.StatusCode, key.ContentType, key.ResponseBody, key.CompletedAt = status, contentType, body, &now
+		return nil
+	}
+
+	releaseIdempotencyKey = func(key *entity.IdempotencyKey) error {
+		delete(keys, key.Client+" "+key.Key)
+		return nil
+	}
+
+	return keys
+}
+
+func TestIdempotencyReplay(t *testing.T) {
+	const key = "a3f1c2e4-punch-1"
+
+	type request struct {
+		body     string
+		code     int
+		replayed bool
+	}
+
+	testCases := []struct {
+		name     string
+		status   int
+		pending  bool
+		requests []request
+		calls    int
+	}{
+		{
+			name:     "Replay",
+			status:   http.StatusOK,
+			requests: []request{{body: `{"type":"entry"}`, code: http.StatusOK}, {body: `{"type":"entry"}`, code: http.StatusOK, replayed: true}},
+			calls:    1,
+		},
+		{
+			name:     "ReplayRejected",
+			status:   http.StatusConflict,
+			requests: []request{{body: `{"type":"entry"}`, code: http.StatusConflict}, {body: `{"type":"entry"}`, code: http.StatusConflict, replayed: true}},
+			calls:    1,
+		},
+		{
+			name:     "OtherRequest",
+			status:   http.StatusOK,
+			requests: []request{{body: `{"type":"entry"}`, code: http.StatusOK}, {body: `{"type":"exit"}`, code: http.StatusUnprocessableEntity}},
+			calls:    1,
+		},
+		{
+			name:     "InProgress",
+			status:   http.StatusOK,
+			pending:  true,
+			requests: []request{{body: `{"type":"entry"}`, code: http.StatusConflict}},
+		},
+		{
+			name:     "ServerError",
+			status:   http.StatusInternalServerError,
+			requests: []request{{body: `{"type":"entry"}`, code: http.StatusInternalServerError}, {body: `{"type":"entry"}`, code: http.StatusInternalServerError}},
+			calls:    2,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			keys := stubIdempotencyKeys(t)
+			if tc.pending {
+				keys["device:1 "+key] = &entity.IdempotencyKey{Client: "device:1", Key: key, RequestHash: requestHash(http.MethodPost, "/worker/work_day", []byte(`{"type":"entry"}`))}
+			}
+
+			calls := 0
+
+			router := gin.New()
+			router.POST("/worker/work_day", func(ctx *gin.Context) {
+				ctx.Set(constant.AuthorizationPayloadKey, &token.Payload{DeviceID: 1})
+			}, Idempotency(time.Hour), func(ctx *gin.Context) {
+				calls++
+				ctx.JSON(tc.status, gin.H{"calls": calls})
+			})
+
+			var first string
+			for _, r := range tc.requests {
+				request := httptest.NewRequest(http.MethodPost, "/worker/work_day", strings.NewReader(r.body))
+				request.Header.Set(IdempotencyKeyHeader, key)
+
+				recorder := httptest.NewRecorder()
+				router.ServeHTTP(recorder, request)
+
+				require.Equal(t, r.code, recorder.Code)
+
+				if r.replayed {
+					require.Equal(t, "true", recorder.Header().Get(IdempotentReplayedHeader))
+					require.JSONEq(t, first, recorder.Body.String())
+				} else {
+					require.Empty(t, recorder.Header().Get(IdempotentReplayedHeader))
+					first = recorder.Body.String()
+				}
+			}
+
+			require.Equal(t, tc.calls, calls)
+
+			if tc.status >= http.StatusInternalServerError {
+				require.Empty(t, keys)
+			}
+		})
+	}
+}
+
+func TestRequestHash(t *testing.T) {
+	hash := requestHash(http.MethodPost, "/api/worker/work_day", []byte(`{"type":"entry"}`))
+
+	require.Len(t, hash, 64)
+	require.Equal(t, hash, requestHash(http.MethodPost, "/api/worker/work_day", []byte(`{"type":"entry"}`)))
+	require.NotEqual(t, hash, requestHash(http.MethodPost, "/api/worker/work_day", []byte(`{"type":"exit"}`)))
+	require.NotEqual(t, hash, requestHash(http.MethodPost, "/api/worker/work_day/add", []byte(`{"type":"entry"}`)))
+}
+
+func TestResponseRecorder(t *testing.T) {
+	router := gin.New()
+	router.GET("/", func(ctx *gin.Context) {
+		r := &responseRecorder{ResponseWriter: ctx.Writer}
+		ctx.Writer = r
+
+		ctx.JSON(http.StatusCreated, gin.H{"message": "ok"})
+
+		require.Equal(t, http.StatusCreated, r.Status())
+		require.JSONEq(t, `{"message":"ok"}`, r.body.String())
+	})
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	require.JSONEq(t, `{"message":"ok"}`, recorder.Body.String())
+}
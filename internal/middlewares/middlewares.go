@@ -1 +1,5 @@
 package middlewares
+
+import "github.com/alexanderbkl/vidre-back/internal/event"
+
+var log = event.Log
@@ -0,0 +1,56 @@
+package middlewares
+
+import (
+	"github.com/alexanderbkl/vidre-back/internal/api"
+	"github.com/alexanderbkl/vidre-back/internal/constant"
+	"github.com/alexanderbkl/vidre-back/internal/entity"
+	"github.com/alexanderbkl/vidre-back/pkg/token"
+	"github.com/gin-gonic/gin"
+)
+
+// RequireRole creates a gin middleware that only admits tokens with one of the given roles.
+// Api keys are rejected. It must be used after AuthMiddleware.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return RequireScope("", roles...)
+}
+
+// RequireScope creates a gin middleware that admits tokens with one of the given
+// roles and api keys with the given scope. An empty scope rejects all api keys.
+// It must be used after AuthMiddleware.
+func RequireScope(scope string, roles ...string) gin.HandlerFunc {
+	allowed := make(map[string]bool, len(roles))
+	for _, role := range roles {
+		allowed[role] = true
+	}
+
+	return func(ctx *gin.Context) {
+		if value, ok := ctx.Get(constant.AuthorizationApiKeyKey); ok {
+			if key, ok := value.(*entity.ApiKey); !ok || scope == "" || !key.HasScope(scope) {
+				api.AbortForbidden(ctx)
+				return
+			}
+
+			ctx.Next()
+			return
+		}
+
+		value, ok := ctx.Get(constant.AuthorizationPayloadKey)
+		if !ok {
+			api.AbortUnauthorized(ctx)
+			return
+		}
+
+		payload, ok := value.(*token.Payload)
+		if !ok || payload == nil {
+			api.AbortUnauthorized(ctx)
+			return
+		}
+
+		if !allowed[payload.Role] {
+			api.AbortForbidden(ctx)
+			return
+		}
+
+		ctx.Next()
+	}
+}
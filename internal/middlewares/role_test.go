@@ -0,0 +1,111 @@
+package middlewares
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alexanderbkl/vidre-back/internal/api"
+	"github.com/alexanderbkl/vidre-back/internal/constant"
+	"github.com/alexanderbkl/vidre-back/internal/entity"
+	"github.com/alexanderbkl/vidre-back/pkg/rnd"
+	"github.com/alexanderbkl/vidre-back/pkg/token"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequireRole(t *testing.T) {
+	testCases := []struct {
+		name         string
+		role         string
+		authenticate bool
+		status       int
+	}{
+		{name: "Admin", role: constant.RoleAdmin, authenticate: true, status: http.StatusOK},
+		{name: "Manager", role: constant.RoleManager, authenticate: true, status: http.StatusOK},
+		{name: "Kiosk", role: constant.RoleKiosk, authenticate: true, status: http.StatusForbidden},
+		{name: "NoRole", role: "", authenticate: true, status: http.StatusForbidden},
+		{name: "NoAuthorization", authenticate: false, status: http.StatusUnauthorized},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			app, router := api.NewApiTest()
+
+			tokenMaker, err := token.NewPasetoMaker(rnd.GenerateRandomString(32))
+			require.NoError(t, err)
+
+			router.GET(
+				"/role",
+				AuthMiddleware(tokenMaker),
+				RequireRole(constant.RoleAdmin, constant.RoleManager),
+				func(ctx *gin.Context) {
+					ctx.JSON(http.StatusOK, gin.H{})
+				},
+			)
+
+			request, err := http.NewRequest(http.MethodGet, "/api/role", nil)
+			require.NoError(t, err)
+
+			if tc.authenticate {
+				accessToken, _, err := tokenMaker.CreateToken(1, "user", "user", time.Minute, token.WithRole(tc.role))
+				require.NoError(t, err)
+				request.Header.Set(constant.AuthorizationHeaderKey, fmt.Sprintf("%s %s", constant.AuthorizationTypeBearer, accessToken))
+			}
+
+			recorder := httptest.NewRecorder()
+			app.ServeHTTP(recorder, request)
+			require.Equal(t, tc.status, recorder.Code)
+		})
+	}
+}
+
+func TestRequireScope(t *testing.T) {
+	testCases := []struct {
+		name   string
+		key    *entity.ApiKey
+		role   string
+		scope  string
+		status int
+	}{
+		{name: "ApiKeyWithScope", key: &entity.ApiKey{Scopes: "workers:read work_days:read"}, scope: constant.ScopeWorkersRead, status: http.StatusOK},
+		{name: "ApiKeyWithoutScope", key: &entity.ApiKey{Scopes: "workers:read"}, scope: constant.ScopeWorkersWrite, status: http.StatusForbidden},
+		{name: "ApiKeyOnRoleRoute", key: &entity.ApiKey{Scopes: "workers:read"}, scope: "", status: http.StatusForbidden},
+		{name: "AdminToken", role: constant.RoleAdmin, scope: constant.ScopeWorkersRead, status: http.StatusOK},
+		{name: "WorkerToken", role: constant.RoleWorker, scope: constant.ScopeWorkersRead, status: http.StatusForbidden},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			app, router := api.NewApiTest()
+
+			router.GET(
+				"/scope",
+				func(ctx *gin.Context) {
+					if tc.key != nil {
+						ctx.Set(constant.AuthorizationApiKeyKey, tc.key)
+					} else {
+						ctx.Set(constant.AuthorizationPayloadKey, &token.Payload{Role: tc.role})
+					}
+				},
+				RequireScope(tc.scope, constant.RoleAdmin),
+				func(ctx *gin.Context) {
+					ctx.JSON(http.StatusOK, gin.H{})
+				},
+			)
+
+			request, err := http.NewRequest(http.MethodGet, "/api/scope", nil)
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			app.ServeHTTP(recorder, request)
+			require.Equal(t, tc.status, recorder.Code)
+		})
+	}
+}
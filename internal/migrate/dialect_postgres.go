@@ -0,0 +1,121 @@
+package migrate
+
+// Dialects contains the migrations of each database dialect.
+var Dialects = map[string]Migrations{
+	"postgres": DialectPostgres,
+}
+
+// DialectPostgres contains the PostgreSQL migrations in the order they run.
+var DialectPostgres = Migrations{
+	{
+		ID:      "20261018-000001",
+		Dialect: "postgres",
+		Stage:   "main",
+		Statements: []string{
+			"UPDATE punches SET date = work_schedules.date FROM work_schedules WHERE punches.work_schedule_id = work_schedules.id AND punches.date IS NULL;",
+		},
+	},
+	{
+		ID:      "20261018-000002",
+		Dialect: "postgres",
+		Stage:   "main",
+		Statements: []string{
+			"INSERT INTO punches (worker_id, work_schedule_id, date, type, time, source, created_at) SELECT worker_id, id, date, 'reset', now(), 'migration', now() FROM work_schedules WHERE deleted_at IS NULL ORDER BY id;",
+			"INSERT INTO punches (worker_id, work_schedule_id, date, type, time, source, created_at) SELECT worker_id, id, date, 'enterHour', date + entry_hour, 'migration', now() FROM work_schedules WHERE deleted_at IS NULL AND entry_hour <> '00:00:00' ORDER BY id;",
+			"DO $$ BEGIN IF EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'work_schedules' AND column_name = 'breakfast_start_hour') THEN EXECUTE $q$INSERT INTO punches (worker_id, work_schedule_id, date, type, time, source, created_at) SELECT worker_id, id, date, 'startBreakfastHour', date + breakfast_start_hour, 'migration', now() FROM work_schedules WHERE deleted_at IS NULL AND breakfast_start_hour <> '00:00:00' ORDER BY id$q$; EXECUTE $q$INSERT INTO punches (worker_id, work_schedule_id, date, type, time, source, created_at) SELECT worker_id, id, date, 'endBreakfastHour', date + breakfast_end_hour, 'migration', now() FROM work_schedules WHERE deleted_at IS NULL AND breakfast_end_hour <> '00:00:00' ORDER BY id$q$; EXECUTE $q$INSERT INTO punches (worker_id, work_schedule_id, date, type, time, source, created_at) SELECT worker_id, id, date, 'startLunchHour', date + lunch_start_hour, 'migration', now() FROM work_schedules WHERE deleted_at IS NULL AND lunch_start_hour <> '00:00:00' ORDER BY id$q$; EXECUTE $q$INSERT INTO punches (worker_id, work_schedule_id, date, type, time, source, created_at) SELECT worker_id, id, date, 'endLunchHour', date + lunch_end_hour, 'migration', now() FROM work_schedules WHERE deleted_at IS NULL AND lunch_end_hour <> '00:00:00' ORDER BY id$q$; END IF; END $$;",
+			"INSERT INTO punches (worker_id, work_schedule_id, date, type, time, source, created_at) SELECT worker_id, id, date, 'exitHour', date + exit_hour, 'migration', now() FROM work_schedules WHERE deleted_at IS NULL AND exit_hour <> '00:00:00' ORDER BY id;",
+		},
+	},
+	{
+		ID:      "20261018-000003",
+		Dialect: "postgres",
+		Stage:   "main",
+		Statements: []string{
+			"CREATE OR REPLACE FUNCTION punches_append_only() RETURNS trigger AS $$ BEGIN RAISE EXCEPTION 'punches are append-only'; END; $$ LANGUAGE plpgsql;",
+			"DROP TRIGGER IF EXISTS punches_append_only ON punches;",
+			"CREATE TRIGGER punches_append_only BEFORE UPDATE OR DELETE ON punches FOR EACH ROW EXECUTE FUNCTION punches_append_only();",
+		},
+	},
+	{
+		ID:      "20261018-000004",
+		Dialect: "postgres",
+		Stage:   "main",
+		Statements: []string{
+			"DO $$ BEGIN IF EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'work_schedules' AND column_name = 'breakfast_start_hour') THEN " +
+				"INSERT INTO work_breaks (work_schedule_id, position, type, start_hour, end_hour, created_at, updated_at) SELECT id, 0, 'unpaid', COALESCE(breakfast_start_hour, '00:00:00'), COALESCE(breakfast_end_hour, '00:00:00'), now(), now() FROM work_schedules WHERE breakfast_start_hour <> '00:00:00' OR breakfast_end_hour <> '00:00:00' ORDER BY id; " +
+				"INSERT INTO work_breaks (work_schedule_id, position, type, start_hour, end_hour, created_at, updated_at) SELECT id, CASE WHEN breakfast_start_hour <> '00:00:00' OR breakfast_end_hour <> '00:00:00' THEN 1 ELSE 0 END, 'unpaid', COALESCE(lunch_start_hour, '00:00:00'), COALESCE(lunch_end_hour, '00:00:00'), now(), now() FROM work_schedules WHERE lunch_start_hour <> '00:00:00' OR lunch_end_hour <> '00:00:00' ORDER BY id; " +
+				"END IF; END $$;",
+			"ALTER TABLE work_schedules DROP COLUMN IF EXISTS breakfast_start_hour, DROP COLUMN IF EXISTS breakfast_end_hour, DROP COLUMN IF EXISTS lunch_start_hour, DROP COLUMN IF EXISTS lunch_end_hour;",
+		},
+	},
+	{
+		ID:      "20261018-000005",
+		Dialect: "postgres",
+		Stage:   "pre",
+		Statements: []string{
+			// Migrations 20261018-000002 and 20261018-000004 read times of day, which
+			// the conversion below removes. Databases that have not run them yet are
+			// converted first: 20261018-000008 moves the breaks, 20261018-000006
+			// snapshots the work days.
+			"INSERT INTO migrations (id, dialect, stage, error, started_at, finished_at) SELECT id, 'postgres', 'main', '', now(), now() FROM unnest(ARRAY['20261018-000002', '20261018-000004']) AS id " +
+				"ON CONFLICT (id) DO UPDATE SET error = '', finished_at = now() WHERE migrations.finished_at IS NULL;",
+			// Work day times were stored as UTC time of day on the business date.
+			"CREATE OR REPLACE FUNCTION migrate_utc_time(d date, t time) RETURNS timestamptz AS $$ " +
+				"SELECT CASE WHEN t IS NULL OR t = '00:00:00' THEN '0001-01-01 00:00:00+00'::timestamptz " +
+				"ELSE ((d + t) AT TIME ZONE 'UTC') + (d - ((d + t) AT TIME ZONE 'UTC')::date) * interval '1 day' END " +
+				"$$ LANGUAGE sql STABLE;",
+			"DO $$ DECLARE c text; BEGIN " +
+				"FOREACH c IN ARRAY ARRAY['entry_hour', 'exit_hour', 'breakfast_start_hour', 'breakfast_end_hour', 'lunch_start_hour', 'lunch_end_hour'] LOOP " +
+				"IF EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'work_schedules' AND column_name = c AND data_type = 'time without time zone') THEN " +
+				"EXECUTE format('ALTER TABLE work_schedules ALTER COLUMN %I TYPE timestamptz USING migrate_utc_time(date, %I)', c, c); " +
+				"IF c <> 'entry_hour' THEN EXECUTE format('UPDATE work_schedules SET %I = %I + interval ''1 day'' WHERE %I > ''0001-01-02'' AND %I < entry_hour', c, c, c, c); END IF; " +
+				"END IF; END LOOP; END $$;",
+			"DO $$ BEGIN IF EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'work_breaks' AND column_name = 'start_hour' AND data_type = 'time without time zone') THEN " +
+				"ALTER TABLE work_breaks ADD COLUMN start_at timestamptz, ADD COLUMN end_at timestamptz; " +
+				"UPDATE work_breaks SET start_at = migrate_utc_time(s.date, work_breaks.start_hour), end_at = migrate_utc_time(s.date, work_breaks.end_hour) FROM work_schedules s WHERE s.id = work_breaks.work_schedule_id; " +
+				"UPDATE work_breaks SET start_at = start_at + interval '1 day' FROM work_schedules s WHERE s.id = work_breaks.work_schedule_id AND start_at > '0001-01-02' AND start_at < s.entry_hour; " +
+				"UPDATE work_breaks SET end_at = end_at + interval '1 day' WHERE end_at > '0001-01-02' AND end_at < start_at; " +
+				"ALTER TABLE work_breaks DROP COLUMN start_hour, DROP COLUMN end_hour; " +
+				"ALTER TABLE work_breaks RENAME COLUMN start_at TO start_hour; " +
+				"ALTER TABLE work_breaks RENAME COLUMN end_at TO end_hour; " +
+				"END IF; END $$;",
+			"DROP FUNCTION migrate_utc_time(date, time);",
+		},
+	},
+	{
+		ID:      "20261018-000008",
+		Dialect: "postgres",
+		Stage:   "main",
+		Statements: []string{
+			// Breaks of databases converted before 20261018-000004 ran, see 20261018-000005.
+			"DO $$ BEGIN IF EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'work_schedules' AND column_name = 'breakfast_start_hour') THEN " +
+				"INSERT INTO work_breaks (work_schedule_id, position, type, start_hour, end_hour, created_at, updated_at) SELECT id, 0, 'unpaid', COALESCE(breakfast_start_hour, '0001-01-01 00:00:00+00'), COALESCE(breakfast_end_hour, '0001-01-01 00:00:00+00'), now(), now() FROM work_schedules WHERE breakfast_start_hour > '0001-01-02' OR breakfast_end_hour > '0001-01-02' ORDER BY id; " +
+				"INSERT INTO work_breaks (work_schedule_id, position, type, start_hour, end_hour, created_at, updated_at) SELECT id, CASE WHEN breakfast_start_hour > '0001-01-02' OR breakfast_end_hour > '0001-01-02' THEN 1 ELSE 0 END, 'unpaid', COALESCE(lunch_start_hour, '0001-01-01 00:00:00+00'), COALESCE(lunch_end_hour, '0001-01-01 00:00:00+00'), now(), now() FROM work_schedules WHERE lunch_start_hour > '0001-01-02' OR lunch_end_hour > '0001-01-02' ORDER BY id; " +
+				"END IF; END $$;",
+			"ALTER TABLE work_schedules DROP COLUMN IF EXISTS breakfast_start_hour, DROP COLUMN IF EXISTS breakfast_end_hour, DROP COLUMN IF EXISTS lunch_start_hour, DROP COLUMN IF EXISTS lunch_end_hour;",
+		},
+	},
+	{
+		ID:      "20261018-000006",
+		Dialect: "postgres",
+		Stage:   "main",
+		Statements: []string{
+			// Snapshot the converted work days, so that they are projected from punches with exact times.
+			"INSERT INTO punches (worker_id, work_schedule_id, date, type, time, source, created_at) SELECT worker_id, id, date, 'reset', now(), 'migration', now() FROM work_schedules WHERE deleted_at IS NULL ORDER BY id;",
+			"INSERT INTO punches (worker_id, work_schedule_id, date, type, time, source, created_at) SELECT worker_id, id, date, 'enterHour', entry_hour, 'migration', now() FROM work_schedules WHERE deleted_at IS NULL AND entry_hour > '0001-01-02' ORDER BY id;",
+			"INSERT INTO punches (worker_id, work_schedule_id, date, type, time, break_index, break_type, source, created_at) SELECT s.worker_id, s.id, s.date, 'startBreakHour', b.start_hour, b.position, b.type, 'migration', now() FROM work_breaks b JOIN work_schedules s ON s.id = b.work_schedule_id WHERE s.deleted_at IS NULL AND b.start_hour > '0001-01-02' ORDER BY s.id, b.position;",
+			"INSERT INTO punches (worker_id, work_schedule_id, date, type, time, break_index, break_type, source, created_at) SELECT s.worker_id, s.id, s.date, 'endBreakHour', b.end_hour, b.position, b.type, 'migration', now() FROM work_breaks b JOIN work_schedules s ON s.id = b.work_schedule_id WHERE s.deleted_at IS NULL AND b.end_hour > '0001-01-02' ORDER BY s.id, b.position;",
+			"INSERT INTO punches (worker_id, work_schedule_id, date, type, time, source, created_at) SELECT worker_id, id, date, 'exitHour', exit_hour, 'migration', now() FROM work_schedules WHERE deleted_at IS NULL AND exit_hour > '0001-01-02' ORDER BY id;",
+		},
+	},
+	{
+		ID:      "20261018-000007",
+		Dialect: "postgres",
+		Stage:   "main",
+		Statements: []string{
+			"CREATE OR REPLACE FUNCTION audit_logs_append_only() RETURNS trigger AS $$ BEGIN RAISE EXCEPTION 'audit logs are append-only'; END; $$ LANGUAGE plpgsql;",
+			"DROP TRIGGER IF EXISTS audit_logs_append_only ON audit_logs;",
+			"CREATE TRIGGER audit_logs_append_only BEFORE UPDATE OR DELETE ON audit_logs FOR EACH ROW EXECUTE FUNCTION audit_logs_append_only();",
+		},
+	},
+}
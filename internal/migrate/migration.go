@@ -0,0 +1,85 @@
+package migrate
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Migration represents a database schema or data migration.
+type Migration struct {
+	ID         string     `gorm:"type:varchar(16);primary_key" json:"id"`
+	Dialect    string     `gorm:"type:varchar(16)" json:"dialect"`
+	Stage      string     `gorm:"type:varchar(16)" json:"stage"`
+	Error      string     `gorm:"type:varchar(255)" json:"error"`
+	Source     string     `gorm:"-" json:"source"`
+	Statements []string   `gorm:"-" json:"statements"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+}
+
+// TableName returns the entity table name.
+func (Migration) TableName() string {
+	return "migrations"
+}
+
+// Finished tests if the migration has been finished yet.
+func (m *Migration) Finished() bool {
+	return m.FinishedAt != nil && !m.FinishedAt.IsZero()
+}
+
+// RunStage tests if the migration belongs to the given stage.
+func (m *Migration) RunStage(name string) bool {
+	if m.Stage == "" {
+		return name == StageMain
+	}
+
+	return m.Stage == name
+}
+
+// Fail marks the migration as failed by adding an error message.
+func (m *Migration) Fail(err error, db *gorm.DB) {
+	if err == nil {
+		return
+	}
+
+	m.Error = err.Error()
+
+	if len(m.Error) > 255 {
+		m.Error = m.Error[:255]
+	}
+
+	if err := db.Model(m).Update("error", m.Error).Error; err != nil {
+		log.Errorf("migrate: %s (update error of %s)", err, m.ID)
+	}
+}
+
+// Finish updates the finished at timestamp and removes the error message.
+func (m *Migration) Finish(db *gorm.DB) error {
+	finished := time.Now().UTC()
+	m.FinishedAt = &finished
+	m.Error = ""
+
+	return db.Model(m).Updates(Values{"finished_at": finished, "error": ""}).Error
+}
+
+// Execute runs the statements of the migration in a single transaction,
+// using the time zone as session time zone if not empty.
+func (m *Migration) Execute(db *gorm.DB, timeZone string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if timeZone != "" {
+			if err := tx.Exec("SELECT set_config('TimeZone', ?, true)", timeZone).Error; err != nil {
+				return fmt.Errorf("%s (%s)", err, m.ID)
+			}
+		}
+
+		for _, s := range m.Statements {
+			if err := tx.Exec(s).Error; err != nil {
+				return fmt.Errorf("%s (%s)", err, m.ID)
+			}
+		}
+
+		return nil
+	})
+}
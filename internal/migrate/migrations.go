@@ -0,0 +1,88 @@
+package migrate
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Migrations represents a sorted list of migrations.
+type Migrations []Migration
+
+// MigrationMap represents a map of migrations by id.
+type MigrationMap map[string]Migration
+
+// Existing returns the migrations that have already been started.
+func Existing(db *gorm.DB) MigrationMap {
+	result := make(MigrationMap)
+
+	var list Migrations
+	if err := db.Find(&list).Error; err != nil {
+		log.Warnf("migrate: %s (find existing)", err)
+		return result
+	}
+
+	for _, m := range list {
+		result[m.ID] = m
+	}
+
+	return result
+}
+
+// Start runs the migrations of the stage selected in the options that have not been finished yet.
+func (ms Migrations) Start(db *gorm.DB, opt Options) {
+	existing := Existing(db)
+	stage := opt.StageName()
+
+	for _, m := range ms {
+		if !m.RunStage(stage) {
+			continue
+		}
+
+		if len(opt.Migrations) > 0 && !contains(opt.Migrations, m.ID) {
+			continue
+		}
+
+		if done, ok := existing[m.ID]; ok {
+			if done.Finished() {
+				log.Debugf("migrate: %s skipped", m.ID)
+				continue
+			} else if done.Error != "" && !opt.RunFailed && len(opt.Migrations) == 0 {
+				log.Warnf("migrate: %s previously failed (%s), skipped", m.ID, done.Error)
+				continue
+			}
+		} else {
+			m.StartedAt = time.Now().UTC()
+
+			if err := db.Create(&m).Error; err != nil {
+				log.Errorf("migrate: %s (create %s)", err, m.ID)
+				continue
+			}
+		}
+
+		start := time.Now()
+
+		if err := m.Execute(db, opt.TimeZone); err != nil {
+			m.Fail(err, db)
+			log.Errorf("migrate: %s failed (%s)", m.ID, err)
+			continue
+		}
+
+		if err := m.Finish(db); err != nil {
+			log.Errorf("migrate: %s (finish %s)", err, m.ID)
+			continue
+		}
+
+		log.Infof("migrate: %s successful [%s]", m.ID, time.Since(start))
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
@@ -7,6 +7,9 @@ type Options struct {
 	RunFailed      bool
 	Migrations     []string
 	DropDeprecated bool
+	// TimeZone is the session time zone of the migrations, e.g. to compute
+	// dates from timestamps. The database default is used if empty.
+	TimeZone string
 }
 
 // Opt returns migration options based on the specified parameters.
@@ -33,9 +36,16 @@ func (opt Options) Stage(name string) Options {
 		RunFailed:      opt.RunFailed,
 		Migrations:     opt.Migrations,
 		DropDeprecated: opt.DropDeprecated,
+		TimeZone:       opt.TimeZone,
 	}
 }
 
+// In returns options for running the migrations in the specified time zone.
+func (opt Options) In(timeZone string) Options {
+	opt.TimeZone = timeZone
+	return opt
+}
+
 // Pre returns options for the pre-migration stage.
 func (opt Options) Pre() Options {
 	return opt.Stage(StagePre)
@@ -12,9 +12,16 @@ func Run(db *gorm.DB, opt Options) (err error) {
 		return fmt.Errorf("migrate: no database connection")
 	}
 
+	// Make sure the migrations table exists.
+	once.Do(func() {
+		err = db.AutoMigrate(&Migration{})
+	})
+
 	if err != nil {
 		return fmt.Errorf("migrate: %s (create migrations table)", err)
 	}
 
+	Dialects[db.Dialector.Name()].Start(db, opt)
+
 	return
 }
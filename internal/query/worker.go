@@ -11,4 +11,12 @@ func GetWorkerIDFromCode(code string) (uint, error) {
 		return 0, err
 	}
 	return worker.ID, nil
-}
\ No newline at end of file
+}
+
+func GetWorkerFromCode(code string) (*entity.Worker, error) {
+	var worker entity.Worker
+	if err := db.Db().Where("code = ?", code).First(&worker).Error; err != nil {
+		return nil, err
+	}
+	return &worker, nil
+}
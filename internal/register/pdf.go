@@ -0,0 +1,108 @@
+package register
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// Page layout in millimeters.
+const (
+	margin    = 12.0
+	rowHeight = 5.5
+)
+
+// columns are the widths of the table columns, which fill the page width.
+var columns = [...]float64{18, 18, 66, 20, 16, 48}
+
+var headings = [...]string{"Día", "Entrada", "Descansos", "Salida", "Horas", "Observaciones"}
+
+// WritePDF writes the register as A4 PDF document.
+func (r *Register) WritePDF(w io.Writer) error {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	pdf.SetMargins(margin, margin, margin)
+	pdf.SetAutoPageBreak(true, margin)
+	pdf.SetTitle(tr("Registro de jornada "+r.Title()+" "+r.Worker.Name), false)
+	pdf.SetCreationDate(r.GeneratedAt)
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-margin)
+		pdf.SetFont("Helvetica", "I", 7)
+		pdf.CellFormat(0, 4, tr("Documento generado el "+r.GeneratedAt.In(r.Location).Format("02/01/2006 15:04")), "", 0, "L", false, 0, "")
+		pdf.CellFormat(0, 4, fmt.Sprintf("%d/{nb}", pdf.PageNo()), "", 0, "R", false, 0, "")
+	})
+	pdf.AliasNbPages("")
+	pdf.AddPage()
+
+	// Header
+	pdf.SetFont("Helvetica", "B", 14)
+	pdf.CellFormat(0, 8, "REGISTRO DIARIO DE JORNADA", "", 1, "C", false, 0, "")
+	pdf.SetFont("Helvetica", "", 11)
+	pdf.CellFormat(0, 6, tr(r.Title()), "", 1, "C", false, 0, "")
+	pdf.Ln(3)
+
+	pdf.SetFont("Helvetica", "", 9)
+	info := [][2]string{
+		{"Empresa:", r.Company},
+		{"Trabajador:", r.Worker.Name},
+		{"Código:", r.Worker.Code},
+	}
+	for _, line := range info {
+		pdf.SetFont("Helvetica", "B", 9)
+		pdf.CellFormat(24, 5, tr(line[0]), "", 0, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "", 9)
+		pdf.CellFormat(0, 5, tr(line[1]), "B", 1, "L", false, 0, "")
+	}
+	pdf.Ln(4)
+
+	// Table
+	pdf.SetFont("Helvetica", "B", 8)
+	pdf.SetFillColor(220, 220, 220)
+	for i, heading := range headings {
+		pdf.CellFormat(columns[i], rowHeight+1, tr(heading), "1", 0, "C", true, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Helvetica", "", 8)
+	pdf.SetFillColor(240, 240, 240)
+	for _, day := range r.Days {
+		row := r.Row(day)
+		fill := day.Festivo || day.Weekend
+		cells := [...]string{row.Day, row.Entry, row.Breaks, row.Exit, row.Worked, row.Remarks}
+		aligns := [...]string{"L", "C", "L", "C", "R", "L"}
+
+		for i, text := range cells {
+			pdf.CellFormat(columns[i], rowHeight, tr(text), "1", 0, aligns[i], fill, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+
+	// Totals
+	pdf.SetFont("Helvetica", "B", 8)
+	label := columns[0] + columns[1] + columns[2] + columns[3]
+	pdf.CellFormat(label, rowHeight+1, "Total horas trabajadas", "1", 0, "R", false, 0, "")
+	pdf.CellFormat(columns[4], rowHeight+1, Hours(r.Total.Net), "1", 0, "R", false, 0, "")
+	pdf.SetFont("Helvetica", "", 8)
+	pdf.CellFormat(columns[5], rowHeight+1, tr(fmt.Sprintf("Descansos %s (R %s)", Hours(r.Total.Breaks), Hours(r.Total.PaidBreaks))), "1", 1, "L", false, 0, "")
+
+	pdf.SetFont("Helvetica", "I", 7)
+	pdf.CellFormat(0, 5, tr("R: descanso retribuido. (+1): hora del día siguiente. Las horas trabajadas no incluyen los descansos no retribuidos."), "", 1, "L", false, 0, "")
+	pdf.Ln(3)
+
+	// Signatures
+	pdf.SetFont("Helvetica", "", 9)
+	pdf.CellFormat(0, 5, "En ____________________, a ____ de ____________________ de ________", "", 1, "L", false, 0, "")
+	pdf.Ln(2)
+
+	width := (210 - 2*margin - 10) / 2
+	x, y := pdf.GetX(), pdf.GetY()
+	pdf.Rect(x, y, width, 24, "D")
+	pdf.Rect(x+width+10, y, width, 24, "D")
+	pdf.SetFont("Helvetica", "B", 8)
+	pdf.Text(x+2, y+4, "Firma de la empresa")
+	pdf.Text(x+width+12, y+4, "Firma del trabajador")
+
+	return pdf.Output(w)
+}
@@ -0,0 +1,173 @@
+/*
+Package register generates the monthly working time register of a worker
+(registro de jornada), which Spanish law requires to be kept for every
+worker and shown on request.
+
+The register lists every day of the month with its entry, breaks, exit and
+net worked time, marks weekends and festivos, and leaves room for the
+signatures of the company and the worker.
+*/
+package register
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/alexanderbkl/vidre-back/internal/entity"
+	"github.com/alexanderbkl/vidre-back/internal/worktime"
+)
+
+var months = [...]string{"Enero", "Febrero", "Marzo", "Abril", "Mayo", "Junio", "Julio", "Agosto", "Septiembre", "Octubre", "Noviembre", "Diciembre"}
+
+var weekdays = [...]string{"dom", "lun", "mar", "mié", "jue", "vie", "sáb"}
+
+// Day is a calendar day of the register.
+type Day struct {
+	Date     time.Time
+	Festivo  bool
+	Weekend  bool
+	Schedule *entity.WorkSchedule
+	worktime.Day
+}
+
+// Register is the working time register of a worker for a month.
+type Register struct {
+	Company  string
+	Worker   entity.Worker
+	Month    time.Time
+	Location *time.Location
+	Days     []Day
+	Total    worktime.Times
+	// GeneratedAt is shown in the footer of the register.
+	GeneratedAt time.Time
+}
+
+// New returns the register of the month, which is given by any date in it,
+// with one day for each calendar day.
+func New(company string, worker entity.Worker, month time.Time, loc *time.Location, schedules entity.WorkSchedules, festivos entity.Festivos) *Register {
+	first := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	r := &Register{
+		Company:  company,
+		Worker:   worker,
+		Month:    first,
+		Location: loc,
+
+		GeneratedAt: time.Now(),
+	}
+
+	byDate := make(map[string]*entity.WorkSchedule, len(schedules))
+	for i := range schedules {
+		byDate[schedules[i].Date.Format("2006-01-02")] = &schedules[i]
+	}
+
+	isFestivo := make(map[string]bool, len(festivos))
+	for _, f := range festivos {
+		isFestivo[f.Date] = true
+	}
+
+	for date := first; date.Month() == first.Month(); date = date.AddDate(0, 0, 1) {
+		key := date.Format("2006-01-02")
+
+		day := Day{
+			Date:     date,
+			Festivo:  isFestivo[key],
+			Weekend:  date.Weekday() == time.Saturday || date.Weekday() == time.Sunday,
+			Schedule: byDate[key],
+		}
+
+		if day.Schedule != nil {
+			day.Day = worktime.Compute(day.Schedule)
+			r.Total.Add(day.Times)
+		}
+
+		r.Days = append(r.Days, day)
+	}
+
+	return r
+}
+
+// Title returns the month of the register, e.g. "Marzo 2024".
+func (r *Register) Title() string {
+	return fmt.Sprintf("%s %d", months[r.Month.Month()-1], r.Month.Year())
+}
+
+// Row contains the texts of the table row of a day.
+type Row struct {
+	Day     string
+	Entry   string
+	Breaks  string
+	Exit    string
+	Worked  string
+	Remarks string
+}
+
+// Row returns the table row of a day. Times on a later day than the work
+// day, e.g. the exit of a night shift, are marked with "(+1)".
+func (r *Register) Row(day Day) Row {
+	row := Row{Day: fmt.Sprintf("%02d %s", day.Date.Day(), weekdays[day.Date.Weekday()])}
+
+	var remarks []string
+
+	if day.Festivo {
+		remarks = append(remarks, "Festivo")
+	} else if day.Weekend {
+		remarks = append(remarks, "Fin de semana")
+	}
+
+	if s := day.Schedule; s != nil {
+		row.Entry = r.clock(day.Date, s.EntryHour)
+		row.Exit = r.clock(day.Date, s.ExitHour)
+
+		breaks := make([]string, 0, len(s.Breaks))
+		for _, b := range s.Breaks {
+			text := r.clock(day.Date, b.StartHour) + "-" + r.clock(day.Date, b.EndHour)
+			if b.Type == entity.BreakPaid {
+				text += " R"
+			}
+			breaks = append(breaks, text)
+		}
+		row.Breaks = strings.Join(breaks, ", ")
+
+		if day.Complete {
+			row.Worked = Hours(day.Net)
+		} else {
+			remarks = append(remarks, "Incompleto")
+		}
+	}
+
+	row.Remarks = strings.Join(remarks, ", ")
+
+	return row
+}
+
+// clock returns the wall clock time of t in the location of the register.
+func (r *Register) clock(date, t time.Time) string {
+	return Clock(date, t, r.Location)
+}
+
+// Clock returns the wall clock time of t in the location, or an empty string
+// for the zero time. Times on another day than the date are marked with the
+// day offset, e.g. "06:00 (+1)".
+func Clock(date, t time.Time, loc *time.Location) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	local := t.In(loc)
+	text := local.Format("15:04")
+
+	y, m, d := local.Date()
+	if days := int(time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Sub(date).Hours() / 24); days != 0 {
+		text += fmt.Sprintf(" (%+d)", days)
+	}
+
+	return text
+}
+
+// Hours formats a duration as hours and minutes, e.g. "7:45".
+func Hours(d time.Duration) string {
+	d = d.Round(time.Minute)
+	return fmt.Sprintf("%d:%02d", int(d/time.Hour), int(d%time.Hour/time.Minute))
+}
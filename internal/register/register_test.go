@@ -0,0 +1,107 @@
+package register
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/alexanderbkl/vidre-back/internal/entity"
+	"github.com/stretchr/testify/require"
+)
+
+func testRegister(t *testing.T) *Register {
+	madrid, err := time.LoadLocation("Europe/Madrid")
+	require.NoError(t, err)
+
+	local := func(day, hour, min int) time.Time {
+		return time.Date(2024, 3, day, hour, min, 0, 0, madrid)
+	}
+
+	date := func(day int) time.Time {
+		return time.Date(2024, 3, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	schedules := entity.WorkSchedules{
+		{Date: date(4), EntryHour: local(4, 8, 0), ExitHour: local(4, 17, 0), Breaks: entity.WorkBreaks{
+			{Type: entity.BreakPaid, StartHour: local(4, 10, 0), EndHour: local(4, 10, 15)},
+			{Type: entity.BreakUnpaid, StartHour: local(4, 14, 0), EndHour: local(4, 15, 0)},
+		}},
+		{Date: date(5), EntryHour: local(5, 22, 0), ExitHour: local(6, 6, 0)},
+		{Date: date(7), EntryHour: local(7, 8, 0)},
+	}
+
+	worker := entity.Worker{Name: "Begoña Núñez", Code: "0042"}
+
+	r := New("Vidre S.L.", worker, date(15), madrid, schedules, entity.Festivos{{Date: "2024-03-19"}})
+	r.GeneratedAt = local(31, 12, 0)
+
+	return r
+}
+
+func TestNew(t *testing.T) {
+	r := testRegister(t)
+
+	require.Equal(t, "Marzo 2024", r.Title())
+	require.Len(t, r.Days, 31)
+	require.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), r.Days[0].Date)
+	require.True(t, r.Days[1].Weekend)
+	require.True(t, r.Days[18].Festivo)
+	require.Equal(t, 16*time.Hour, r.Total.Net)
+	require.Equal(t, 75*time.Minute, r.Total.Breaks)
+}
+
+func TestRow(t *testing.T) {
+	r := testRegister(t)
+
+	testCases := []struct {
+		name     string
+		day      int
+		expected Row
+	}{
+		{
+			name:     "Breaks",
+			day:      4,
+			expected: Row{Day: "04 lun", Entry: "08:00", Breaks: "10:00-10:15 R, 14:00-15:00", Exit: "17:00", Worked: "8:00"},
+		},
+		{
+			name:     "NightShift",
+			day:      5,
+			expected: Row{Day: "05 mar", Entry: "22:00", Exit: "06:00 (+1)", Worked: "8:00"},
+		},
+		{
+			name:     "Incomplete",
+			day:      7,
+			expected: Row{Day: "07 jue", Entry: "08:00", Remarks: "Incompleto"},
+		},
+		{
+			name:     "Weekend",
+			day:      9,
+			expected: Row{Day: "09 sáb", Remarks: "Fin de semana"},
+		},
+		{
+			name:     "Festivo",
+			day:      19,
+			expected: Row{Day: "19 mar", Remarks: "Festivo"},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, r.Row(r.Days[tc.day-1]))
+		})
+	}
+}
+
+func TestHours(t *testing.T) {
+	require.Equal(t, "0:00", Hours(0))
+	require.Equal(t, "7:45", Hours(7*time.Hour+45*time.Minute))
+	require.Equal(t, "160:01", Hours(160*time.Hour+50*time.Second))
+}
+
+func TestWritePDF(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, testRegister(t).WritePDF(&buf))
+	require.True(t, bytes.HasPrefix(buf.Bytes(), []byte("%PDF-")))
+}
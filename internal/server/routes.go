@@ -3,14 +3,21 @@ package server
 import (
 	"github.com/alexanderbkl/vidre-back/internal/api"
 	"github.com/alexanderbkl/vidre-back/internal/config"
+	"github.com/alexanderbkl/vidre-back/internal/constant"
 	"github.com/alexanderbkl/vidre-back/internal/middlewares"
-	"github.com/alexanderbkl/vidre-back/pkg/token"
 	"github.com/gin-gonic/gin"
 )
 
 var APIv1 *gin.RouterGroup
 var AuthAPIv1 *gin.RouterGroup
 
+const (
+	admin   = constant.RoleAdmin
+	manager = constant.RoleManager
+	kiosk   = constant.RoleKiosk
+	worker  = constant.RoleWorker
+)
+
 func registerRoutes(router *gin.Engine) {
 	// Enables automatic redirection if the current route cannot be matched but a
 	// handler for the path with (without) the trailing slash exists.
@@ -19,32 +26,117 @@ func registerRoutes(router *gin.Engine) {
 	// Create API router group.
 	APIv1 = router.Group("/api")
 	// Create AuthAPI router group.
-	tokenMaker, err := token.NewPasetoMaker(config.Env().TokenSymmetricKey)
+	tokenMaker, err := config.NewTokenMaker()
 	if err != nil {
 		log.Errorf("cannot create token maker: %s", err)
 		panic(err)
 	}
 
+	oidcProvider, oidcRoles, err := config.NewOidcProvider()
+	if err != nil {
+		log.Errorf("cannot create oidc provider: %s", err)
+		panic(err)
+	}
 
-
-	AuthAPIv1 := router.Group("/api")
-	AuthAPIv1.Use(middlewares.AuthMiddleware(tokenMaker))
+	AuthAPIv1 = router.Group("/api")
+	AuthAPIv1.Use(middlewares.AuthMiddleware(tokenMaker), middlewares.DeviceMiddleware())
 	// routes
 	api.Ping(APIv1)
 
-	api.GetWorkers(APIv1)
-	api.CreateWorker(APIv1)
-	api.ModifyWorker(APIv1)
-	api.DeleteWorker(APIv1)
-	api.GetExtraHours(APIv1)
-	api.ToggleExtraHours(APIv1)
-	api.GetWorkDay(APIv1)
-	api.GetFestivos(APIv1)
-	api.PostFestivo(APIv1)
-	api.DeleteFestivo(APIv1)
-	api.PostWorkDay(APIv1)
-	api.AddWorkDay(APIv1)
-	api.DeleteWorkDay(APIv1)
-	api.UpdateWorkDay(APIv1)
+	// auth
+	api.Login(APIv1, tokenMaker)
+	api.RenewAccessToken(APIv1, tokenMaker)
+	api.GetTokenKeys(APIv1, tokenMaker)
+	api.GetLoginNonce(APIv1)
+	api.LoginWallet(APIv1, tokenMaker)
+	api.OidcLogin(APIv1, oidcProvider)
+	api.OidcCallback(APIv1, oidcProvider, oidcRoles, tokenMaker)
+	api.SetupTotp(APIv1)
+	api.EnableTotp(APIv1)
+	api.DisableTotp(APIv1)
+	api.RenewRecoveryCodes(APIv1)
+	api.Logout(AuthAPIv1)
+	api.ResetUserTotp(allow(admin))
+	api.GetRolePolicies(allow(admin))
+	api.UpdateRolePolicy(allow(admin))
+	api.GetUserSessions(allow(admin))
+	api.RevokeUserSessions(allow(admin))
+	api.RevokeSession(allow(admin))
+
+	// api keys
+	api.GetApiKeys(allow(admin))
+	api.CreateApiKey(allow(admin))
+	api.RevokeApiKey(allow(admin))
+
+	// devices
+	api.EnrollDevice(APIv1, tokenMaker)
+	api.GetDevices(allow(admin))
+	api.CreateDevice(allow(admin))
+	api.UpdateDevice(allow(admin))
+	api.ResetDeviceSecret(allow(admin))
+	api.DeleteDevice(allow(admin))
+
+	// workers
+	api.GetWorkers(scoped(constant.ScopeWorkersRead, admin, manager))
+	api.CreateWorker(scoped(constant.ScopeWorkersWrite, admin))
+	api.ModifyWorker(scoped(constant.ScopeWorkersWrite, admin))
+	api.DeleteWorker(scoped(constant.ScopeWorkersWrite, admin))
+	api.GetExtraHours(scoped(constant.ScopeWorkersRead, admin, manager))
+	api.ToggleExtraHours(allow(admin))
+	api.SetWorkerPin(allow(admin))
+	api.ResetWorkerPin(allow(admin))
+	api.GetPinAttempts(allow(admin))
+	api.ImportWorkers(scoped(constant.ScopeWorkersWrite, admin))
+
+	// festivos
+	api.GetFestivos(scoped(constant.ScopeFestivosRead, admin, manager, worker))
+	api.PostFestivo(scoped(constant.ScopeFestivosWrite, admin))
+	api.DeleteFestivo(scoped(constant.ScopeFestivosWrite, admin))
+
+	// work days
+	api.GetWorkDay(scoped(constant.ScopeWorkDaysRead, admin, manager))
+	api.GetWorkDaysSummary(scoped(constant.ScopeWorkDaysRead, admin, manager))
+	api.GetOvertime(scoped(constant.ScopeWorkDaysRead, admin, manager))
+	api.GetWorkerRegister(scoped(constant.ScopeWorkDaysRead, admin, manager))
+	api.ExportWorkDays(scoped(constant.ScopeWorkDaysRead, admin, manager))
+	api.PostWorkDay(idempotent(allow(admin, manager, kiosk)))
+	api.SyncPunches(allow(kiosk))
+	api.AddWorkDay(scoped(constant.ScopeWorkDaysWrite, admin, manager))
+	api.DeleteWorkDay(scoped(constant.ScopeWorkDaysWrite, admin, manager))
+	api.UpdateWorkDay(scoped(constant.ScopeWorkDaysWrite, admin, manager))
+	api.ImportWorkDays(scoped(constant.ScopeWorkDaysWrite, admin, manager))
+	api.GetPunches(scoped(constant.ScopeWorkDaysRead, admin, manager))
+	api.GetPunchesForReview(scoped(constant.ScopeWorkDaysRead, admin, manager))
+	api.GetPunchesOutsideGeofence(scoped(constant.ScopeWorkDaysRead, admin, manager))
+	api.PostCorrection(allow(admin, manager, kiosk, worker))
+	api.GetCorrection(allow(admin, manager, kiosk, worker))
+	api.GetCorrections(scoped(constant.ScopeWorkDaysRead, admin, manager))
+	api.ApproveCorrection(scoped(constant.ScopeWorkDaysWrite, admin, manager))
+	api.RejectCorrection(scoped(constant.ScopeWorkDaysWrite, admin, manager))
+
+	// geofences
+	api.GetGeofences(allow(admin, manager))
+	api.CreateGeofence(allow(admin))
+	api.UpdateGeofence(allow(admin))
+	api.DeleteGeofence(allow(admin))
+
+	// audit
+	api.GetAudit(scoped(constant.ScopeAuditRead, admin, manager))
+}
+
+// allow returns an authenticated router group that only admits the given roles.
+func allow(roles ...string) *gin.RouterGroup {
+	return AuthAPIv1.Group("", middlewares.RequireRole(roles...))
+}
+
+// scoped returns an authenticated router group that admits the given roles
+// and api keys with the given scope.
+func scoped(scope string, roles ...string) *gin.RouterGroup {
+	return AuthAPIv1.Group("", middlewares.RequireScope(scope, roles...))
+}
 
+// idempotent adds the replay of responses to requests with an Idempotency-Key
+// header to the router group.
+func idempotent(group *gin.RouterGroup) *gin.RouterGroup {
+	return group.Group("", middlewares.Idempotency(config.Env().IdempotencyWindow))
 }
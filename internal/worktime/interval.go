@@ -0,0 +1,97 @@
+package worktime
+
+import (
+	"sort"
+	"time"
+
+	"github.com/alexanderbkl/vidre-back/internal/entity"
+)
+
+// Interval is a time interval from Start (inclusive) to End (exclusive).
+type Interval struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Duration returns the length of the interval.
+func (i Interval) Duration() time.Duration {
+	return i.End.Sub(i.Start)
+}
+
+// Intersect returns the overlap of two intervals, if any.
+func (i Interval) Intersect(other Interval) (Interval, bool) {
+	result := Interval{Start: maxTime(i.Start, other.Start), End: minTime(i.End, other.End)}
+	return result, result.End.After(result.Start)
+}
+
+// Union returns the length of the union of the intervals.
+func Union(intervals []Interval) time.Duration {
+	sorted := make([]Interval, len(intervals))
+	copy(sorted, intervals)
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Start.Before(sorted[j].Start)
+	})
+
+	var total time.Duration
+	var counted time.Time
+
+	for _, i := range sorted {
+		if i.Start.Before(counted) {
+			i.Start = counted
+		}
+
+		if i.End.After(i.Start) {
+			total += i.Duration()
+			counted = i.End
+		}
+	}
+
+	return total
+}
+
+// Worked returns the intervals of net worked time of a work schedule, that
+// is from entry to exit without the unpaid breaks. Breaks are counted as in
+// Compute, so overlapping parts belong to the break that starts first. It
+// returns nil for incomplete days.
+func Worked(schedule *entity.WorkSchedule) []Interval {
+	entry, exit := schedule.EntryHour, schedule.ExitHour
+	if entry.IsZero() || exit.IsZero() || !exit.After(entry) {
+		return nil
+	}
+
+	var worked []Interval
+	start := entry
+
+	for _, b := range countedBreaks(schedule) {
+		if b.Paid {
+			continue
+		}
+
+		if b.Start.After(start) {
+			worked = append(worked, Interval{Start: start, End: b.Start})
+		}
+
+		start = b.End
+	}
+
+	if exit.After(start) {
+		worked = append(worked, Interval{Start: start, End: exit})
+	}
+
+	return worked
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,265 @@
+package worktime
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alexanderbkl/vidre-back/internal/entity"
+)
+
+// Day types of extra hour windows besides the names of weekdays, e.g. "monday".
+const (
+	DayTypeAll     = "all"
+	DayTypeWeekday = "weekday"
+	DayTypeWeekend = "weekend"
+	DayTypeFestivo = "festivo"
+)
+
+// dayTypes maps the accepted day type names, in English and Spanish, to day types.
+var dayTypes = map[string]string{
+	"":              DayTypeAll,
+	"all":           DayTypeAll,
+	"todos":         DayTypeAll,
+	"weekday":       DayTypeWeekday,
+	"weekdays":      DayTypeWeekday,
+	"laborable":     DayTypeWeekday,
+	"weekend":       DayTypeWeekend,
+	"fin de semana": DayTypeWeekend,
+	"festivo":       DayTypeFestivo,
+	"holiday":       DayTypeFestivo,
+	"monday":        "monday",
+	"lunes":         "monday",
+	"tuesday":       "tuesday",
+	"martes":        "tuesday",
+	"wednesday":     "wednesday",
+	"miercoles":     "wednesday",
+	"miércoles":     "wednesday",
+	"thursday":      "thursday",
+	"jueves":        "thursday",
+	"friday":        "friday",
+	"viernes":       "friday",
+	"saturday":      "saturday",
+	"sabado":        "saturday",
+	"sábado":        "saturday",
+	"sunday":        "sunday",
+	"domingo":       "sunday",
+}
+
+var ErrInvalidWindow = errors.New("invalid extra hour window")
+
+// Window is a daily time window in which worked time is overtime. Windows
+// that end before they start continue on the next day, e.g. 22:00 to 06:00.
+type Window struct {
+	DayType string
+	Start   time.Duration
+	End     time.Duration
+}
+
+// ParseWindow returns the window of an extra hour setting. Its start and end
+// hour are wall clock times like "18:00".
+func ParseWindow(extraHour entity.ExtraHour) (Window, error) {
+	dayType, ok := dayTypes[strings.ToLower(strings.TrimSpace(extraHour.DayType))]
+	if !ok {
+		return Window{}, fmt.Errorf("%w: unknown day type %s", ErrInvalidWindow, extraHour.DayType)
+	}
+
+	start, err := parseClock(extraHour.StartHour)
+	if err != nil {
+		return Window{}, err
+	}
+
+	end, err := parseClock(extraHour.EndHour)
+	if err != nil {
+		return Window{}, err
+	}
+
+	return Window{DayType: dayType, Start: start, End: end}, nil
+}
+
+// parseClock returns the time of day of a wall clock time like "18:00".
+func parseClock(value string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, fmt.Errorf("%w: invalid hour %s", ErrInvalidWindow, value)
+	}
+
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// Overtime contains worked time split into regular time and overtime.
+type Overtime struct {
+	Regular time.Duration
+	// Window is overtime within extra hour windows on working days.
+	Window time.Duration
+	// Weekend is the time worked on Saturdays and Sundays.
+	Weekend time.Duration
+	// Festivo is the time worked on festivos.
+	Festivo time.Duration
+}
+
+// Total returns the overtime.
+func (o Overtime) Total() time.Duration {
+	return o.Window + o.Weekend + o.Festivo
+}
+
+// Add adds the times of another day.
+func (o *Overtime) Add(other Overtime) {
+	o.Regular += other.Regular
+	o.Window += other.Window
+	o.Weekend += other.Weekend
+	o.Festivo += other.Festivo
+}
+
+// Calendar classifies worked time as regular time or overtime. All worked
+// time on weekends and festivos is overtime; on other days, worked time within
+// the extra hour windows that apply to the day is. A window that continues on
+// the next day applies according to the day it starts on.
+type Calendar struct {
+	Location *time.Location
+	Festivos map[string]bool
+	Windows  []Window
+}
+
+// NewCalendar returns a calendar with the festivo dates and windows.
+func NewCalendar(loc *time.Location, festivos entity.Festivos, windows []Window) *Calendar {
+	c := &Calendar{Location: loc, Festivos: make(map[string]bool, len(festivos)), Windows: windows}
+
+	for _, f := range festivos {
+		c.Festivos[f.Date] = true
+	}
+
+	return c
+}
+
+// OvertimeDay contains the overtime breakdown of a work day.
+type OvertimeDay struct {
+	Date time.Time
+	Overtime
+}
+
+// OvertimeMonth contains the overtime breakdown of the work days of a month.
+type OvertimeMonth struct {
+	Month string
+	Overtime
+}
+
+// OvertimeSummary contains the overtime breakdown per day and month.
+type OvertimeSummary struct {
+	Days   []OvertimeDay
+	Months []OvertimeMonth
+	Total  Overtime
+}
+
+// Summarize returns the overtime breakdown of the work schedules. Worked time
+// is attributed to the date of its work schedule, where the shift started.
+func (c *Calendar) Summarize(schedules entity.WorkSchedules) OvertimeSummary {
+	summary := OvertimeSummary{Days: make([]OvertimeDay, 0, len(schedules)), Months: []OvertimeMonth{}}
+	months := make(map[string]int)
+
+	for i := range schedules {
+		day := OvertimeDay{Date: schedules[i].Date, Overtime: c.Split(&schedules[i])}
+		summary.Days = append(summary.Days, day)
+		summary.Total.Add(day.Overtime)
+
+		month := day.Date.Format("2006-01")
+		if j, ok := months[month]; ok {
+			summary.Months[j].Add(day.Overtime)
+		} else {
+			months[month] = len(summary.Months)
+			summary.Months = append(summary.Months, OvertimeMonth{Month: month, Overtime: day.Overtime})
+		}
+	}
+
+	sort.SliceStable(summary.Months, func(i, j int) bool {
+		return summary.Months[i].Month < summary.Months[j].Month
+	})
+
+	return summary
+}
+
+// Split returns the overtime breakdown of the net worked time of a work schedule.
+func (c *Calendar) Split(schedule *entity.WorkSchedule) Overtime {
+	var result Overtime
+
+	for _, worked := range Worked(schedule) {
+		for start := worked.Start; start.Before(worked.End); {
+			y, m, d := start.In(c.Location).Date()
+			day := time.Date(y, m, d, 0, 0, 0, 0, c.Location)
+			end := minTime(worked.End, time.Date(y, m, d+1, 0, 0, 0, 0, c.Location))
+
+			switch {
+			case c.Festivos[day.Format("2006-01-02")]:
+				result.Festivo += end.Sub(start)
+			case day.Weekday() == time.Saturday || day.Weekday() == time.Sunday:
+				result.Weekend += end.Sub(start)
+			default:
+				window := c.windowTime(day, Interval{Start: start, End: end})
+				result.Window += window
+				result.Regular += end.Sub(start) - window
+			}
+
+			start = end
+		}
+	}
+
+	return result
+}
+
+// windowTime returns the time of an interval within a weekday that falls in
+// the windows, counting overlapping windows once.
+func (c *Calendar) windowTime(day time.Time, interval Interval) time.Duration {
+	var overlaps []Interval
+
+	y, m, d := day.Date()
+
+	for _, w := range c.Windows {
+		// Windows that end on the next day may also cover the start of this day.
+		for _, offset := range []int{-1, 0} {
+			if !c.applies(w, time.Date(y, m, d+offset, 0, 0, 0, 0, c.Location)) {
+				continue
+			}
+
+			start := wallClock(y, m, d+offset, w.Start, c.Location)
+			end := wallClock(y, m, d+offset, w.End, c.Location)
+
+			if !end.After(start) {
+				end = wallClock(y, m, d+offset+1, w.End, c.Location)
+			} else if offset != 0 {
+				continue
+			}
+
+			if overlap, ok := interval.Intersect(Interval{Start: start, End: end}); ok {
+				overlaps = append(overlaps, overlap)
+			}
+		}
+	}
+
+	return Union(overlaps)
+}
+
+// applies tests if the window applies to the day.
+func (c *Calendar) applies(w Window, day time.Time) bool {
+	festivo := c.Festivos[day.Format("2006-01-02")]
+	weekend := day.Weekday() == time.Saturday || day.Weekday() == time.Sunday
+
+	switch w.DayType {
+	case DayTypeAll:
+		return true
+	case DayTypeWeekday:
+		return !weekend && !festivo
+	case DayTypeWeekend:
+		return weekend
+	case DayTypeFestivo:
+		return festivo
+	default:
+		return w.DayType == strings.ToLower(day.Weekday().String())
+	}
+}
+
+// wallClock returns the wall clock time of day on a date in the location.
+func wallClock(y int, m time.Month, d int, clock time.Duration, loc *time.Location) time.Time {
+	return time.Date(y, m, d, int(clock/time.Hour), int(clock%time.Hour/time.Minute), 0, 0, loc)
+}
@@ -0,0 +1,225 @@
+package worktime
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alexanderbkl/vidre-back/internal/entity"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseWindow(t *testing.T) {
+	testCases := []struct {
+		name      string
+		extraHour entity.ExtraHour
+		expected  Window
+		err       bool
+	}{
+		{
+			name:      "Weekday",
+			extraHour: entity.ExtraHour{DayType: "laborable", StartHour: "18:00", EndHour: "22:30"},
+			expected:  Window{DayType: DayTypeWeekday, Start: 18 * time.Hour, End: 22*time.Hour + 30*time.Minute},
+		},
+		{
+			name:      "Name",
+			extraHour: entity.ExtraHour{DayType: "Viernes", StartHour: "14:00", EndHour: "15:00"},
+			expected:  Window{DayType: "friday", Start: 14 * time.Hour, End: 15 * time.Hour},
+		},
+		{
+			name:      "EmptyDayType",
+			extraHour: entity.ExtraHour{StartHour: "22:00", EndHour: "06:00"},
+			expected:  Window{DayType: DayTypeAll, Start: 22 * time.Hour, End: 6 * time.Hour},
+		},
+		{name: "UnknownDayType", extraHour: entity.ExtraHour{DayType: "someday", StartHour: "18:00", EndHour: "20:00"}, err: true},
+		{name: "InvalidHour", extraHour: entity.ExtraHour{DayType: "all", StartHour: "6pm", EndHour: "20:00"}, err: true},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			w, err := ParseWindow(tc.extraHour)
+			if tc.err {
+				require.ErrorIs(t, err, ErrInvalidWindow)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, w)
+		})
+	}
+}
+
+func TestSplit(t *testing.T) {
+	madrid, err := time.LoadLocation("Europe/Madrid")
+	require.NoError(t, err)
+
+	// local returns a time on the day of March 2024 in Madrid.
+	local := func(day, hour, min int) time.Time {
+		return time.Date(2024, 3, day, hour, min, 0, 0, madrid)
+	}
+
+	evenings := Window{DayType: DayTypeWeekday, Start: 18 * time.Hour, End: 22 * time.Hour}
+	nights := Window{DayType: DayTypeAll, Start: 22 * time.Hour, End: 6 * time.Hour}
+	sundayNights := Window{DayType: "sunday", Start: 22 * time.Hour, End: 6 * time.Hour}
+	festivos := entity.Festivos{{Date: "2024-03-19"}}
+
+	testCases := []struct {
+		name     string
+		windows  []Window
+		schedule entity.WorkSchedule
+		expected Overtime
+	}{
+		{
+			name:     "Incomplete",
+			windows:  []Window{evenings},
+			schedule: entity.WorkSchedule{EntryHour: local(4, 8, 0)},
+		},
+		{
+			name:     "Regular",
+			windows:  []Window{evenings},
+			schedule: entity.WorkSchedule{EntryHour: local(4, 8, 0), ExitHour: local(4, 17, 0)},
+			expected: Overtime{Regular: 9 * time.Hour},
+		},
+		{
+			name:    "StaysLate",
+			windows: []Window{evenings},
+			schedule: entity.WorkSchedule{EntryHour: local(4, 8, 0), ExitHour: local(4, 20, 0), Breaks: entity.WorkBreaks{
+				unpaid(local(4, 14, 0), local(4, 15, 0)),
+			}},
+			expected: Overtime{Regular: 9 * time.Hour, Window: 2 * time.Hour},
+		},
+		{
+			name:    "UnpaidBreakInWindow",
+			windows: []Window{evenings},
+			schedule: entity.WorkSchedule{EntryHour: local(4, 8, 0), ExitHour: local(4, 20, 0), Breaks: entity.WorkBreaks{
+				unpaid(local(4, 18, 0), local(4, 18, 30)),
+				paid(local(4, 19, 0), local(4, 19, 15)),
+			}},
+			expected: Overtime{Regular: 10 * time.Hour, Window: 90 * time.Minute},
+		},
+		{
+			name:    "PaidBreakOverlapsUnpaid",
+			windows: []Window{evenings},
+			schedule: entity.WorkSchedule{EntryHour: local(4, 8, 0), ExitHour: local(4, 17, 0), Breaks: entity.WorkBreaks{
+				unpaid(local(4, 14, 30), local(4, 15, 30)),
+				paid(local(4, 14, 0), local(4, 15, 0)),
+			}},
+			expected: Overtime{Regular: 8*time.Hour + 30*time.Minute},
+		},
+		{
+			name:    "UnpaidBreakOverlapsPaid",
+			windows: []Window{evenings},
+			schedule: entity.WorkSchedule{EntryHour: local(4, 8, 0), ExitHour: local(4, 17, 0), Breaks: entity.WorkBreaks{
+				unpaid(local(4, 14, 0), local(4, 15, 0)),
+				paid(local(4, 14, 30), local(4, 15, 30)),
+			}},
+			expected: Overtime{Regular: 8 * time.Hour},
+		},
+		{
+			name:     "OverlappingWindows",
+			windows:  []Window{evenings, nights},
+			schedule: entity.WorkSchedule{EntryHour: local(4, 16, 0), ExitHour: local(4, 23, 0)},
+			expected: Overtime{Regular: 2 * time.Hour, Window: 5 * time.Hour},
+		},
+		{
+			name:     "NightShiftAcrossMidnight",
+			windows:  []Window{nights},
+			schedule: entity.WorkSchedule{EntryHour: local(5, 20, 0), ExitHour: local(6, 7, 0)},
+			expected: Overtime{Regular: 3 * time.Hour, Window: 8 * time.Hour},
+		},
+		{
+			name:     "FridayNightIntoSaturday",
+			windows:  []Window{evenings},
+			schedule: entity.WorkSchedule{EntryHour: local(8, 20, 0), ExitHour: local(9, 4, 0)},
+			expected: Overtime{Regular: 2 * time.Hour, Window: 2 * time.Hour, Weekend: 4 * time.Hour},
+		},
+		{
+			name:     "SundayWindowIntoMonday",
+			windows:  []Window{sundayNights},
+			schedule: entity.WorkSchedule{EntryHour: local(10, 23, 0), ExitHour: local(11, 7, 0)},
+			expected: Overtime{Regular: time.Hour, Weekend: time.Hour, Window: 6 * time.Hour},
+		},
+		{
+			name:     "Saturday",
+			windows:  []Window{evenings},
+			schedule: entity.WorkSchedule{EntryHour: local(9, 8, 0), ExitHour: local(9, 14, 0)},
+			expected: Overtime{Weekend: 6 * time.Hour},
+		},
+		{
+			name:     "Festivo",
+			windows:  []Window{evenings},
+			schedule: entity.WorkSchedule{EntryHour: local(19, 8, 0), ExitHour: local(19, 19, 0)},
+			expected: Overtime{Festivo: 11 * time.Hour},
+		},
+		{
+			name:     "ChangeToSummerTime",
+			windows:  []Window{nights},
+			schedule: entity.WorkSchedule{EntryHour: local(30, 22, 0), ExitHour: local(31, 6, 0)},
+			expected: Overtime{Weekend: 7 * time.Hour},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			c := NewCalendar(madrid, festivos, tc.windows)
+			overtime := c.Split(&tc.schedule)
+			require.Equal(t, tc.expected, overtime)
+			require.Equal(t, tc.expected.Window+tc.expected.Weekend+tc.expected.Festivo, overtime.Total())
+
+			// Regular time and overtime add up to the net worked time.
+			day := Compute(&tc.schedule)
+			require.Equal(t, day.Net, overtime.Regular+overtime.Total())
+		})
+	}
+}
+
+func TestCalendarSummarize(t *testing.T) {
+	madrid, err := time.LoadLocation("Europe/Madrid")
+	require.NoError(t, err)
+
+	local := func(month time.Month, day, hour int) time.Time {
+		return time.Date(2024, month, day, hour, 0, 0, 0, madrid)
+	}
+
+	date := func(month time.Month, day int) time.Time {
+		return time.Date(2024, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	c := NewCalendar(madrid, nil, []Window{{DayType: DayTypeWeekday, Start: 18 * time.Hour, End: 22 * time.Hour}})
+
+	summary := c.Summarize(entity.WorkSchedules{
+		{Date: date(3, 29), EntryHour: local(3, 29, 8), ExitHour: local(3, 29, 19)},
+		{Date: date(3, 30), EntryHour: local(3, 30, 9), ExitHour: local(3, 30, 13)},
+		{Date: date(4, 1), EntryHour: local(4, 1, 8), ExitHour: local(4, 1, 16)},
+	})
+
+	require.Len(t, summary.Days, 3)
+	require.Equal(t, []OvertimeMonth{
+		{Month: "2024-03", Overtime: Overtime{Regular: 10 * time.Hour, Window: time.Hour, Weekend: 4 * time.Hour}},
+		{Month: "2024-04", Overtime: Overtime{Regular: 8 * time.Hour}},
+	}, summary.Months)
+	require.Equal(t, Overtime{Regular: 18 * time.Hour, Window: time.Hour, Weekend: 4 * time.Hour}, summary.Total)
+}
+
+func TestWorked(t *testing.T) {
+	schedule := entity.WorkSchedule{EntryHour: at(8, 0), ExitHour: at(17, 0), Breaks: entity.WorkBreaks{
+		unpaid(at(14, 0), at(15, 0)),
+		paid(at(10, 0), at(10, 15)),
+		unpaid(at(7, 0), at(8, 30)),
+		unpaid(at(16, 30), time.Time{}),
+	}}
+
+	require.Equal(t, []Interval{
+		{Start: at(8, 30), End: at(14, 0)},
+		{Start: at(15, 0), End: at(16, 30)},
+	}, Worked(&schedule))
+
+	require.Nil(t, Worked(&entity.WorkSchedule{EntryHour: at(8, 0)}))
+	require.Equal(t, 2*time.Hour, Union([]Interval{
+		{Start: at(9, 0), End: at(10, 0)},
+		{Start: at(8, 0), End: at(9, 30)},
+	}))
+}
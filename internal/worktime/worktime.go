@@ -0,0 +1,157 @@
+/*
+Package worktime computes worked time from work schedules.
+
+The gross time of a day runs from its entry to its exit. Breaks are limited
+to that interval and must not overlap; overlapping parts are only counted
+once, for the break that starts first. Paid breaks count as worked time, unpaid breaks do not, so the net
+time is the gross time less the unpaid breaks.
+
+A Calendar further splits the net worked time into regular time and
+overtime, see Calendar.Split.
+*/
+package worktime
+
+import (
+	"sort"
+	"time"
+
+	"github.com/alexanderbkl/vidre-back/internal/clock"
+	"github.com/alexanderbkl/vidre-back/internal/entity"
+)
+
+// Times contains the worked time of one or more days.
+type Times struct {
+	Gross        time.Duration
+	Breaks       time.Duration
+	PaidBreaks   time.Duration
+	UnpaidBreaks time.Duration
+	Net          time.Duration
+}
+
+// Add adds the times of another day.
+func (t *Times) Add(other Times) {
+	t.Gross += other.Gross
+	t.Breaks += other.Breaks
+	t.PaidBreaks += other.PaidBreaks
+	t.UnpaidBreaks += other.UnpaidBreaks
+	t.Net += other.Net
+}
+
+// Day contains the worked time of a work day. Days without entry or exit
+// are incomplete and have no worked time.
+type Day struct {
+	Date     time.Time
+	Complete bool
+	Times
+}
+
+// Compute returns the worked time of the work schedule. An open break ends
+// with the exit.
+func Compute(schedule *entity.WorkSchedule) Day {
+	day := Day{Date: schedule.Date}
+
+	entry, exit := schedule.EntryHour, schedule.ExitHour
+	if entry.IsZero() || exit.IsZero() || exit.Before(entry) {
+		return day
+	}
+
+	day.Complete = true
+	day.Gross = exit.Sub(entry)
+
+	for _, b := range countedBreaks(schedule) {
+		d := b.Duration()
+		day.Breaks += d
+
+		if b.Paid {
+			day.PaidBreaks += d
+		} else {
+			day.UnpaidBreaks += d
+		}
+	}
+
+	day.Net = day.Gross - day.UnpaidBreaks
+
+	return day
+}
+
+// countedBreak is the part of a break that counts for the worked time.
+type countedBreak struct {
+	Interval
+	Paid bool
+}
+
+// countedBreaks returns the breaks of a work schedule with entry and exit,
+// limited to that interval and in the order they start. Overlapping parts are
+// counted once, for the break that starts first. An open break ends with the
+// exit.
+func countedBreaks(schedule *entity.WorkSchedule) []countedBreak {
+	entry, exit := schedule.EntryHour, schedule.ExitHour
+
+	breaks := make(entity.WorkBreaks, len(schedule.Breaks))
+	copy(breaks, schedule.Breaks)
+
+	sort.SliceStable(breaks, func(i, j int) bool {
+		return breaks[i].StartHour.Before(breaks[j].StartHour)
+	})
+
+	var counted []countedBreak
+
+	// end is the end of the break time counted so far.
+	end := entry
+
+	for _, b := range breaks {
+		interval := Interval{Start: b.StartHour, End: b.EndHour}
+
+		if interval.Start.IsZero() {
+			continue
+		} else if interval.End.IsZero() || interval.End.After(exit) {
+			interval.End = exit
+		}
+
+		if interval.Start.Before(end) {
+			interval.Start = end
+		}
+
+		if !interval.End.After(interval.Start) {
+			continue
+		}
+
+		counted = append(counted, countedBreak{Interval: interval, Paid: b.Type == entity.BreakPaid})
+		end = interval.End
+	}
+
+	return counted
+}
+
+// ComputePunches returns the worked time of a day from its punches.
+func ComputePunches(date time.Time, punches entity.Punches) Day {
+	schedule := entity.WorkSchedule{Date: date}
+	clock.Project(&schedule, punches)
+
+	return Compute(&schedule)
+}
+
+// Summary contains the worked time of a date range.
+type Summary struct {
+	Days       []Day
+	Total      Times
+	Incomplete int
+}
+
+// Summarize returns the worked time of each work schedule and their total.
+func Summarize(schedules entity.WorkSchedules) Summary {
+	summary := Summary{Days: make([]Day, 0, len(schedules))}
+
+	for i := range schedules {
+		day := Compute(&schedules[i])
+
+		if !day.Complete {
+			summary.Incomplete++
+		}
+
+		summary.Days = append(summary.Days, day)
+		summary.Total.Add(day.Times)
+	}
+
+	return summary
+}
@@ -0,0 +1,190 @@
+package worktime
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alexanderbkl/vidre-back/internal/entity"
+	"github.com/stretchr/testify/require"
+)
+
+var date = time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+
+func at(hour, min int) time.Time {
+	return date.Add(time.Duration(hour)*time.Hour + time.Duration(min)*time.Minute)
+}
+
+func unpaid(start, end time.Time) entity.WorkBreak {
+	return entity.WorkBreak{Type: entity.BreakUnpaid, StartHour: start, EndHour: end}
+}
+
+func paid(start, end time.Time) entity.WorkBreak {
+	return entity.WorkBreak{Type: entity.BreakPaid, StartHour: start, EndHour: end}
+}
+
+func TestCompute(t *testing.T) {
+	testCases := []struct {
+		name     string
+		schedule entity.WorkSchedule
+		complete bool
+		expected Times
+	}{
+		{
+			name: "Empty",
+		},
+		{
+			name:     "NoExit",
+			schedule: entity.WorkSchedule{EntryHour: at(8, 0)},
+		},
+		{
+			name:     "NoEntry",
+			schedule: entity.WorkSchedule{ExitHour: at(17, 0)},
+		},
+		{
+			name:     "ExitBeforeEntry",
+			schedule: entity.WorkSchedule{EntryHour: at(17, 0), ExitHour: at(8, 0)},
+		},
+		{
+			name:     "NoBreaks",
+			schedule: entity.WorkSchedule{EntryHour: at(8, 0), ExitHour: at(16, 30)},
+			complete: true,
+			expected: Times{Gross: 8*time.Hour + 30*time.Minute, Net: 8*time.Hour + 30*time.Minute},
+		},
+		{
+			name: "UnpaidLunch",
+			schedule: entity.WorkSchedule{EntryHour: at(8, 0), ExitHour: at(17, 0), Breaks: entity.WorkBreaks{
+				unpaid(at(14, 0), at(15, 0)),
+			}},
+			complete: true,
+			expected: Times{Gross: 9 * time.Hour, Breaks: time.Hour, UnpaidBreaks: time.Hour, Net: 8 * time.Hour},
+		},
+		{
+			name: "PaidAndUnpaid",
+			schedule: entity.WorkSchedule{EntryHour: at(8, 0), ExitHour: at(17, 0), Breaks: entity.WorkBreaks{
+				paid(at(10, 0), at(10, 15)),
+				unpaid(at(14, 0), at(15, 0)),
+			}},
+			complete: true,
+			expected: Times{
+				Gross:        9 * time.Hour,
+				Breaks:       time.Hour + 15*time.Minute,
+				PaidBreaks:   15 * time.Minute,
+				UnpaidBreaks: time.Hour,
+				Net:          8 * time.Hour,
+			},
+		},
+		{
+			name: "NightShiftThreeBreaks",
+			schedule: entity.WorkSchedule{EntryHour: at(22, 0), ExitHour: at(30, 0), Breaks: entity.WorkBreaks{
+				paid(at(24, 0), at(24, 15)),
+				unpaid(at(26, 0), at(26, 30)),
+				paid(at(28, 0), at(28, 15)),
+			}},
+			complete: true,
+			expected: Times{
+				Gross:        8 * time.Hour,
+				Breaks:       time.Hour,
+				PaidBreaks:   30 * time.Minute,
+				UnpaidBreaks: 30 * time.Minute,
+				Net:          7*time.Hour + 30*time.Minute,
+			},
+		},
+		{
+			name: "OpenBreakEndsWithExit",
+			schedule: entity.WorkSchedule{EntryHour: at(8, 0), ExitHour: at(17, 0), Breaks: entity.WorkBreaks{
+				unpaid(at(16, 30), time.Time{}),
+			}},
+			complete: true,
+			expected: Times{Gross: 9 * time.Hour, Breaks: 30 * time.Minute, UnpaidBreaks: 30 * time.Minute, Net: 8*time.Hour + 30*time.Minute},
+		},
+		{
+			name: "BreakOutsideShift",
+			schedule: entity.WorkSchedule{EntryHour: at(8, 0), ExitHour: at(17, 0), Breaks: entity.WorkBreaks{
+				unpaid(at(7, 30), at(8, 30)),
+				unpaid(at(16, 45), at(17, 30)),
+				unpaid(at(18, 0), at(19, 0)),
+			}},
+			complete: true,
+			expected: Times{Gross: 9 * time.Hour, Breaks: 45 * time.Minute, UnpaidBreaks: 45 * time.Minute, Net: 8*time.Hour + 15*time.Minute},
+		},
+		{
+			name: "OverlappingBreaks",
+			schedule: entity.WorkSchedule{EntryHour: at(8, 0), ExitHour: at(17, 0), Breaks: entity.WorkBreaks{
+				unpaid(at(14, 30), at(15, 30)),
+				paid(at(14, 0), at(15, 0)),
+			}},
+			complete: true,
+			expected: Times{
+				Gross:        9 * time.Hour,
+				Breaks:       90 * time.Minute,
+				PaidBreaks:   time.Hour,
+				UnpaidBreaks: 30 * time.Minute,
+				Net:          8*time.Hour + 30*time.Minute,
+			},
+		},
+		{
+			name: "BreakWithoutStart",
+			schedule: entity.WorkSchedule{EntryHour: at(8, 0), ExitHour: at(17, 0), Breaks: entity.WorkBreaks{
+				unpaid(time.Time{}, at(10, 0)),
+			}},
+			complete: true,
+			expected: Times{Gross: 9 * time.Hour, Net: 9 * time.Hour},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			tc.schedule.Date = date
+
+			day := Compute(&tc.schedule)
+			require.Equal(t, date, day.Date)
+			require.Equal(t, tc.complete, day.Complete)
+			require.Equal(t, tc.expected, day.Times)
+		})
+	}
+}
+
+func TestComputePunches(t *testing.T) {
+	punches := entity.Punches{
+		{Type: entity.PunchEntry, Time: at(8, 0)},
+		{Type: entity.PunchStartRest, Time: at(10, 0), BreakType: entity.BreakPaid},
+		{Type: entity.PunchEndRest, Time: at(10, 15)},
+		{Type: entity.PunchStartRest, Time: at(14, 0)},
+		{Type: entity.PunchEndRest, Time: at(14, 45)},
+		{Type: entity.PunchExit, Time: at(17, 0)},
+	}
+
+	day := ComputePunches(date, punches)
+	require.True(t, day.Complete)
+	require.Equal(t, Times{
+		Gross:        9 * time.Hour,
+		Breaks:       time.Hour,
+		PaidBreaks:   15 * time.Minute,
+		UnpaidBreaks: 45 * time.Minute,
+		Net:          8*time.Hour + 15*time.Minute,
+	}, day.Times)
+}
+
+func TestSummarize(t *testing.T) {
+	schedules := entity.WorkSchedules{
+		{Date: date, EntryHour: at(8, 0), ExitHour: at(17, 0), Breaks: entity.WorkBreaks{unpaid(at(14, 0), at(15, 0))}},
+		{Date: date.AddDate(0, 0, 1), EntryHour: at(32, 0), ExitHour: at(38, 0), Breaks: entity.WorkBreaks{paid(at(34, 0), at(34, 30))}},
+		{Date: date.AddDate(0, 0, 2), EntryHour: at(56, 0)},
+	}
+
+	summary := Summarize(schedules)
+	require.Len(t, summary.Days, 3)
+	require.Equal(t, 1, summary.Incomplete)
+	require.False(t, summary.Days[2].Complete)
+	require.Equal(t, Times{
+		Gross:        15 * time.Hour,
+		Breaks:       90 * time.Minute,
+		PaidBreaks:   30 * time.Minute,
+		UnpaidBreaks: time.Hour,
+		Net:          14 * time.Hour,
+	}, summary.Total)
+
+	require.Equal(t, Summary{Days: []Day{}}, Summarize(nil))
+}
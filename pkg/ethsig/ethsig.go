@@ -0,0 +1,100 @@
+/*
+Package ethsig verifies Ethereum personal_sign (EIP-191) message signatures.
+*/
+package ethsig
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	"golang.org/x/crypto/sha3"
+)
+
+// SignatureLength is the length of an R || S || V signature in bytes.
+const SignatureLength = 65
+
+var (
+	ErrInvalidAddress   = errors.New("invalid wallet address")
+	ErrInvalidSignature = errors.New("invalid signature")
+)
+
+// Keccak256 returns the legacy Keccak-256 hash used by Ethereum.
+func Keccak256(data ...[]byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	for _, b := range data {
+		h.Write(b)
+	}
+	return h.Sum(nil)
+}
+
+// HashMessage returns the hash that personal_sign signs for the message.
+func HashMessage(msg []byte) []byte {
+	prefix := fmt.Sprintf("\x19Ethereum Signed Message:\n%d", len(msg))
+	return Keccak256([]byte(prefix), msg)
+}
+
+// PubkeyToAddress returns the lower case hex address of a public key.
+func PubkeyToAddress(pub *secp256k1.PublicKey) string {
+	return "0x" + hex.EncodeToString(Keccak256(pub.SerializeUncompressed()[1:])[12:])
+}
+
+// NormalizeAddress validates a hex address and returns it in lower case.
+func NormalizeAddress(address string) (string, error) {
+	address = strings.ToLower(strings.TrimSpace(address))
+	address = strings.TrimPrefix(address, "0x")
+
+	if len(address) != 40 {
+		return "", ErrInvalidAddress
+	}
+
+	if _, err := hex.DecodeString(address); err != nil {
+		return "", ErrInvalidAddress
+	}
+
+	return "0x" + address, nil
+}
+
+// RecoverAddress returns the address that signed the message with personal_sign.
+// The signature is hex encoded R || S || V, where V is 0, 1, 27 or 28.
+func RecoverAddress(msg []byte, signature string) (string, error) {
+	sig, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(signature), "0x"))
+	if err != nil || len(sig) != SignatureLength {
+		return "", ErrInvalidSignature
+	}
+
+	v := sig[64]
+	if v >= 27 {
+		v -= 27
+	}
+
+	if v > 1 {
+		return "", ErrInvalidSignature
+	}
+
+	// Convert to the compact format [27 + recovery id] || R || S.
+	compact := make([]byte, SignatureLength)
+	compact[0] = 27 + v
+	copy(compact[1:], sig[:64])
+
+	pub, _, err := ecdsa.RecoverCompact(compact, HashMessage(msg))
+	if err != nil {
+		return "", ErrInvalidSignature
+	}
+
+	return PubkeyToAddress(pub), nil
+}
+
+// Sign signs the message with personal_sign and returns the hex encoded signature.
+func Sign(key *secp256k1.PrivateKey, msg []byte) string {
+	compact := ecdsa.SignCompact(key, HashMessage(msg), false)
+
+	sig := make([]byte, SignatureLength)
+	copy(sig, compact[1:])
+	sig[64] = compact[0]
+
+	return "0x" + hex.EncodeToString(sig)
+}
@@ -0,0 +1,73 @@
+package ethsig
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/stretchr/testify/require"
+)
+
+// Test vector from the web3.js accounts documentation.
+const (
+	testPrivateKey = "4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318"
+	testAddress    = "0x2c7536e3605d9c16a7a3d7b1898e529396a65c23"
+	testMessage    = "Some data"
+	testSignature  = "0xb91467e570a6466aa9e9876cbcd013baba02900b8979d43fe208a4a4f339f5fd6007e74cd82e037b800186422fc2da167c747ef045e5d18a5f5d4300f8e1a0291c"
+)
+
+func testKey(t *testing.T) *secp256k1.PrivateKey {
+	b, err := hex.DecodeString(testPrivateKey)
+	require.NoError(t, err)
+	return secp256k1.PrivKeyFromBytes(b)
+}
+
+func TestPubkeyToAddress(t *testing.T) {
+	require.Equal(t, testAddress, PubkeyToAddress(testKey(t).PubKey()))
+}
+
+func TestRecoverAddress(t *testing.T) {
+	address, err := RecoverAddress([]byte(testMessage), testSignature)
+	require.NoError(t, err)
+	require.Equal(t, testAddress, address)
+}
+
+func TestSign(t *testing.T) {
+	require.Equal(t, testSignature, Sign(testKey(t), []byte(testMessage)))
+
+	key, err := secp256k1.GeneratePrivateKey()
+	require.NoError(t, err)
+
+	msg := []byte("nonce: 123")
+	address, err := RecoverAddress(msg, Sign(key, msg))
+	require.NoError(t, err)
+	require.Equal(t, PubkeyToAddress(key.PubKey()), address)
+
+	// A signature over another message recovers another address.
+	address, err = RecoverAddress([]byte("nonce: 124"), Sign(key, msg))
+	require.NoError(t, err)
+	require.NotEqual(t, PubkeyToAddress(key.PubKey()), address)
+}
+
+func TestRecoverAddressInvalid(t *testing.T) {
+	_, err := RecoverAddress([]byte(testMessage), "0x1234")
+	require.ErrorIs(t, err, ErrInvalidSignature)
+
+	_, err = RecoverAddress([]byte(testMessage), testSignature[:len(testSignature)-2]+"05")
+	require.ErrorIs(t, err, ErrInvalidSignature)
+
+	_, err = RecoverAddress([]byte(testMessage), "not hex")
+	require.ErrorIs(t, err, ErrInvalidSignature)
+}
+
+func TestNormalizeAddress(t *testing.T) {
+	address, err := NormalizeAddress("0x2c7536E3605D9C16a7a3D7b1898e529396a65c23")
+	require.NoError(t, err)
+	require.Equal(t, testAddress, address)
+
+	_, err = NormalizeAddress("0x1234")
+	require.ErrorIs(t, err, ErrInvalidAddress)
+
+	_, err = NormalizeAddress("0xzz7536e3605d9c16a7a3d7b1898e529396a65c23")
+	require.ErrorIs(t, err, ErrInvalidAddress)
+}
@@ -0,0 +1,167 @@
+/*
+Package geo implements the geometry of geofences: circles and polygons on the
+earth's surface, and the distance of a point to them in meters.
+
+Distances between points are great-circle distances on a sphere with the mean
+earth radius. Polygons are projected onto a plane around the point they are
+compared with, which is accurate for areas of a few kilometers, as customer
+sites are. Polygons that cross the antimeridian are not supported.
+*/
+package geo
+
+import (
+	"errors"
+	"math"
+)
+
+// EarthRadius is the mean earth radius in meters.
+const EarthRadius = 6371008.8
+
+var (
+	ErrInvalidPoint   = errors.New("latitude must be between -90 and 90 and longitude between -180 and 180")
+	ErrInvalidRadius  = errors.New("radius must be positive")
+	ErrInvalidPolygon = errors.New("polygon must have at least 3 points")
+)
+
+// Point is a location in degrees.
+type Point struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+// Validate checks that the point has a valid latitude and longitude.
+func (p Point) Validate() error {
+	if !(p.Lat >= -90 && p.Lat <= 90 && p.Lng >= -180 && p.Lng <= 180) {
+		return ErrInvalidPoint
+	}
+
+	return nil
+}
+
+// Distance returns the great-circle distance between two points in meters.
+func Distance(a, b Point) float64 {
+	lat1, lat2 := radians(a.Lat), radians(b.Lat)
+	dLat := lat2 - lat1
+	dLng := radians(b.Lng - a.Lng)
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+
+	return 2 * EarthRadius * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// Shape is an area on the earth's surface.
+type Shape interface {
+	// Validate checks that the area is well defined.
+	Validate() error
+	// Distance returns the distance in meters from the point to the area,
+	// or zero if the area contains the point.
+	Distance(p Point) float64
+}
+
+// Contains tests if the area contains the point.
+func Contains(s Shape, p Point) bool {
+	return s.Distance(p) == 0
+}
+
+// Circle is the area within a radius in meters around its center.
+type Circle struct {
+	Center Point
+	Radius float64
+}
+
+// Validate checks the center and radius of the circle.
+func (c Circle) Validate() error {
+	if err := c.Center.Validate(); err != nil {
+		return err
+	} else if !(c.Radius > 0) {
+		return ErrInvalidRadius
+	}
+
+	return nil
+}
+
+// Distance returns the distance in meters from the point to the circle.
+func (c Circle) Distance(p Point) float64 {
+	return math.Max(0, Distance(c.Center, p)-c.Radius)
+}
+
+// Polygon is the area within a closed line through its points. The last
+// point is connected to the first one.
+type Polygon []Point
+
+// Validate checks that the polygon has at least three valid points.
+func (poly Polygon) Validate() error {
+	if len(poly) < 3 {
+		return ErrInvalidPolygon
+	}
+
+	for _, p := range poly {
+		if err := p.Validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Distance returns the distance in meters from the point to the polygon.
+func (poly Polygon) Distance(p Point) float64 {
+	if len(poly) == 0 {
+		return math.Inf(1)
+	}
+
+	// Project the polygon onto a plane with the point at its origin.
+	xy := make([]vector, len(poly))
+	for i, q := range poly {
+		xy[i] = project(p, q)
+	}
+
+	inside := false
+	distance := math.Inf(1)
+
+	for i, j := 0, len(xy)-1; i < len(xy); j, i = i, i+1 {
+		a, b := xy[j], xy[i]
+
+		// Count the edges that cross the ray from the origin to the east.
+		if (a.y > 0) != (b.y > 0) && a.x-a.y*(b.x-a.x)/(b.y-a.y) > 0 {
+			inside = !inside
+		}
+
+		distance = math.Min(distance, segmentDistance(a, b))
+	}
+
+	if inside {
+		return 0
+	}
+
+	return distance
+}
+
+// vector is a point on a plane in meters.
+type vector struct {
+	x, y float64
+}
+
+// project maps q onto an equirectangular plane in meters around the origin.
+func project(origin, q Point) vector {
+	return vector{
+		x: radians(q.Lng-origin.Lng) * math.Cos(radians(origin.Lat)) * EarthRadius,
+		y: radians(q.Lat-origin.Lat) * EarthRadius,
+	}
+}
+
+// segmentDistance returns the distance from the origin to the segment ab.
+func segmentDistance(a, b vector) float64 {
+	dx, dy := b.x-a.x, b.y-a.y
+
+	t := 0.0
+	if l := dx*dx + dy*dy; l > 0 {
+		t = math.Max(0, math.Min(1, -(a.x*dx+a.y*dy)/l))
+	}
+
+	return math.Hypot(a.x+t*dx, a.y+t*dy)
+}
+
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
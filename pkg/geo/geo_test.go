@@ -0,0 +1,92 @@
+package geo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// square is a polygon of about 222 by 222 meters around the origin.
+var square = Polygon{{-0.001, -0.001}, {-0.001, 0.001}, {0.001, 0.001}, {0.001, -0.001}}
+
+func TestDistance(t *testing.T) {
+	testCases := []struct {
+		name     string
+		a, b     Point
+		expected float64
+		delta    float64
+	}{
+		{"Same", Point{40.4168, -3.7038}, Point{40.4168, -3.7038}, 0, 0},
+		{"Degree", Point{0, 0}, Point{0, 1}, 111195, 1},
+		{"MadridBarcelona", Point{40.4168, -3.7038}, Point{41.3874, 2.1686}, 505000, 2000},
+		{"Antipodes", Point{0, 0}, Point{0, 180}, 20015114, 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.InDelta(t, tc.expected, Distance(tc.a, tc.b), tc.delta)
+			require.InDelta(t, tc.expected, Distance(tc.b, tc.a), tc.delta)
+		})
+	}
+}
+
+func TestCircle(t *testing.T) {
+	circle := Circle{Center: Point{40.4168, -3.7038}, Radius: 100}
+
+	require.True(t, Contains(circle, circle.Center))
+	require.True(t, Contains(circle, Point{40.4176, -3.7038}))
+	require.False(t, Contains(circle, Point{40.4180, -3.7038}))
+	require.InDelta(t, 33.4, circle.Distance(Point{40.4180, -3.7038}), 0.5)
+}
+
+func TestPolygon(t *testing.T) {
+	testCases := []struct {
+		name     string
+		point    Point
+		expected float64
+	}{
+		{"Center", Point{0, 0}, 0},
+		{"Inside", Point{0.0009, -0.0009}, 0},
+		{"East", Point{0, 0.002}, 111.2},
+		{"North", Point{0.0015, 0.0005}, 55.6},
+		{"Corner", Point{0.002, 0.002}, 157.3},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.InDelta(t, tc.expected, square.Distance(tc.point), 0.5)
+		})
+	}
+}
+
+func TestPolygonConcave(t *testing.T) {
+	// A U shape open to the north.
+	u := Polygon{{0, 0}, {0, 0.003}, {0.003, 0.003}, {0.003, 0.002}, {0.001, 0.002}, {0.001, 0.001}, {0.003, 0.001}, {0.003, 0}}
+
+	require.True(t, Contains(u, Point{0.002, 0.0005}))
+	require.True(t, Contains(u, Point{0.0005, 0.0015}))
+	require.False(t, Contains(u, Point{0.002, 0.0015}))
+	require.InDelta(t, 55.6, u.Distance(Point{0.002, 0.0015}), 0.5)
+}
+
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		name     string
+		shape    Shape
+		expected error
+	}{
+		{"Circle", Circle{Center: Point{40, -3}, Radius: 50}, nil},
+		{"ZeroRadius", Circle{Center: Point{40, -3}}, ErrInvalidRadius},
+		{"NegativeRadius", Circle{Center: Point{40, -3}, Radius: -1}, ErrInvalidRadius},
+		{"InvalidCenter", Circle{Center: Point{91, 0}, Radius: 50}, ErrInvalidPoint},
+		{"Polygon", square, nil},
+		{"TwoPoints", square[:2], ErrInvalidPolygon},
+		{"InvalidPoint", Polygon{{0, 0}, {0, 181}, {1, 1}}, ErrInvalidPoint},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, tc.shape.Validate())
+		})
+	}
+}
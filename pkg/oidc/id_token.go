@@ -0,0 +1,272 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ClockSkew is the tolerance for the expiry and issue time of ID tokens.
+const ClockSkew = time.Minute
+
+// keyRefreshInterval limits how often the keys are fetched again for unknown key ids.
+const keyRefreshInterval = time.Minute
+
+var (
+	ErrMalformedToken   = errors.New("oidc: malformed id token")
+	ErrUnsupportedAlg   = errors.New("oidc: unsupported signing algorithm")
+	ErrUnknownKey       = errors.New("oidc: unknown signing key")
+	ErrInvalidSignature = errors.New("oidc: invalid id token signature")
+	ErrInvalidIssuer    = errors.New("oidc: invalid issuer")
+	ErrInvalidAudience  = errors.New("oidc: invalid audience")
+	ErrInvalidNonce     = errors.New("oidc: invalid nonce")
+	ErrExpiredToken     = errors.New("oidc: id token has expired")
+)
+
+// Claims contains the claims of a verified ID token.
+type Claims struct {
+	Issuer            string   `json:"iss"`
+	Subject           string   `json:"sub"`
+	Audience          audience `json:"aud"`
+	Expiry            int64    `json:"exp"`
+	IssuedAt          int64    `json:"iat"`
+	Nonce             string   `json:"nonce"`
+	Email             string   `json:"email"`
+	Name              string   `json:"name"`
+	PreferredUsername string   `json:"preferred_username"`
+
+	// Raw contains all claims, e.g. to look up groups or roles.
+	Raw map[string]interface{} `json:"-"`
+}
+
+// Values returns the string values of a claim, which may be a string or a list of strings.
+func (c *Claims) Values(name string) []string {
+	switch v := c.Raw[name].(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	default:
+		return nil
+	}
+}
+
+// audience is a JSON string or list of strings.
+type audience []string
+
+func (a *audience) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*a = audience{s}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(b, &list); err != nil {
+		return err
+	}
+
+	*a = list
+
+	return nil
+}
+
+func (a audience) contains(s string) bool {
+	for _, v := range a {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// VerifyIDToken checks the signature, issuer, audience, expiry and nonce of
+// an ID token and returns its claims.
+func (p *Provider) VerifyIDToken(ctx context.Context, raw, nonce string) (*Claims, error) {
+	metadata, err := p.Metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformedToken
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, ErrMalformedToken
+	}
+
+	if header.Alg != "RS256" {
+		return nil, ErrUnsupportedAlg
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrMalformedToken
+	}
+
+	key, err := p.keys.get(ctx, p, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+		return nil, ErrInvalidSignature
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, ErrMalformedToken
+	}
+
+	if err := decodeSegment(parts[1], &claims.Raw); err != nil {
+		return nil, ErrMalformedToken
+	}
+
+	now := time.Now()
+
+	switch {
+	case claims.Issuer != metadata.Issuer:
+		return nil, ErrInvalidIssuer
+	case !claims.Audience.contains(p.config.ClientID):
+		return nil, ErrInvalidAudience
+	case claims.Expiry == 0 || now.After(time.Unix(claims.Expiry, 0).Add(ClockSkew)):
+		return nil, ErrExpiredToken
+	case claims.IssuedAt != 0 && now.Add(ClockSkew).Before(time.Unix(claims.IssuedAt, 0)):
+		return nil, ErrExpiredToken
+	case claims.Nonce != nonce:
+		return nil, ErrInvalidNonce
+	}
+
+	return &claims, nil
+}
+
+// decodeSegment decodes a base64url encoded JSON segment of a token.
+func decodeSegment(s string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(b, v)
+}
+
+// jsonWebKey is an RSA key of a JWKS document.
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// publicKey returns the RSA public key.
+func (k jsonWebKey) publicKey() (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, err
+	}
+
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, err
+	}
+
+	exponent := new(big.Int).SetBytes(e)
+	if !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 {
+		return nil, fmt.Errorf("oidc: invalid exponent of key %s", k.Kid)
+	}
+
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+}
+
+// keySet caches the signing keys of the provider.
+type keySet struct {
+	uri string
+
+	mu        sync.Mutex
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+}
+
+// get returns the key with the given id. The keys are fetched again if the
+// id is unknown, so that key rotation at the provider is picked up.
+func (s *keySet) get(ctx context.Context, p *Provider, kid string) (*rsa.PublicKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if key, ok := s.lookup(kid); ok {
+		return key, nil
+	}
+
+	if !s.fetchedAt.IsZero() && time.Since(s.fetchedAt) < keyRefreshInterval {
+		return nil, ErrUnknownKey
+	}
+
+	var doc struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+
+	if err := p.getJSON(ctx, s.uri, &doc); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(doc.Keys))
+	for _, k := range doc.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+
+		key, err := k.publicKey()
+		if err != nil {
+			continue
+		}
+
+		keys[k.Kid] = key
+	}
+
+	s.keys = keys
+	s.fetchedAt = time.Now()
+
+	if key, ok := s.lookup(kid); ok {
+		return key, nil
+	}
+
+	return nil, ErrUnknownKey
+}
+
+// lookup returns the key with the given id. Tokens without a key id are
+// accepted if the provider publishes a single key.
+func (s *keySet) lookup(kid string) (*rsa.PublicKey, bool) {
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key, true
+		}
+	}
+
+	key, ok := s.keys[kid]
+
+	return key, ok
+}
@@ -0,0 +1,297 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testClientID = "vidre"
+
+// mockProvider is a minimal OpenID Connect provider for tests.
+type mockProvider struct {
+	t      *testing.T
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	kid    string
+
+	// challenge and claims of the pending authorization code.
+	challenge string
+	claims    map[string]interface{}
+}
+
+func newMockProvider(t *testing.T) *mockProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	m := &mockProvider{t: t, key: key, kid: "key-1"}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, Metadata{
+			Issuer:                m.server.URL,
+			AuthorizationEndpoint: m.server.URL + "/authorize",
+			TokenEndpoint:         m.server.URL + "/token",
+			JwksURI:               m.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{"keys": []jsonWebKey{{
+			Kty: "RSA",
+			Kid: m.kid,
+			Use: "sig",
+			N:   base64.RawURLEncoding.EncodeToString(m.key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(m.key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+
+		if r.PostForm.Get("code") != "code-1" || CodeChallenge(r.PostForm.Get("code_verifier")) != m.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			writeJSON(w, map[string]string{"error": "invalid_grant"})
+			return
+		}
+
+		writeJSON(w, TokenResponse{AccessToken: "access", TokenType: "Bearer", IDToken: m.sign(m.claims)})
+	})
+
+	m.server = httptest.NewServer(mux)
+	t.Cleanup(m.server.Close)
+
+	return m
+}
+
+// authorize records the code challenge of an authorization request.
+func (m *mockProvider) authorize(authURL string, claims map[string]interface{}) {
+	u, err := url.Parse(authURL)
+	require.NoError(m.t, err)
+	require.Equal(m.t, "S256", u.Query().Get("code_challenge_method"))
+
+	m.challenge = u.Query().Get("code_challenge")
+	m.claims = claims
+	m.claims["nonce"] = u.Query().Get("nonce")
+}
+
+// validClaims returns valid claims for the test client.
+func (m *mockProvider) validClaims() map[string]interface{} {
+	now := time.Now()
+
+	return map[string]interface{}{
+		"iss":    m.server.URL,
+		"sub":    "user-1",
+		"aud":    testClientID,
+		"exp":    now.Add(time.Hour).Unix(),
+		"iat":    now.Unix(),
+		"email":  "alice@example.com",
+		"groups": []string{"office", "time-admins"},
+	}
+}
+
+func (m *mockProvider) sign(claims map[string]interface{}) string {
+	return signToken(m.t, m.key, m.kid, claims)
+}
+
+func signToken(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]interface{}) string {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": kid})
+	require.NoError(t, err)
+
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	require.NoError(t, err)
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func newTestProvider(m *mockProvider) *Provider {
+	return NewProvider(Config{
+		Issuer:      m.server.URL,
+		ClientID:    testClientID,
+		RedirectURL: "http://localhost/callback",
+		Scopes:      []string{"openid", "email", "groups"},
+	}, m.server.Client())
+}
+
+func TestAuthorizationCodeFlow(t *testing.T) {
+	m := newMockProvider(t)
+	p := newTestProvider(m)
+	ctx := context.Background()
+
+	verifier := NewCodeVerifier()
+	authURL, err := p.AuthCodeURL(ctx, "state-1", "nonce-1", CodeChallenge(verifier))
+	require.NoError(t, err)
+	require.Contains(t, authURL, m.server.URL+"/authorize?")
+
+	m.authorize(authURL, m.validClaims())
+
+	_, err = p.Exchange(ctx, "code-1", NewCodeVerifier())
+	require.Error(t, err)
+
+	token, err := p.Exchange(ctx, "code-1", verifier)
+	require.NoError(t, err)
+
+	claims, err := p.VerifyIDToken(ctx, token.IDToken, "nonce-1")
+	require.NoError(t, err)
+	require.Equal(t, "user-1", claims.Subject)
+	require.Equal(t, "alice@example.com", claims.Email)
+	require.Equal(t, []string{"office", "time-admins"}, claims.Values("groups"))
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	m := newMockProvider(t)
+	p := newTestProvider(m)
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name  string
+		token func() string
+		err   error
+	}{
+		{
+			name: "OK",
+			token: func() string {
+				return m.sign(m.validClaims())
+			},
+		},
+		{
+			name: "AudienceList",
+			token: func() string {
+				claims := m.validClaims()
+				claims["aud"] = []string{"other", testClientID}
+				return m.sign(claims)
+			},
+		},
+		{
+			name: "WrongAudience",
+			token: func() string {
+				claims := m.validClaims()
+				claims["aud"] = "other"
+				return m.sign(claims)
+			},
+			err: ErrInvalidAudience,
+		},
+		{
+			name: "WrongIssuer",
+			token: func() string {
+				claims := m.validClaims()
+				claims["iss"] = "https://evil.example.com"
+				return m.sign(claims)
+			},
+			err: ErrInvalidIssuer,
+		},
+		{
+			name: "Expired",
+			token: func() string {
+				claims := m.validClaims()
+				claims["exp"] = time.Now().Add(-time.Hour).Unix()
+				return m.sign(claims)
+			},
+			err: ErrExpiredToken,
+		},
+		{
+			name: "WrongNonce",
+			token: func() string {
+				claims := m.validClaims()
+				claims["nonce"] = "other"
+				return m.sign(claims)
+			},
+			err: ErrInvalidNonce,
+		},
+		{
+			name: "WrongKey",
+			token: func() string {
+				return signToken(t, otherKey, m.kid, m.validClaims())
+			},
+			err: ErrInvalidSignature,
+		},
+		{
+			name: "UnknownKey",
+			token: func() string {
+				return signToken(t, otherKey, "key-2", m.validClaims())
+			},
+			err: ErrUnknownKey,
+		},
+		{
+			name: "Malformed",
+			token: func() string {
+				return "not.a-token"
+			},
+			err: ErrMalformedToken,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := p.VerifyIDToken(context.Background(), tc.token(), "")
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestRoleMapping(t *testing.T) {
+	mapping, err := ParseRoleMapping("groups", "office=manager, time-admins=admin")
+	require.NoError(t, err)
+
+	priority := []string{"admin", "manager", "kiosk", "worker"}
+
+	testCases := []struct {
+		name   string
+		groups interface{}
+		role   string
+		ok     bool
+	}{
+		{name: "HighestRole", groups: []interface{}{"office", "time-admins"}, role: "admin", ok: true},
+		{name: "SingleValue", groups: "office", role: "manager", ok: true},
+		{name: "NoMatch", groups: []interface{}{"sales"}},
+		{name: "NoClaim"},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			claims := &Claims{Raw: map[string]interface{}{}}
+			if tc.groups != nil {
+				claims.Raw["groups"] = tc.groups
+			}
+
+			role, ok := mapping.Role(claims, priority)
+			require.Equal(t, tc.ok, ok)
+			require.Equal(t, tc.role, role)
+		})
+	}
+
+	_, err = ParseRoleMapping("groups", "office")
+	require.Error(t, err)
+}
@@ -0,0 +1,22 @@
+package oidc
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+
+	"github.com/alexanderbkl/vidre-back/pkg/rnd"
+)
+
+// CodeVerifierLength is the number of characters of a PKCE code verifier (RFC 7636 allows 43 to 128).
+const CodeVerifierLength = 64
+
+// NewCodeVerifier returns a random PKCE code verifier.
+func NewCodeVerifier() string {
+	return rnd.GenerateRandomString(CodeVerifierLength)
+}
+
+// CodeChallenge returns the S256 code challenge of the verifier.
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,53 @@
+package oidc
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RoleMapping maps the values of a claim, e.g. group names, to roles.
+type RoleMapping struct {
+	Claim string
+	Roles map[string]string
+}
+
+// ParseRoleMapping parses a comma separated list of value=role pairs,
+// e.g. "time-admins=admin,office=manager".
+func ParseRoleMapping(claim, s string) (RoleMapping, error) {
+	mapping := RoleMapping{Claim: claim, Roles: make(map[string]string)}
+
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		value, role, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(value) == "" || strings.TrimSpace(role) == "" {
+			return RoleMapping{}, fmt.Errorf("oidc: invalid role mapping %q", pair)
+		}
+
+		mapping.Roles[strings.TrimSpace(value)] = strings.TrimSpace(role)
+	}
+
+	return mapping, nil
+}
+
+// Role returns the first role of priority that is mapped from a value of the claim.
+func (m RoleMapping) Role(claims *Claims, priority []string) (string, bool) {
+	granted := make(map[string]bool)
+
+	for _, value := range claims.Values(m.Claim) {
+		if role, ok := m.Roles[value]; ok {
+			granted[role] = true
+		}
+	}
+
+	for _, role := range priority {
+		if granted[role] {
+			return role, true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,278 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/o1egl/paseto"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// Key purposes, see https://github.com/paseto-standard/paseto-spec.
+const (
+	PurposeLocal  = "local"
+	PurposePublic = "public"
+)
+
+// LegacyKeyID is the id of the key that verifies tokens without a key id in the footer.
+const LegacyKeyID = "default"
+
+var (
+	ErrNoCurrentKey = errors.New("keyring has no current key")
+	ErrUnknownKey   = errors.New("token key is unknown")
+)
+
+// Key is a token key with an id that is stored in the token footer.
+type Key struct {
+	ID      string
+	Purpose string
+	// Material is the 32 byte symmetric key of local keys
+	// or the 64 byte Ed25519 private key of public keys.
+	Material []byte
+}
+
+// Validate checks the key material size for its purpose.
+func (key Key) Validate() error {
+	if key.ID == "" {
+		return fmt.Errorf("key id is missing")
+	}
+
+	switch key.Purpose {
+	case PurposeLocal:
+		if len(key.Material) != chacha20poly1305.KeySize {
+			return fmt.Errorf("invalid key size: local key %s must be exactly %d bytes", key.ID, chacha20poly1305.KeySize)
+		}
+	case PurposePublic:
+		if len(key.Material) != ed25519.PrivateKeySize {
+			return fmt.Errorf("invalid key size: public key %s must be exactly %d bytes", key.ID, ed25519.PrivateKeySize)
+		}
+	default:
+		return fmt.Errorf("unknown purpose %s of key %s", key.Purpose, key.ID)
+	}
+
+	return nil
+}
+
+// GenerateKey creates a new random key for the given purpose.
+func GenerateKey(id, purpose string) (Key, error) {
+	key := Key{ID: id, Purpose: purpose}
+
+	switch purpose {
+	case PurposeLocal:
+		material := make([]byte, chacha20poly1305.KeySize)
+		if _, err := rand.Read(material); err != nil {
+			return key, err
+		}
+		key.Material = material
+	case PurposePublic:
+		_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return key, err
+		}
+		key.Material = privateKey
+	default:
+		return key, fmt.Errorf("unknown purpose %s", purpose)
+	}
+
+	return key, nil
+}
+
+// keyFooter is the token footer that identifies the key.
+type keyFooter struct {
+	KeyID string `json:"kid"`
+}
+
+// Keyring holds the key that creates new tokens and the keys that still verify older ones.
+// It can be updated at runtime, e.g. when a new key is promoted.
+type Keyring struct {
+	mu      sync.RWMutex
+	current string
+	keys    map[string]Key
+}
+
+// NewKeyring creates a keyring that signs with the current key and verifies
+// tokens of all given keys. The current key must be one of them.
+func NewKeyring(current string, keys []Key) (*Keyring, error) {
+	ring := &Keyring{}
+
+	if err := ring.Set(current, keys); err != nil {
+		return nil, err
+	}
+
+	return ring, nil
+}
+
+// Set replaces all keys of the keyring.
+func (ring *Keyring) Set(current string, keys []Key) error {
+	m := make(map[string]Key, len(keys))
+
+	for _, key := range keys {
+		if err := key.Validate(); err != nil {
+			return err
+		}
+		m[key.ID] = key
+	}
+
+	if _, ok := m[current]; !ok {
+		return ErrNoCurrentKey
+	}
+
+	ring.mu.Lock()
+	defer ring.mu.Unlock()
+
+	ring.current = current
+	ring.keys = m
+
+	return nil
+}
+
+// Current returns the key that creates new tokens.
+func (ring *Keyring) Current() (Key, error) {
+	ring.mu.RLock()
+	defer ring.mu.RUnlock()
+
+	key, ok := ring.keys[ring.current]
+	if !ok {
+		return Key{}, ErrNoCurrentKey
+	}
+
+	return key, nil
+}
+
+// Key returns the key with the given id.
+func (ring *Keyring) Key(id string) (Key, bool) {
+	ring.mu.RLock()
+	defer ring.mu.RUnlock()
+
+	key, ok := ring.keys[id]
+	return key, ok
+}
+
+// Keys returns all keys sorted by id.
+func (ring *Keyring) Keys() []Key {
+	ring.mu.RLock()
+	defer ring.mu.RUnlock()
+
+	keys := make([]Key, 0, len(ring.keys))
+	for _, key := range ring.keys {
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ID < keys[j].ID })
+
+	return keys
+}
+
+// KeyringMaker creates PASETO tokens with the current key of a keyring.
+// The key id is stored in the token footer so that tokens created with
+// previous keys still verify until the key is removed from the keyring.
+type KeyringMaker struct {
+	paseto *paseto.V2
+	ring   *Keyring
+}
+
+// NewKeyringMaker creates a new KeyringMaker
+func NewKeyringMaker(ring *Keyring) (Maker, error) {
+	if _, err := ring.Current(); err != nil {
+		return nil, err
+	}
+
+	maker := &KeyringMaker{
+		paseto: paseto.NewV2(),
+		ring:   ring,
+	}
+
+	return maker, nil
+}
+
+// CreateToken creates a new token for a specific username and duration
+func (maker *KeyringMaker) CreateToken(user_id uint, user_uid, user_name string, duration time.Duration, opts ...PayloadOption) (string, *Payload, error) {
+	payload, err := NewPayload(user_id, user_uid, user_name, duration, opts...)
+	if err != nil {
+		return "", payload, err
+	}
+
+	key, err := maker.ring.Current()
+	if err != nil {
+		return "", payload, err
+	}
+
+	footer := keyFooter{KeyID: key.ID}
+
+	var token string
+	switch key.Purpose {
+	case PurposePublic:
+		token, err = maker.paseto.Sign(ed25519.PrivateKey(key.Material), payload, footer)
+	default:
+		token, err = maker.paseto.Encrypt(key.Material, payload, footer)
+	}
+
+	return token, payload, err
+}
+
+// VerifyToken checks if the token is valid or not
+func (maker *KeyringMaker) VerifyToken(token string) (*Payload, error) {
+	var footer keyFooter
+	if err := paseto.ParseFooter(token, &footer); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if footer.KeyID == "" {
+		footer.KeyID = LegacyKeyID
+	}
+
+	key, ok := maker.ring.Key(footer.KeyID)
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+
+	payload := &Payload{}
+
+	var err error
+	switch {
+	case key.Purpose == PurposeLocal && strings.HasPrefix(token, "v2.local."):
+		err = maker.paseto.Decrypt(token, key.Material, payload, nil)
+	case key.Purpose == PurposePublic && strings.HasPrefix(token, "v2.public."):
+		err = maker.paseto.Verify(token, ed25519.PrivateKey(key.Material).Public(), payload, nil)
+	default:
+		err = ErrUnknownKey
+	}
+
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	err = payload.Valid()
+	if err != nil {
+		return nil, err
+	}
+
+	return payload, nil
+}
+
+// PublicKeys returns the public keys of all public keys in the keyring.
+func (maker *KeyringMaker) PublicKeys() []PublicKey {
+	result := []PublicKey{}
+
+	for _, key := range maker.ring.Keys() {
+		if key.Purpose != PurposePublic {
+			continue
+		}
+
+		result = append(result, PublicKey{
+			ID:      key.ID,
+			Version: "v2",
+			Purpose: PurposePublic,
+			Key:     base64.RawURLEncoding.EncodeToString(ed25519.PrivateKey(key.Material).Public().(ed25519.PublicKey)),
+		})
+	}
+
+	return result
+}
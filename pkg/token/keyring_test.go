@@ -0,0 +1,166 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/alexanderbkl/vidre-back/pkg/rnd"
+	"github.com/o1egl/paseto"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestKey(t *testing.T, id, purpose string) Key {
+	key, err := GenerateKey(id, purpose)
+	require.NoError(t, err)
+	return key
+}
+
+func TestKeyringMaker(t *testing.T) {
+	for _, purpose := range []string{PurposeLocal, PurposePublic} {
+		purpose := purpose
+
+		t.Run(purpose, func(t *testing.T) {
+			first := newTestKey(t, "k1", purpose)
+			ring, err := NewKeyring(first.ID, []Key{first})
+			require.NoError(t, err)
+
+			maker, err := NewKeyringMaker(ring)
+			require.NoError(t, err)
+
+			oldToken, _, err := maker.CreateToken(1, "uid", "name", time.Minute)
+			require.NoError(t, err)
+			require.Contains(t, oldToken, "v2."+purpose+".")
+
+			// Distribute and promote a second key.
+			second := newTestKey(t, "k2", purpose)
+			require.NoError(t, ring.Set(second.ID, []Key{first, second}))
+
+			newToken, _, err := maker.CreateToken(1, "uid", "name", time.Minute)
+			require.NoError(t, err)
+
+			_, err = maker.VerifyToken(oldToken)
+			require.NoError(t, err)
+			payload, err := maker.VerifyToken(newToken)
+			require.NoError(t, err)
+			require.Equal(t, "name", payload.UserName)
+
+			// Retire the first key.
+			require.NoError(t, ring.Set(second.ID, []Key{second}))
+
+			_, err = maker.VerifyToken(oldToken)
+			require.EqualError(t, err, ErrInvalidToken.Error())
+			_, err = maker.VerifyToken(newToken)
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestKeyringMakerLegacyToken(t *testing.T) {
+	secret := rnd.GenerateRandomString(32)
+
+	legacy, err := NewPasetoMaker(secret)
+	require.NoError(t, err)
+
+	token, _, err := legacy.CreateToken(1, "uid", "name", time.Minute)
+	require.NoError(t, err)
+
+	ring, err := NewKeyring(LegacyKeyID, []Key{{ID: LegacyKeyID, Purpose: PurposeLocal, Material: []byte(secret)}})
+	require.NoError(t, err)
+
+	maker, err := NewKeyringMaker(ring)
+	require.NoError(t, err)
+
+	payload, err := maker.VerifyToken(token)
+	require.NoError(t, err)
+	require.Equal(t, "name", payload.UserName)
+}
+
+func TestKeyringMakerMixedPurposes(t *testing.T) {
+	local := newTestKey(t, "local", PurposeLocal)
+	public := newTestKey(t, "public", PurposePublic)
+
+	ring, err := NewKeyring(local.ID, []Key{local, public})
+	require.NoError(t, err)
+
+	maker, err := NewKeyringMaker(ring)
+	require.NoError(t, err)
+
+	localToken, _, err := maker.CreateToken(1, "uid", "name", time.Minute)
+	require.NoError(t, err)
+
+	require.NoError(t, ring.Set(public.ID, []Key{local, public}))
+
+	publicToken, _, err := maker.CreateToken(1, "uid", "name", time.Minute)
+	require.NoError(t, err)
+
+	_, err = maker.VerifyToken(localToken)
+	require.NoError(t, err)
+	_, err = maker.VerifyToken(publicToken)
+	require.NoError(t, err)
+
+	keys := maker.(PublicKeyProvider).PublicKeys()
+	require.Len(t, keys, 1)
+	require.Equal(t, public.ID, keys[0].ID)
+}
+
+func TestKeyringMakerPublicKeys(t *testing.T) {
+	key := newTestKey(t, "public", PurposePublic)
+
+	ring, err := NewKeyring(key.ID, []Key{key})
+	require.NoError(t, err)
+
+	maker, err := NewKeyringMaker(ring)
+	require.NoError(t, err)
+
+	token, _, err := maker.CreateToken(1, "uid", "name", time.Minute)
+	require.NoError(t, err)
+
+	keys := maker.(PublicKeyProvider).PublicKeys()
+	require.Len(t, keys, 1)
+	require.Equal(t, PublicKey{ID: key.ID, Version: "v2", Purpose: PurposePublic, Key: keys[0].Key}, keys[0])
+
+	published, err := base64.RawURLEncoding.DecodeString(keys[0].Key)
+	require.NoError(t, err)
+	require.Equal(t, []byte(ed25519.PrivateKey(key.Material).Public().(ed25519.PublicKey)), published)
+
+	// A third party only needs the published key to verify the token.
+	var payload Payload
+	require.NoError(t, paseto.NewV2().Verify(token, ed25519.PublicKey(published), &payload, nil))
+	require.Equal(t, "name", payload.UserName)
+}
+
+func TestKeyringMakerWrongPublicKey(t *testing.T) {
+	key := newTestKey(t, "public", PurposePublic)
+	other := newTestKey(t, "public", PurposePublic)
+
+	ring, err := NewKeyring(key.ID, []Key{key})
+	require.NoError(t, err)
+	maker, err := NewKeyringMaker(ring)
+	require.NoError(t, err)
+
+	otherRing, err := NewKeyring(other.ID, []Key{other})
+	require.NoError(t, err)
+	otherMaker, err := NewKeyringMaker(otherRing)
+	require.NoError(t, err)
+
+	// The key id matches, but the token is signed with another key.
+	token, _, err := otherMaker.CreateToken(1, "uid", "name", time.Minute)
+	require.NoError(t, err)
+
+	payload, err := maker.VerifyToken(token)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+	require.Nil(t, payload)
+}
+
+func TestKeyringValidation(t *testing.T) {
+	_, err := NewKeyring("missing", []Key{newTestKey(t, "k1", PurposeLocal)})
+	require.ErrorIs(t, err, ErrNoCurrentKey)
+
+	_, err = NewKeyring("short", []Key{{ID: "short", Purpose: PurposeLocal, Material: []byte("short")}})
+	require.Error(t, err)
+
+	_, err = GenerateKey("k1", "unknown")
+	require.Error(t, err)
+}
@@ -7,8 +7,23 @@ import (
 // Maker is an interface for managing tokens
 type Maker interface {
 	// CreateToken creates a new token for a specific username and duration
-	CreateToken(user_id uint, user_uid, user_name string, duration time.Duration) (string, *Payload, error)
+	// Options can be used to set optional payload data, such as the session.
+	CreateToken(user_id uint, user_uid, user_name string, duration time.Duration, opts ...PayloadOption) (string, *Payload, error)
 
 	// VerifyToken checks if the token is valid or not
 	VerifyToken(token string) (*Payload, error)
 }
+
+// PublicKey describes a key that can be used to verify v2.public tokens.
+type PublicKey struct {
+	ID      string `json:"kid,omitempty"`
+	Version string `json:"version"`
+	Purpose string `json:"purpose"`
+	Key     string `json:"key"`
+}
+
+// PublicKeyProvider is implemented by makers whose tokens can be verified without the signing key.
+type PublicKeyProvider interface {
+	// PublicKeys returns the keys that verify tokens created by the maker.
+	PublicKeys() []PublicKey
+}
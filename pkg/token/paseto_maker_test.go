@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/alexanderbkl/vidre-back/pkg/rnd"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 )
 
@@ -29,11 +30,38 @@ func TestPasetoMaker(t *testing.T) {
 	require.NotEmpty(t, token)
 
 	require.NotZero(t, payload.TokenID)
+	require.Equal(t, PurposeAccess, payload.Purpose)
 	require.Equal(t, user_name, payload.UserName)
 	require.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second)
 	require.WithinDuration(t, expiredAt, payload.ExpiredAt, time.Second)
 }
 
+func TestPasetoMakerWithSession(t *testing.T) {
+	maker, err := NewPasetoMaker(rnd.GenerateRandomString(32))
+	require.NoError(t, err)
+
+	sessionID := uuid.New()
+
+	token, _, err := maker.CreateToken(1, rnd.GenerateRandomString(6), rnd.GenerateRandomString(6), time.Minute, WithSession(sessionID))
+	require.NoError(t, err)
+
+	payload, err := maker.VerifyToken(token)
+	require.NoError(t, err)
+	require.Equal(t, sessionID, payload.SessionID)
+}
+
+func TestPasetoMakerWithPurpose(t *testing.T) {
+	maker, err := NewPasetoMaker(rnd.GenerateRandomString(32))
+	require.NoError(t, err)
+
+	token, _, err := maker.CreateToken(1, rnd.GenerateRandomString(6), rnd.GenerateRandomString(6), time.Minute, WithPurpose(PurposeRefresh))
+	require.NoError(t, err)
+
+	payload, err := maker.VerifyToken(token)
+	require.NoError(t, err)
+	require.Equal(t, PurposeRefresh, payload.Purpose)
+}
+
 func TestExpiredPasetoToken(t *testing.T) {
 	maker, err := NewPasetoMaker(rnd.GenerateRandomString(32))
 	require.NoError(t, err)
@@ -11,20 +11,63 @@ import (
 var (
 	ErrInvalidToken = errors.New("token is invalid")
 	ErrExpiredToken = errors.New("token has expired")
+	ErrWrongPurpose = errors.New("token cannot be used for this purpose")
+)
+
+// Token purposes. Access tokens authorize api requests, refresh tokens can
+// only be exchanged for new tokens.
+const (
+	PurposeAccess  = "access"
+	PurposeRefresh = "refresh"
 )
 
 // Payload contains the payload data of the token
 type Payload struct {
 	TokenID   uuid.UUID `json:"token_id"`
+	Purpose   string    `json:"purpose"`
+	SessionID uuid.UUID `json:"session_id"`
 	UserID    uint      `json:"id"`
 	UserUID   string    `json:"uid"`
 	UserName  string    `json:"name"`
+	Role      string    `json:"role"`
+	DeviceID  uint      `json:"device_id,omitempty"`
 	IssuedAt  time.Time `json:"issued_at"`
 	ExpiredAt time.Time `json:"expired_at"`
 }
 
+// PayloadOption sets optional token payload data.
+type PayloadOption func(payload *Payload)
+
+// WithPurpose sets the purpose of the token, which is PurposeAccess by default.
+func WithPurpose(purpose string) PayloadOption {
+	return func(payload *Payload) {
+		payload.Purpose = purpose
+	}
+}
+
+// WithSession binds the token to a login session.
+func WithSession(sessionID uuid.UUID) PayloadOption {
+	return func(payload *Payload) {
+		payload.SessionID = sessionID
+	}
+}
+
+// WithRole sets the role of the token user.
+func WithRole(role string) PayloadOption {
+	return func(payload *Payload) {
+		payload.Role = role
+	}
+}
+
+// WithDevice marks the token as issued to an enrolled device.
+func WithDevice(deviceID uint) PayloadOption {
+	return func(payload *Payload) {
+		payload.DeviceID = deviceID
+	}
+}
+
 // NewPayload creates a new token payload with a specific username and duration
-func NewPayload(user_id uint, user_uid, user_name string, duration time.Duration) (*Payload, error) {
+func NewPayload(user_id uint, user_uid, user_name string, duration time.Duration, opts ...PayloadOption) (*Payload, error) {
 	tokenID, err := uuid.NewRandom()
 	if err != nil {
 		return nil, err
@@ -32,12 +75,18 @@ func NewPayload(user_id uint, user_uid, user_name string, duration time.Duration
 
 	payload := &Payload{
 		TokenID:   tokenID,
+		Purpose:   PurposeAccess,
 		UserID:    user_id,
 		UserUID:   user_uid,
 		UserName:  user_name,
 		IssuedAt:  time.Now(),
 		ExpiredAt: time.Now().Add(duration),
 	}
+
+	for _, opt := range opts {
+		opt(payload)
+	}
+
 	return payload, nil
 }
 
@@ -0,0 +1,139 @@
+/*
+Package totp implements time-based one-time passwords as described in
+RFC 6238, compatible with common authenticator apps.
+
+Only the default parameters are supported: HMAC-SHA1, 6 digits and a
+30 second period.
+*/
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/alexanderbkl/vidre-back/pkg/rnd"
+)
+
+// Default code parameters.
+const (
+	Digits     = 6
+	Period     = 30
+	SecretSize = 20
+)
+
+var ErrInvalidSecret = errors.New("totp: invalid secret")
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret returns a new random base32 encoded secret.
+func GenerateSecret() (string, error) {
+	b, err := rnd.GenerateRandomBytes(SecretSize)
+	if err != nil {
+		return "", err
+	}
+
+	return encoding.EncodeToString(b), nil
+}
+
+// DecodeSecret decodes a base32 secret, ignoring case, spaces and padding.
+func DecodeSecret(secret string) ([]byte, error) {
+	secret = strings.ToUpper(strings.ReplaceAll(secret, " ", ""))
+	secret = strings.TrimRight(secret, "=")
+
+	b, err := encoding.DecodeString(secret)
+	if err != nil || len(b) == 0 {
+		return nil, ErrInvalidSecret
+	}
+
+	return b, nil
+}
+
+// HOTP returns the RFC 4226 one-time password for the counter.
+func HOTP(key []byte, counter uint64, digits int) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < digits; i++ {
+		mod *= 10
+	}
+
+	return fmt.Sprintf("%0*d", digits, value%mod)
+}
+
+// Counter returns the time step of t.
+func Counter(t time.Time) uint64 {
+	return uint64(t.Unix()) / Period
+}
+
+// Code returns the code of the secret at time t.
+func Code(secret string, t time.Time) (string, error) {
+	key, err := DecodeSecret(secret)
+	if err != nil {
+		return "", err
+	}
+
+	return HOTP(key, Counter(t), Digits), nil
+}
+
+// Verify checks the code against the time steps within skew periods of t
+// and returns the matching counter. Callers should reject counters that are
+// not greater than the last accepted one, so that a code cannot be reused.
+func Verify(secret, code string, t time.Time, skew int) (uint64, bool) {
+	key, err := DecodeSecret(secret)
+	if err != nil {
+		return 0, false
+	}
+
+	code = strings.ReplaceAll(code, " ", "")
+
+	if len(code) != Digits {
+		return 0, false
+	}
+
+	now := Counter(t)
+
+	for i := -skew; i <= skew; i++ {
+		counter := now + uint64(i)
+
+		if subtle.ConstantTimeCompare([]byte(HOTP(key, counter, Digits)), []byte(code)) == 1 {
+			return counter, true
+		}
+	}
+
+	return 0, false
+}
+
+// URI returns the otpauth provisioning URI of the secret, usually shown as a QR code.
+func URI(issuer, account, secret string) string {
+	u := url.URL{
+		Scheme: "otpauth",
+		Host:   "totp",
+		Path:   "/" + issuer + ":" + account,
+	}
+
+	q := url.Values{}
+	q.Set("secret", secret)
+	q.Set("issuer", issuer)
+	q.Set("algorithm", "SHA1")
+	q.Set("digits", fmt.Sprint(Digits))
+	q.Set("period", fmt.Sprint(Period))
+	u.RawQuery = q.Encode()
+
+	return u.String()
+}
@@ -0,0 +1,108 @@
+package totp
+
+import (
+	"encoding/base32"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// rfcKey is the shared secret of the RFC 4226 and RFC 6238 test vectors.
+var rfcKey = []byte("12345678901234567890")
+
+func TestHOTP(t *testing.T) {
+	// RFC 4226, Appendix D.
+	expected := []string{
+		"755224", "287082", "359152", "969429", "338314",
+		"254676", "287922", "162583", "399871", "520489",
+	}
+
+	for counter, code := range expected {
+		require.Equal(t, code, HOTP(rfcKey, uint64(counter), 6))
+	}
+}
+
+func TestTOTPVectors(t *testing.T) {
+	// RFC 6238, Appendix B (SHA1).
+	testCases := []struct {
+		unix int64
+		code string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.code, HOTP(rfcKey, Counter(time.Unix(tc.unix, 0)), 8))
+	}
+}
+
+func TestCode(t *testing.T) {
+	secret := base32.StdEncoding.EncodeToString(rfcKey)
+
+	code, err := Code(secret, time.Unix(59, 0))
+	require.NoError(t, err)
+	require.Equal(t, "287082", code)
+
+	_, err = Code("not base32!", time.Unix(59, 0))
+	require.ErrorIs(t, err, ErrInvalidSecret)
+}
+
+func TestVerify(t *testing.T) {
+	secret, err := GenerateSecret()
+	require.NoError(t, err)
+
+	key, err := DecodeSecret(secret)
+	require.NoError(t, err)
+	require.Len(t, key, SecretSize)
+
+	now := time.Unix(1700000000, 0)
+	current := Counter(now)
+
+	testCases := []struct {
+		name    string
+		counter uint64
+		skew    int
+		ok      bool
+	}{
+		{name: "Current", counter: current, ok: true},
+		{name: "PreviousWithSkew", counter: current - 1, skew: 1, ok: true},
+		{name: "NextWithSkew", counter: current + 1, skew: 1, ok: true},
+		{name: "PreviousWithoutSkew", counter: current - 1},
+		{name: "OutsideSkew", counter: current - 2, skew: 1},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			counter, ok := Verify(secret, HOTP(key, tc.counter, Digits), now, tc.skew)
+			require.Equal(t, tc.ok, ok)
+			if tc.ok {
+				require.Equal(t, tc.counter, counter)
+			}
+		})
+	}
+
+	_, ok := Verify(secret, "12345", now, 1)
+	require.False(t, ok)
+}
+
+func TestURI(t *testing.T) {
+	uri := URI("Vidre", "alice", "JBSWY3DPEHPK3PXP")
+
+	u, err := url.Parse(uri)
+	require.NoError(t, err)
+	require.Equal(t, "otpauth", u.Scheme)
+	require.Equal(t, "totp", u.Host)
+	require.Equal(t, "/Vidre:alice", u.Path)
+	require.Equal(t, "JBSWY3DPEHPK3PXP", u.Query().Get("secret"))
+	require.Equal(t, "Vidre", u.Query().Get("issuer"))
+	require.Equal(t, "6", u.Query().Get("digits"))
+}